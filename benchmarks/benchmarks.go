@@ -2,8 +2,11 @@ package benchmarks
 
 import (
 	"context"
+	"math/rand"
 	"sync"
 	"testing"
+
+	"github.com/thetechpanda/typedmap"
 )
 
 // use is an helper function that does nothing with the input.
@@ -33,3 +36,47 @@ func benchmarkConcurrentInt(b *testing.B, f func(n, i, j int)) {
 	cancel()
 	wg.Wait()
 }
+
+// zipfTrace returns a trace of n keys in [0, keys) following a Zipf distribution with parameter s > 1,
+// the trace is generated using a fixed seed so that every run replays the same keys.
+func zipfTrace(n int, s float64, keys uint64) []int {
+	z := rand.NewZipf(rand.New(rand.NewSource(1)), s, 1, keys-1)
+	trace := make([]int, n)
+	for i := range trace {
+		trace[i] = int(z.Uint64())
+	}
+	return trace
+}
+
+// scanTrace returns a Zipf trace, as returned by zipfTrace, where every scanEvery keys a sequential
+// scan of scanLength keys never seen before is inserted.
+func scanTrace(n int, s float64, keys uint64, scanEvery, scanLength int) []int {
+	trace := make([]int, 0, n+n/scanEvery*scanLength)
+	next := int(keys)
+	for i, key := range zipfTrace(n, s, keys) {
+		trace = append(trace, key)
+		if i%scanEvery == scanEvery-1 {
+			for j := 0; j < scanLength; j++ {
+				trace = append(trace, next)
+				next++
+			}
+		}
+	}
+	return trace
+}
+
+// benchmarkHitRatio replays trace against m, loading every key and storing it on a miss,
+// and reports the percentage of loads that found the key in the map as the "hit%" metric.
+func benchmarkHitRatio(b *testing.B, m typedmap.TypedMap[int, int], trace []int) {
+	hits := 0
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		key := trace[n%len(trace)]
+		if _, ok := m.Load(key); ok {
+			hits++
+			continue
+		}
+		m.Store(key, key)
+	}
+	b.ReportMetric(float64(hits)*100/float64(b.N), "hit%")
+}
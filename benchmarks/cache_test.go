@@ -0,0 +1,49 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/thetechpanda/typedmap"
+)
+
+var cachePolicies = []typedmap.Policy{
+	typedmap.PolicyLRU,
+	typedmap.PolicyLFU,
+	typedmap.PolicyARC,
+	typedmap.PolicyS3FIFO,
+	typedmap.PolicyWTinyLFU,
+}
+
+// cacheCapacity is the capacity of the caches used by the hit ratio benchmarks, 1% of the keys in the traces.
+const cacheCapacity = 1000
+
+func BenchmarkCacheHitRatioZipf(b *testing.B) {
+	trace := zipfTrace(1_000_000, 1.1, 100*cacheCapacity)
+	for _, policy := range cachePolicies {
+		b.Run(policy.String(), func(b *testing.B) {
+			benchmarkHitRatio(b, typedmap.NewCache[int, int](cacheCapacity, typedmap.WithPolicy(policy)), trace)
+		})
+	}
+}
+
+func BenchmarkCacheHitRatioZipfScan(b *testing.B) {
+	trace := scanTrace(1_000_000, 1.1, 100*cacheCapacity, 10*cacheCapacity, 2*cacheCapacity)
+	for _, policy := range cachePolicies {
+		b.Run(policy.String(), func(b *testing.B) {
+			benchmarkHitRatio(b, typedmap.NewCache[int, int](cacheCapacity, typedmap.WithPolicy(policy)), trace)
+		})
+	}
+}
+
+func BenchmarkCacheConcurrentOperations(b *testing.B) {
+	for _, policy := range cachePolicies {
+		b.Run(policy.String(), func(b *testing.B) {
+			m := typedmap.NewCache[int, int](50, typedmap.WithPolicy(policy))
+			benchmarkConcurrentInt(b, func(n, i, j int) {
+				m.Store(i, j)
+				m.Load(i)
+				m.Delete(j)
+			})
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/thetechpanda/typedmap"
+)
+
+// record is a comparable struct value, compared field by field by reflect.DeepEqual.
+type record struct {
+	ID    int
+	Name  string
+	Score float64
+}
+
+func BenchmarkTypedMapCompareAndSwap(b *testing.B) {
+	m := typedmap.New[int, int]()
+	m.Store(0, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.CompareAndSwap(0, i, i+1)
+	}
+}
+
+func BenchmarkComparableMapCompareAndSwap(b *testing.B) {
+	m := typedmap.NewComparable[int, int]()
+	m.Store(0, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.CompareAndSwap(0, i, i+1)
+	}
+}
+
+func BenchmarkTypedMapCompareAndSwapStruct(b *testing.B) {
+	m := typedmap.New[int, record]()
+	m.Store(0, record{Name: "name"})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.CompareAndSwap(0, record{ID: i, Name: "name"}, record{ID: i + 1, Name: "name"})
+	}
+}
+
+func BenchmarkComparableMapCompareAndSwapStruct(b *testing.B) {
+	m := typedmap.NewComparable[int, record]()
+	m.Store(0, record{Name: "name"})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.CompareAndSwap(0, record{ID: i, Name: "name"}, record{ID: i + 1, Name: "name"})
+	}
+}
+
+func BenchmarkTypedMapCompareAndDelete(b *testing.B) {
+	m := typedmap.New[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.CompareAndDelete(i, i)
+	}
+}
+
+func BenchmarkComparableMapCompareAndDelete(b *testing.B) {
+	m := typedmap.NewComparable[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.CompareAndDelete(i, i)
+	}
+}
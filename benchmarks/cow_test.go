@@ -0,0 +1,110 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/thetechpanda/typedmap"
+)
+
+// readMostlyKeys is the number of keys of the maps used by the read-mostly benchmarks.
+const readMostlyKeys = 1000
+
+// readMostlyMap is the subset of functions shared by TypedMap and SyncMap used by the read-mostly benchmarks.
+type readMostlyMap interface {
+	Load(key int) (int, bool)
+	Store(key, value int)
+}
+
+// readMostlyMaps returns the maps compared by the read-mostly benchmarks, holding readMostlyKeys keys.
+func readMostlyMaps() map[string]readMostlyMap {
+	maps := map[string]readMostlyMap{
+		"TypedMap": typedmap.New[int, int](),
+		"SyncMap":  typedmap.NewSyncMap[int, int](),
+		"COWMap":   typedmap.NewCOW[int, int](nil),
+		"HashTrie": typedmap.NewHashTrie[int, int](),
+	}
+	for _, m := range maps {
+		for i := 0; i < readMostlyKeys; i++ {
+			m.Store(i, i)
+		}
+	}
+	return maps
+}
+
+// benchmarkReadMostly runs Load in parallel on m, one operation every writeEvery is a Store instead.
+// If writeEvery is 0 no value is stored.
+func benchmarkReadMostly(b *testing.B, m readMostlyMap, writeEvery int) {
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			if writeEvery > 0 && i%writeEvery == 0 {
+				m.Store(i%readMostlyKeys, i)
+				continue
+			}
+			v, _ := m.Load(i % readMostlyKeys)
+			noop(v)
+		}
+	})
+}
+
+func BenchmarkReadOnlyParallelLoad(b *testing.B) {
+	for _, name := range []string{"TypedMap", "SyncMap", "COWMap", "HashTrie"} {
+		b.Run(name, func(b *testing.B) {
+			benchmarkReadMostly(b, readMostlyMaps()[name], 0)
+		})
+	}
+}
+
+func BenchmarkReadMostlyParallelLoad(b *testing.B) {
+	for _, name := range []string{"TypedMap", "SyncMap", "COWMap", "HashTrie"} {
+		b.Run(name, func(b *testing.B) {
+			benchmarkReadMostly(b, readMostlyMaps()[name], 10000)
+		})
+	}
+}
+
+func BenchmarkCOWMapStore(b *testing.B) {
+	m := typedmap.NewCOW[int, int](nil)
+	for i := 0; i < readMostlyKeys; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Store(i%readMostlyKeys, i)
+	}
+}
+
+func BenchmarkCOWMapTransactionStore(b *testing.B) {
+	m := typedmap.NewCOW[int, int](nil)
+	for i := 0; i < readMostlyKeys; i++ {
+		m.Store(i, i)
+	}
+	// stores 100 values per transaction, reports the cost of a single store.
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 100 {
+		m.Transaction(func(tx typedmap.Tx[int, int]) error {
+			for j := i; j < i+100; j++ {
+				tx.Store(j%readMostlyKeys, j)
+			}
+			return nil
+		})
+	}
+}
+
+func BenchmarkCOWMapConcurrentStore(b *testing.B) {
+	m := typedmap.NewCOW[int, int](nil)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+	})
+}
+
+func BenchmarkCOWMapConcurrentOperations(b *testing.B) {
+	m := typedmap.NewCOW[int, int](nil)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+		m.Load(i)
+		m.Delete(i)
+	})
+}
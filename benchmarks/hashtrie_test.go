@@ -0,0 +1,86 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/thetechpanda/typedmap"
+)
+
+func BenchmarkHashTrieMapStoreAndDelete(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Delete(i)
+	}
+}
+
+func BenchmarkHashTrieMapRange(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	m.Range(func(k, v int) bool {
+		noop(k, v)
+		return true
+	})
+}
+
+func BenchmarkHashTrieMapAll(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for k, v := range m.All() {
+		noop(k, v)
+	}
+}
+
+func BenchmarkHashTrieMapLoad(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, _ := m.Load(i)
+		noop(v)
+	}
+}
+
+func BenchmarkHashTrieMapConcurrentOperations(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+		m.Load(i)
+		m.Delete(j)
+	})
+}
+
+func BenchmarkHashTrieMapConcurrentStore(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+	})
+}
+
+func BenchmarkHashTrieMapConcurrentSwap(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Swap(i, j)
+	})
+}
+
+func BenchmarkHashTrieMapConcurrentLoadOrStore(b *testing.B) {
+	m := typedmap.NewHashTrie[int, int]()
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		_, ok := m.LoadOrStore(i, j)
+		if !ok {
+			m.Delete(i)
+		}
+	})
+}
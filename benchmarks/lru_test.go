@@ -0,0 +1,159 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/thetechpanda/typedmap"
+)
+
+func BenchmarkLRUMapStoreAndDelete(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Delete(i)
+	}
+}
+
+func BenchmarkLRUMapRange(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	m.Range(func(k int, v int) bool {
+		noop(k, v)
+		return true
+	})
+}
+
+func BenchmarkLRUMapAll(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for k, v := range m.All() {
+		noop(k, v)
+	}
+}
+
+func BenchmarkLRUMapLoad(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, _ := m.Load(i)
+		noop(v)
+	}
+}
+
+func BenchmarkLRUMapEntries(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	keys, values := m.Entries()
+	noop(keys, values)
+}
+
+func BenchmarkLRUMapKeys(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	keys := m.Keys()
+	noop(keys)
+}
+
+func BenchmarkLRUMapValues(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	values := m.Values()
+	noop(values)
+}
+
+func BenchmarkLRUMapUpdate(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Update(i, func(v int, ok bool) int {
+			return v * i
+		})
+
+	}
+}
+
+func BenchmarkLRUMapUpdateRange(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	m.UpdateRange(func(k, i int) (int, bool) {
+		noop(k, i)
+		return i + 1, true
+	})
+}
+
+func BenchmarkLRUMapConcurrentOperations(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+		m.Load(i)
+		m.Delete(i)
+	})
+}
+
+func BenchmarkLRUMapConcurrentStore(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+	})
+}
+
+func BenchmarkLRUMapConcurrentSwap(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Swap(i, j)
+	})
+}
+
+func BenchmarkLRUMapConcurrentLoadOrStore(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		_, ok := m.LoadOrStore(i, j)
+		if !ok {
+			m.Delete(i)
+		}
+	})
+}
+
+func BenchmarkLRUMapConcurrentUpdate(b *testing.B) {
+	m := typedmap.NewLRU[int, int](b.N)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Update(i, func(v int, ok bool) int {
+			return v + 1
+		})
+	})
+}
+
+func BenchmarkLRUMapStoreEvict(b *testing.B) {
+	m := typedmap.NewLRU[int, int](1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+}
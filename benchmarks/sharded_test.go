@@ -0,0 +1,151 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/thetechpanda/typedmap"
+)
+
+func BenchmarkShardedMapStoreAndDelete(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Delete(i)
+	}
+}
+
+func BenchmarkShardedMapRange(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	m.Range(func(k int, v int) bool {
+		noop(k, v)
+		return true
+	})
+}
+
+func BenchmarkShardedMapAll(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for k, v := range m.All() {
+		noop(k, v)
+	}
+}
+
+func BenchmarkShardedMapLoad(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, _ := m.Load(i)
+		noop(v)
+	}
+}
+
+func BenchmarkShardedMapEntries(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	keys, values := m.Entries()
+	noop(keys, values)
+}
+
+func BenchmarkShardedMapKeys(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	keys := m.Keys()
+	noop(keys)
+}
+
+func BenchmarkShardedMapValues(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	values := m.Values()
+	noop(values)
+}
+
+func BenchmarkShardedMapUpdate(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Update(i, func(v int, ok bool) int {
+			return v * i
+		})
+
+	}
+}
+
+func BenchmarkShardedMapUpdateRange(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	m.UpdateRange(func(k, i int) (int, bool) {
+		noop(k, i)
+		return i + 1, true
+	})
+}
+
+func BenchmarkShardedMapConcurrentOperations(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+		m.Load(i)
+		m.Delete(i)
+	})
+}
+
+func BenchmarkShardedMapConcurrentStore(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+	})
+}
+
+func BenchmarkShardedMapConcurrentSwap(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Swap(i, j)
+	})
+}
+
+func BenchmarkShardedMapConcurrentLoadOrStore(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		_, ok := m.LoadOrStore(i, j)
+		if !ok {
+			m.Delete(i)
+		}
+	})
+}
+
+func BenchmarkShardedMapConcurrentUpdate(b *testing.B) {
+	m := typedmap.NewSharded[int, int](0)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Update(i, func(v int, ok bool) int {
+			return v + 1
+		})
+	})
+}
@@ -30,6 +30,17 @@ func BenchmarkTypedSyncMapRange(b *testing.B) {
 	})
 }
 
+func BenchmarkTypedSyncMapAll(b *testing.B) {
+	m := typedmap.NewSyncMap[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for k, v := range m.All() {
+		noop(k, v)
+	}
+}
+
 func BenchmarkTypedSyncMapLoad(b *testing.B) {
 	m := typedmap.NewSyncMap[int, int]()
 	for i := 0; i < b.N; i++ {
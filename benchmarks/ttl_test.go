@@ -0,0 +1,152 @@
+package benchmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thetechpanda/typedmap"
+)
+
+func BenchmarkTTLMapStoreAndDelete(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Delete(i)
+	}
+}
+
+func BenchmarkTTLMapRange(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	m.Range(func(k int, v int) bool {
+		noop(k, v)
+		return true
+	})
+}
+
+func BenchmarkTTLMapAll(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for k, v := range m.All() {
+		noop(k, v)
+	}
+}
+
+func BenchmarkTTLMapLoad(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, _ := m.Load(i)
+		noop(v)
+	}
+}
+
+func BenchmarkTTLMapEntries(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	keys, values := m.Entries()
+	noop(keys, values)
+}
+
+func BenchmarkTTLMapKeys(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	keys := m.Keys()
+	noop(keys)
+}
+
+func BenchmarkTTLMapValues(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	values := m.Values()
+	noop(values)
+}
+
+func BenchmarkTTLMapUpdate(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Update(i, func(v int, ok bool) int {
+			return v * i
+		})
+
+	}
+}
+
+func BenchmarkTTLMapUpdateRange(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	m.UpdateRange(func(k, i int) (int, bool) {
+		noop(k, i)
+		return i + 1, true
+	})
+}
+
+func BenchmarkTTLMapConcurrentOperations(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+		m.Load(i)
+		m.Delete(i)
+	})
+}
+
+func BenchmarkTTLMapConcurrentStore(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Store(i, j)
+	})
+}
+
+func BenchmarkTTLMapConcurrentSwap(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Swap(i, j)
+	})
+}
+
+func BenchmarkTTLMapConcurrentLoadOrStore(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		_, ok := m.LoadOrStore(i, j)
+		if !ok {
+			m.Delete(i)
+		}
+	})
+}
+
+func BenchmarkTTLMapConcurrentUpdate(b *testing.B) {
+	m := typedmap.NewTTL[int, int](time.Minute)
+	benchmarkConcurrentInt(b, func(n, i, j int) {
+		m.Update(i, func(v int, ok bool) int {
+			return v + 1
+		})
+	})
+}
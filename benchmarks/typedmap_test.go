@@ -29,6 +29,17 @@ func BenchmarkTypedMapRange(b *testing.B) {
 	})
 }
 
+func BenchmarkTypedMapAll(b *testing.B) {
+	m := typedmap.New[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for k, v := range m.All() {
+		noop(k, v)
+	}
+}
+
 func BenchmarkTypedMapLoad(b *testing.B) {
 	m := typedmap.New[int, int]()
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,84 @@
+package typedmap
+
+import "github.com/thetechpanda/typedmap/internal/cache"
+
+// Policy identifies the algorithm used by a bounded map to choose the entries to evict.
+type Policy int
+
+const (
+	// PolicyLRU evicts the least recently used entry.
+	PolicyLRU Policy = iota
+	// PolicyLFU evicts the least frequently used entry, ties are broken evicting the least recently used one.
+	PolicyLFU
+	// PolicyARC implements the Adaptive Replacement Cache, balancing recency and frequency and resisting scans.
+	PolicyARC
+	// PolicyS3FIFO implements S3-FIFO, which quickly evicts entries accessed only once, making it resistant to scans.
+	PolicyS3FIFO
+	// PolicyWTinyLFU implements W-TinyLFU, which admits new entries only if their estimated access frequency,
+	// tracked by a count-min sketch, is higher than the one of the entry they would replace.
+	PolicyWTinyLFU
+)
+
+// String returns the name of the policy.
+func (p Policy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "LRU"
+	case PolicyLFU:
+		return "LFU"
+	case PolicyARC:
+		return "ARC"
+	case PolicyS3FIFO:
+		return "S3-FIFO"
+	case PolicyWTinyLFU:
+		return "W-TinyLFU"
+	}
+	return "unknown"
+}
+
+// WithPolicy sets the eviction policy used by NewCache, PolicyLRU is used by default.
+func WithPolicy(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// BoundedMap is a TypedMap holding at most a given number of entries, once full storing a new key evicts an entry.
+type BoundedMap[K comparable, V any] interface {
+	TypedMap[K, V]
+	// Peek returns the value stored in the map for a key without recording an access.
+	// The ok result indicates whether value was found in the map.
+	Peek(key K) (v V, ok bool)
+	// Capacity returns the maximum number of entries the map can hold.
+	Capacity() int
+}
+
+// NewCache returns a new BoundedMap holding at most capacity entries, if capacity is less than 1 the map holds a single entry.
+// The entries to evict are chosen by the policy set using WithPolicy, PolicyLRU is used by default.
+//
+// Load, LoadOrStore, Store, Swap, CompareAndSwap and Update record an access to the entry, while Peek, Has,
+// Range, UpdateRange and the functions returning the content of the map do not.
+// Depending on the policy, storing a new key may be rejected to protect more frequently used entries, in which case the key is not stored.
+//
+// Since reads update the state of the policy, all operations on the map use an exclusive lock.
+//
+// Use WithOnRemove to be notified of the entries leaving the map and WithEqual to set how values are compared.
+func NewCache[K comparable, V any](capacity int, opts ...Option) BoundedMap[K, V] {
+	o := newOptions(opts)
+	return cache.New(capacity, newPolicy[K](o.policy), onRemove[K, V](o), equal[V](o))
+}
+
+// newPolicy returns the constructor of the internal policy identified by p.
+func newPolicy[K comparable](p Policy) cache.NewPolicy[K] {
+	switch p {
+	case PolicyLFU:
+		return cache.NewLFU[K]
+	case PolicyARC:
+		return cache.NewARC[K]
+	case PolicyS3FIFO:
+		return cache.NewS3FIFO[K]
+	case PolicyWTinyLFU:
+		return cache.NewWTinyLFU[K]
+	}
+	return cache.NewLRU[K]
+}
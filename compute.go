@@ -0,0 +1,27 @@
+package typedmap
+
+import "github.com/thetechpanda/typedmap/internal/compute"
+
+// Op is the operation performed by Compute, ComputeIfAbsent and ComputeIfPresent once the new value has been computed.
+type Op = compute.Op
+
+const (
+	// OpKeep leaves the map unchanged, the computed value is discarded.
+	OpKeep = compute.Keep
+	// OpStore sets the computed value for the key.
+	OpStore = compute.Store
+	// OpDelete removes the key from the map, if present.
+	OpDelete = compute.Delete
+)
+
+// Computable is a generic interface that provides a way to atomically compute the value of a key, storing or deleting it according to the returned Op.
+// Each function returns the value for the key once the operation is applied, the ok result reports whether the key is present.
+type Computable[K, V any] interface {
+	// Compute calls f with the current value for key and whether it is present, f returns the new value and the Op to perform:
+	// OpKeep leaves the map unchanged, OpStore sets the new value and OpDelete removes the key.
+	Compute(key K, f func(old V, loaded bool) (V, Op)) (value V, ok bool)
+	// ComputeIfAbsent calls f only if key is not present, the value returned by f is stored if the Op is OpStore.
+	ComputeIfAbsent(key K, f func() (V, Op)) (value V, ok bool)
+	// ComputeIfPresent calls f only if key is present, f returns the new value and the Op to perform.
+	ComputeIfPresent(key K, f func(old V) (V, Op)) (value V, ok bool)
+}
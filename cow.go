@@ -0,0 +1,18 @@
+package typedmap
+
+import "github.com/thetechpanda/typedmap/internal/cow"
+
+// NewCOW returns a new copy-on-write TypedMap, initialized with a copy of m. m can be nil.
+//
+// Reads (Load, Has, Len, Range, Keys, Values, Entries and the iterators) load an immutable snapshot of the map
+// through an atomic pointer, without locking: they never contend with each other or with the writers.
+// Range and the iterators visit a snapshot, the loop body can write to the map.
+//
+// Writes copy the whole map, making them O(N): NewCOW is meant for read-mostly maps, eg. configuration or routing tables.
+// Concurrent calls to Store and Delete are applied together to a single copy, and the writes of a Transaction,
+// UpdateRange, DeleteFunc or Exclusive share a single copy as well: use Transaction to batch several writes.
+//
+// Use WithEqual to set how values are compared.
+func NewCOW[K comparable, V any](m map[K]V, opts ...Option) TypedMap[K, V] {
+	return cow.New(m, equal[V](withoutOnRemove(opts)))
+}
@@ -0,0 +1,51 @@
+package typedmap
+
+import (
+	"fmt"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+)
+
+// ErrNotComparable is returned by TryCompareAndSwap and TryCompareAndDelete when the values of the map cannot be compared:
+// V is not a comparable type, it does not implement Equaler and no equality function has been set using WithEqual.
+var ErrNotComparable = equality.ErrNotComparable
+
+// Equaler is implemented by the values providing their own equality, eg. time.Time.
+// CompareAndSwap and CompareAndDelete compare values implementing Equaler[V] using their Equal method,
+// unless an equality function has been set using WithEqual.
+type Equaler[V any] interface {
+	Equal(V) bool
+}
+
+// Comparer is a generic interface that makes the failure to compare values observable,
+// CompareAndSwap and CompareAndDelete return false when the values of the map cannot be compared.
+type Comparer[K, V any] interface {
+	// TryCompareAndSwap is CompareAndSwap, but it returns ErrNotComparable if the values of the map cannot be compared.
+	TryCompareAndSwap(key K, old, new V) (swapped bool, err error)
+	// TryCompareAndDelete is CompareAndDelete, but it returns ErrNotComparable if the values of the map cannot be compared.
+	TryCompareAndDelete(key K, old V) (deleted bool, err error)
+}
+
+// WithEqual sets the function used by CompareAndSwap and CompareAndDelete to compare values,
+// it takes precedence over the Equal method of the values and the default comparison of the map.
+// It allows to compare values of non comparable types, eg. []byte using bytes.Equal.
+//
+// The type of f must match the value type of the map, the map constructor panics otherwise.
+func WithEqual[V any](f func(a, b V) bool) Option {
+	return func(o *options) {
+		o.equal = f
+	}
+}
+
+// equal returns the equality function set using WithEqual, if any.
+func equal[V any](o *options) equality.Func[V] {
+	if o.equal == nil {
+		return nil
+	}
+	f, ok := o.equal.(func(a, b V) bool)
+	if !ok {
+		var v V
+		panic(fmt.Sprintf("typedmap: WithEqual function %T does not match map value type %T", o.equal, v))
+	}
+	return f
+}
@@ -0,0 +1,26 @@
+package typedmap
+
+import "github.com/thetechpanda/typedmap/internal/hashtrie"
+
+// HashTrieMap is a generic concurrent map backed by a hash-trie, it provides the functions of sync.Map without boxing keys and values into interface{}.
+//
+// Load, Has, Range and the iterators never lock the map, writers lock only the node of the trie holding the key:
+// writes of keys placed in different nodes do not contend with each other.
+// Range and the iterators do not correspond to any consistent snapshot of the map, the loop body can invoke any map function.
+type HashTrieMap[K comparable, V any] interface {
+	IterableMap[K, V]
+	Comparer[K, V]
+	// Has returns true if the map contains the key.
+	Has(key K) bool
+	// Len returns the number of items in the map.
+	Len() int
+	// Clear removes all items from the map.
+	Clear()
+}
+
+// NewHashTrie returns a new HashTrieMap.
+//
+// Values are compared using ==, as sync.Map does, unless V implements Equaler: use WithEqual to set how values are compared.
+func NewHashTrie[K comparable, V any](opts ...Option) HashTrieMap[K, V] {
+	return hashtrie.New[K](nil, equal[V](withoutOnRemove(opts)))
+}
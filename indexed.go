@@ -0,0 +1,111 @@
+package typedmap
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/indexed"
+)
+
+// ErrDuplicate is the error of the writes of an IndexedMap that would store a value whose key in a unique index belongs to another entry,
+// the error wrapping it names the duplicate key and the entry it belongs to.
+var ErrDuplicate = indexed.ErrDuplicate
+
+// IndexedMap is a TypedMap maintaining secondary indexes on its values, added using AddIndex, AddUniqueIndex and AddMultiIndex.
+// Every write updates the indexes atomically, so that the indexes always agree with Load.
+// The keys of a value in the indexes are computed when it is stored: a value changed in place, e.g. through a pointer,
+// keeps its previous keys until it is stored again.
+//
+// The writes that would store a value violating a unique index leave the map and its indexes untouched:
+// TryStore, TryLoadOrStore, TrySwap and TryUpdate return an error wrapping ErrDuplicate, Transaction returns it as well,
+// the other writes panic with it. The writes of UpdateRange, UpdateRangeAtomic, Exclusive and Transaction are checked together,
+// so that for example two entries can exchange their keys in a unique index.
+//
+// An IndexedMap cannot be enlisted in a Txn, as its writes could fail once the other maps have been written.
+type IndexedMap[K comparable, V any] interface {
+	TypedMap[K, V]
+	// TryStore is Store, but it returns an error wrapping ErrDuplicate if the value would violate a unique index.
+	TryStore(key K, value V) error
+	// TryLoadOrStore is LoadOrStore, but it returns an error wrapping ErrDuplicate if the value would violate a unique index.
+	TryLoadOrStore(key K, value V) (actual V, loaded bool, err error)
+	// TrySwap is Swap, but it returns an error wrapping ErrDuplicate if the value would violate a unique index.
+	TrySwap(key K, value V) (previous V, loaded bool, err error)
+	// TryUpdate is Update, but it returns an error wrapping ErrDuplicate if the value returned by f would violate a unique index.
+	//
+	// ! Do not invoke any IndexedMap functions within 'f' to prevent a deadlock.
+	TryUpdate(key K, f func(V, bool) V) error
+	// LookupBy returns the entry whose value has key in the index named index, as Index.Lookup does.
+	// It panics if the map has no index named index, or if key is not of the type of the keys of the index.
+	LookupBy(index string, key any) (k K, value V, ok bool)
+	// RangeBy returns an iterator over the entries whose value has key in the index named index, as Index.Range does.
+	// The iterator panics as LookupBy does.
+	//
+	// Avoid invoking any map functions within the loop body to prevent a deadlock.
+	RangeBy(index string, key any) iter.Seq2[K, V]
+}
+
+// Index is a secondary index of an IndexedMap, returned by AddIndex, AddUniqueIndex and AddMultiIndex.
+// Each value of the map is indexed under the keys of type IK returned by the index function.
+// Unlike LookupBy and RangeBy, a lookup with a key of the wrong type does not compile.
+type Index[K comparable, V any, IK comparable] interface {
+	// Lookup returns the entry whose value has key in the index, the ok result reports whether there is one.
+	// If the index is not unique and several entries have key, any of them is returned, see Range.
+	Lookup(key IK) (k K, value V, ok bool)
+	// Range returns an iterator over the entries whose value has key in the index, in no particular order.
+	//
+	// Avoid invoking any map functions within the loop body to prevent a deadlock.
+	Range(key IK) iter.Seq2[K, V]
+	// Count returns the number of entries whose value has key in the index.
+	Count(key IK) int
+}
+
+// NewIndexed returns a new IndexedMap without indexes, see AddIndex, AddUniqueIndex and AddMultiIndex.
+//
+// CompareAndSwap and CompareAndDelete compare values using their Equal method, if V implements Equaler, or reflect.DeepEqual.
+// Use WithEqual to set how values are compared.
+func NewIndexed[K comparable, V any](opts ...Option) IndexedMap[K, V] {
+	o := withoutOnRemove(opts)
+	return indexed.New[K](equal[V](o))
+}
+
+// AddIndex adds to m an index named name, where f returns the key of a value, several entries may share the same key.
+// The entries already in m are indexed. The index can be queried through the returned Index, or by name using LookupBy and RangeBy.
+// AddIndex panics if m was not returned by NewIndexed, or if m already has an index named name.
+func AddIndex[K comparable, V any, IK comparable](m IndexedMap[K, V], name string, f func(V) IK) Index[K, V, IK] {
+	idx, _ := addIndex(m, name, func(value V, yield func(IK)) {
+		yield(f(value))
+	}, false)
+	return idx
+}
+
+// AddUniqueIndex adds to m an index as AddIndex does, but a key of the index can belong to a single entry:
+// storing a value whose key belongs to another entry fails with ErrDuplicate, see IndexedMap.
+// If the entries already in m share a key, AddUniqueIndex returns an error wrapping ErrDuplicate and the index is not added.
+func AddUniqueIndex[K comparable, V any, IK comparable](m IndexedMap[K, V], name string, f func(V) IK) (Index[K, V, IK], error) {
+	return addIndex(m, name, func(value V, yield func(IK)) {
+		yield(f(value))
+	}, true)
+}
+
+// AddMultiIndex adds to m an index where f returns the keys of a value, so that a value is indexed under each of them,
+// for example the tags of an article. Several entries may share the same key, see AddIndex.
+func AddMultiIndex[K comparable, V any, IK comparable](m IndexedMap[K, V], name string, f func(V) []IK) Index[K, V, IK] {
+	idx, _ := addIndex(m, name, func(value V, yield func(IK)) {
+		for _, key := range f(value) {
+			yield(key)
+		}
+	}, false)
+	return idx
+}
+
+func addIndex[K comparable, V any, IK comparable](m IndexedMap[K, V], name string, keys func(V, func(IK)), unique bool) (Index[K, V, IK], error) {
+	im, ok := m.(*indexed.TypedMap[K, V])
+	if !ok {
+		panic(fmt.Sprintf("typedmap: cannot add an index to %T, use NewIndexed", m))
+	}
+	idx, err := indexed.NewIndex(im, name, keys, unique)
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
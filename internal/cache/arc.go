@@ -0,0 +1,96 @@
+package cache
+
+// arc implements the Adaptive Replacement Cache policy.
+//
+// Resident keys are split between t1, keys seen once recently, and t2, keys seen at least twice recently.
+// b1 and b2 remember the keys recently evicted from t1 and t2, a miss on one of them adapts the target size p of t1.
+type arc[K comparable] struct {
+	capacity       int
+	p              int
+	nodes          map[K]*node[K]
+	t1, t2, b1, b2 *nodeList[K]
+}
+
+// NewARC returns a policy implementing the Adaptive Replacement Cache algorithm, balancing recency and frequency.
+func NewARC[K comparable](capacity int) Policy[K] {
+	return &arc[K]{
+		capacity: capacity,
+		nodes:    make(map[K]*node[K]),
+		t1:       newList[K](),
+		t2:       newList[K](),
+		b1:       newList[K](),
+		b2:       newList[K](),
+	}
+}
+
+func (p *arc[K]) Hit(key K) {
+	n, ok := p.nodes[key]
+	if !ok || (n.List() != p.t1 && n.List() != p.t2) {
+		return
+	}
+	n.List().Remove(n)
+	p.t2.PushFront(n)
+}
+
+func (p *arc[K]) Add(key K, evict func(K)) {
+	if n, ok := p.nodes[key]; ok {
+		// key is in a ghost list
+		inB2 := n.List() == p.b2
+		if inB2 {
+			p.p = max(p.p-max(p.b1.Len()/max(p.b2.Len(), 1), 1), 0)
+		} else {
+			p.p = min(p.p+max(p.b2.Len()/max(p.b1.Len(), 1), 1), p.capacity)
+		}
+		n.List().Remove(n)
+		p.replace(inB2, evict)
+		p.t2.PushFront(n)
+		return
+	}
+
+	switch l1 := p.t1.Len() + p.b1.Len(); {
+	case l1 >= p.capacity:
+		if p.t1.Len() < p.capacity {
+			p.drop(p.b1.Back())
+			p.replace(false, evict)
+		} else {
+			victim := p.t1.Back()
+			p.drop(victim)
+			evict(victim.Value.key)
+		}
+	case l1+p.t2.Len()+p.b2.Len() >= p.capacity:
+		if l1+p.t2.Len()+p.b2.Len() >= 2*p.capacity {
+			p.drop(p.b2.Back())
+		}
+		p.replace(false, evict)
+	}
+	n := &node[K]{Value: entry[K]{key: key}}
+	p.nodes[key] = n
+	p.t1.PushFront(n)
+}
+
+// replace evicts a resident key if the cache is full, moving it to the corresponding ghost list.
+func (p *arc[K]) replace(inB2 bool, evict func(K)) {
+	if p.t1.Len()+p.t2.Len() < p.capacity {
+		return
+	}
+	from, to := p.t2, p.b2
+	if p.t1.Len() > 0 && (p.t1.Len() > p.p || (inB2 && p.t1.Len() == p.p) || p.t2.Len() == 0) {
+		from, to = p.t1, p.b1
+	}
+	victim := from.Back()
+	from.Remove(victim)
+	to.PushFront(victim)
+	evict(victim.Value.key)
+}
+
+// drop forgets n.
+func (p *arc[K]) drop(n *node[K]) {
+	n.List().Remove(n)
+	delete(p.nodes, n.Value.key)
+}
+
+func (p *arc[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok && (n.List() == p.t1 || n.List() == p.t2) {
+		p.drop(n)
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// TypedMap implements a thread-safe map bounded to a given capacity, the entries to evict are chosen by a Policy.
+//
+// Since reads update the state of the policy, all operations use an exclusive lock.
+type TypedMap[K comparable, V any] struct {
+	mu sync.Mutex
+	equality.Comparer[V]
+	txn.ID
+	capacity  int
+	data      map[K]V
+	newPolicy NewPolicy[K]
+	policy    Policy[K]
+	onRemove  removal.Hook[K, V]
+	// evict removes a key chosen by the policy from data, recording its removal in evicted.
+	// It is allocated once to avoid a closure for each insertion.
+	evict   func(K)
+	evicted *removal.Batch[K, V]
+}
+
+// New returns a new TypedMap holding at most capacity entries, evicted according to the policy returned by newPolicy.
+// If capacity is less than 1 the map holds a single entry.
+// If onRemove is not nil it is called, without holding the map lock, for every entry removed from the map.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](capacity int, newPolicy NewPolicy[K], onRemove removal.Hook[K, V], equal equality.Func[V]) *TypedMap[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	m := &TypedMap[K, V]{
+		Comparer:  equality.NewComparer(equal, equality.Deep[V]),
+		ID:        txn.NewID(),
+		capacity:  capacity,
+		data:      make(map[K]V),
+		newPolicy: newPolicy,
+		policy:    newPolicy(capacity),
+		onRemove:  onRemove,
+	}
+	m.evict = func(key K) {
+		m.evicted.Add(key, m.data[key], removal.Capacity)
+		delete(m.data, key)
+	}
+	return m
+}
+
+// Capacity returns the maximum number of entries the map can hold.
+func (m *TypedMap[K, V]) Capacity() int {
+	return m.capacity
+}
+
+// Peek returns the value stored in the map for a key without recording an access.
+// The ok result indicates whether value was found in the map.
+func (m *TypedMap[K, V]) Peek(key K) (v V, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok = m.data[key]
+	return v, ok
+}
+
+// set stores value for key recording an access, if the key is new the policy may evict other entries or reject it.
+// The removed entries are recorded in b, the caller must hold the lock.
+func (m *TypedMap[K, V]) set(b *removal.Batch[K, V], key K, value V) {
+	old, ok := m.data[key]
+	m.data[key] = value
+	if ok {
+		b.Replace(key, old, value)
+		m.policy.Hit(key)
+		return
+	}
+	m.evicted = b
+	m.policy.Add(key, m.evict)
+	m.evicted = nil
+}
+
+// remove deletes key from the map, recording its removal in b for the given reason.
+// The caller must hold the lock.
+func (m *TypedMap[K, V]) remove(b *removal.Batch[K, V], key K, reason removal.Reason) {
+	b.Add(key, m.data[key], reason)
+	delete(m.data, key)
+	m.policy.Remove(key)
+}
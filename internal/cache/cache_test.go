@@ -0,0 +1,436 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/cache"
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// policies lists all the policies, every test is run against each of them.
+var policies = map[string]cache.NewPolicy[int]{
+	"LRU":       cache.NewLRU[int],
+	"LFU":       cache.NewLFU[int],
+	"ARC":       cache.NewARC[int],
+	"S3-FIFO":   cache.NewS3FIFO[int],
+	"W-TinyLFU": cache.NewWTinyLFU[int],
+}
+
+// forEachPolicy runs f as a subtest for each policy.
+func forEachPolicy(t *testing.T, f func(t *testing.T, newPolicy cache.NewPolicy[int])) {
+	for name, newPolicy := range policies {
+		t.Run(name, func(t *testing.T) {
+			f(t, newPolicy)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		m := cache.New[int, int](0, newPolicy, nil, nil)
+		if m.Capacity() != 1 {
+			t.Errorf("Capacity(): Expected capacity 1, got %d", m.Capacity())
+		}
+		m.Store(1, 1)
+		m.Store(2, 2)
+		m.Store(3, 3)
+		if m.Len() > 1 {
+			t.Errorf("Len(): Expected map to be within capacity, got %d", m.Len())
+		}
+	})
+}
+
+func TestCapacity(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		m := cache.New[int, int](100, newPolicy, nil, nil)
+		for i := 0; i < 10000; i++ {
+			m.Store(i%1000, i)
+			m.Load(i % 37)
+			if i%7 == 0 {
+				m.Delete(i % 13)
+			}
+			if m.Len() > 100 {
+				t.Fatalf("Len(): Expected map to be within capacity, got %d", m.Len())
+			}
+		}
+		// all keys tracked by the policy must be present in the map
+		for i := 0; i < 1000; i++ {
+			m.Store(i, i)
+		}
+		if m.Len() > 100 {
+			t.Errorf("Len(): Expected map to be within capacity, got %d", m.Len())
+		}
+	})
+}
+
+func TestMapOperations(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		m := cache.New[int, int](10, newPolicy, nil, nil)
+		if _, ok := m.Load(1); ok {
+			t.Errorf("Load(): Expected key to be missing")
+		}
+		if v, loaded := m.LoadOrStore(1, 42); loaded || v != 42 {
+			t.Errorf("LoadOrStore(): Expected key to be stored")
+		}
+		if v, loaded := m.LoadOrStore(1, 43); !loaded || v != 42 {
+			t.Errorf("LoadOrStore(): Expected value 42 to be loaded, got %d", v)
+		}
+		if v, ok := m.Load(1); !ok || v != 42 {
+			t.Errorf("Load(): Expected value 42, got %d", v)
+		}
+		if v, ok := m.Peek(1); !ok || v != 42 {
+			t.Errorf("Peek(): Expected value 42, got %d", v)
+		}
+		if v, loaded := m.Swap(1, 43); !loaded || v != 42 {
+			t.Errorf("Swap(): Expected previous value 42, got %d", v)
+		}
+		if m.CompareAndSwap(1, 42, 44) {
+			t.Errorf("CompareAndSwap(): Expected key not to be swapped")
+		}
+		if !m.CompareAndSwap(1, 43, 44) {
+			t.Errorf("CompareAndSwap(): Expected key to be swapped")
+		}
+		if m.CompareAndDelete(1, 43) {
+			t.Errorf("CompareAndDelete(): Expected key not to be deleted")
+		}
+		if !m.CompareAndDelete(1, 44) {
+			t.Errorf("CompareAndDelete(): Expected key to be deleted")
+		}
+		m.Store(1, 1)
+		if v, loaded := m.LoadAndDelete(1); !loaded || v != 1 {
+			t.Errorf("LoadAndDelete(): Expected value 1, got %d", v)
+		}
+		if _, loaded := m.LoadAndDelete(1); loaded {
+			t.Errorf("LoadAndDelete(): Expected key to be missing")
+		}
+		m.Store(1, 1)
+		m.Delete(1)
+		if m.Has(1) {
+			t.Errorf("Has(): Expected key to be deleted")
+		}
+		m.Update(2, func(v int, ok bool) int {
+			if ok {
+				t.Errorf("Update(): Expected key to be missing")
+			}
+			return 2
+		})
+		m.Update(2, func(v int, ok bool) int { return v + 1 })
+		if v, _ := m.Peek(2); v != 3 {
+			t.Errorf("Update(): Expected value 3, got %d", v)
+		}
+		m.Clear()
+		if m.Len() != 0 {
+			t.Errorf("Len(): Expected length 0, got %d", m.Len())
+		}
+		m.Store(1, 1)
+		if !m.Has(1) {
+			t.Errorf("Has(): Expected key to be present after Clear")
+		}
+	})
+
+	n := cache.New[int, []int](10, cache.NewLRU[int], nil, nil)
+	n.Store(1, []int{1})
+	if n.CompareAndSwap(1, []int{1}, []int{2}) || n.CompareAndDelete(1, []int{1}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := n.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := n.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := cache.New[int, []int](10, cache.NewLRU[int], nil, slices.Equal[[]int])
+	e.Store(1, []int{1})
+	if swapped, err := e.TryCompareAndSwap(1, []int{1}, []int{2}); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := e.TryCompareAndDelete(1, []int{2}); !deleted || err != nil || e.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+}
+
+func TestRangeAndEntries(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		m := cache.New[int, int](100, newPolicy, nil, nil)
+		for i := 0; i < 100; i++ {
+			m.Store(i, i)
+		}
+		n := m.Len()
+		if len(m.Keys()) != n || len(m.Values()) != n {
+			t.Errorf("Keys(), Values(): Expected %d items", n)
+		}
+		keys, values := m.Entries()
+		for i := range keys {
+			if keys[i] != values[i] {
+				t.Errorf("Entries(): Expected key %d to match value %d", keys[i], values[i])
+			}
+		}
+		m.UpdateRange(func(k, v int) (int, bool) { return v * 2, true })
+		m.UpdateRange(func(k, v int) (int, bool) { return 0, false })
+		for k, v := range m.All() {
+			if v != k*2 {
+				t.Errorf("All(): Expected value %d, got %d", k*2, v)
+			}
+		}
+		count := 0
+		m.Range(func(k, v int) bool {
+			count++
+			return false
+		})
+		for range m.KeysSeq() {
+			count++
+		}
+		for range m.ValuesSeq() {
+			count++
+		}
+		if count != 2*n+1 {
+			t.Errorf("Expected %d iterations, got %d", 2*n+1, count)
+		}
+	})
+}
+
+func TestExclusive(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		m := cache.New[int, int](10, newPolicy, nil, nil)
+		for i := 0; i < 5; i++ {
+			m.Store(i, i)
+		}
+		m.Exclusive(func(data map[int]int) {
+			for k := range data {
+				if k == 0 {
+					delete(data, k)
+				} else {
+					data[k] = k * 10
+				}
+			}
+			for i := 100; i < 120; i++ {
+				data[i] = i
+			}
+		})
+		if m.Has(0) {
+			t.Errorf("Exclusive(): Expected key 0 to be removed")
+		}
+		if m.Len() > 10 {
+			t.Errorf("Len(): Expected map to be within capacity, got %d", m.Len())
+		}
+		for k, v := range m.All() {
+			if k < 100 && v != k*10 {
+				t.Errorf("Exclusive(): Expected value %d, got %d", k*10, v)
+			}
+		}
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		m := cache.New[int, int](50, newPolicy, nil, nil)
+		numGoroutines := 100
+		var wg sync.WaitGroup
+		wg.Add(numGoroutines)
+		ctx, cancel := context.WithCancel(context.Background())
+		for i := 0; i < numGoroutines; i++ {
+			go func(i int) {
+				defer wg.Done()
+				// uses context done to have all goroutines start at the same time
+				<-ctx.Done()
+				for j := 0; j < numGoroutines; j++ {
+					if _, ok := m.Load(j); !ok {
+						m.Store(j, i*i)
+					}
+					m.Update(j, func(v int, ok bool) int { return v + 1 })
+				}
+			}(i)
+		}
+		cancel()
+		wg.Wait()
+		if m.Len() > 50 {
+			t.Errorf("Len(): Expected map to be within capacity, got %d", m.Len())
+		}
+	})
+}
+
+// keys returns the keys of m, sorted.
+func keys(m *cache.TypedMap[int, int]) []int {
+	return slices.Sorted(m.KeysSeq())
+}
+
+func TestOnRemove(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		type event struct {
+			key, value int
+			reason     removal.Reason
+		}
+		var events []event
+		m := cache.New(10, newPolicy, func(key int, value int, reason removal.Reason) {
+			events = append(events, event{key, value, reason})
+		}, nil)
+		for i := 0; i < 100; i++ {
+			m.Store(i, i)
+		}
+		if len(events)+m.Len() != 100 {
+			t.Errorf("Expected %d evictions, got %d", 100-m.Len(), len(events))
+		}
+		for _, e := range events {
+			if e.reason != removal.Capacity || e.key != e.value || m.Has(e.key) {
+				t.Errorf("Unexpected eviction %v", e)
+			}
+		}
+
+		key := m.Keys()[0]
+		check := func(op string, expect ...event) {
+			t.Helper()
+			if !slices.Equal(events, expect) {
+				t.Errorf("%s: Expected removals %v, got %v", op, expect, events)
+			}
+			events = nil
+		}
+		events = nil
+		m.Store(key, key)
+		check("Store()")
+		m.Store(key, -1)
+		check("Store()", event{key, key, removal.Replaced})
+		m.Update(key, func(v int, ok bool) int { return -2 })
+		check("Update()", event{key, -1, removal.Replaced})
+		m.UpdateRange(func(k, v int) (int, bool) { return v, k != key })
+		check("UpdateRange()")
+		m.Exclusive(func(data map[int]int) {
+			data[key] = -3
+		})
+		check("Exclusive()", event{key, -2, removal.Replaced})
+		m.Delete(key)
+		check("Delete()", event{key, -3, removal.Deleted})
+		n := m.Len()
+		m.Clear()
+		if len(events) != n {
+			t.Errorf("Clear(): Expected %d removals, got %d", n, len(events))
+		}
+		for _, e := range events {
+			if e.reason != removal.Cleared {
+				t.Errorf("Clear(): Unexpected removal %v", e)
+			}
+		}
+	})
+}
+
+func TestComputeRejected(t *testing.T) {
+	// a key rejected by the policy is not present.
+	m := cache.New[int, int](10, newRejectPolicy, nil, nil)
+	if v, ok := m.Compute(1, func(old int, loaded bool) (int, compute.Op) { return 1, compute.Store }); ok || v != 0 || m.Has(1) {
+		t.Errorf("Compute(): Expected the new key to be rejected, got %d, %v", v, ok)
+	}
+}
+
+// rejectPolicy is a Policy rejecting every new key.
+type rejectPolicy struct{}
+
+func newRejectPolicy(capacity int) cache.Policy[int] {
+	return rejectPolicy{}
+}
+
+func (rejectPolicy) Hit(key int) {}
+
+func (rejectPolicy) Add(key int, evict func(int)) {
+	evict(key)
+}
+
+func (rejectPolicy) Remove(key int) {}
+
+func TestDeleteFunc(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newPolicy cache.NewPolicy[int]) {
+		m := cache.New[int, int](10, newPolicy, nil, nil)
+		for i := 0; i < 10; i++ {
+			m.Store(i, i)
+		}
+		m.DeleteFunc(func(k, v int) bool { return v%2 == 0 })
+		// removed keys no longer count towards the capacity.
+		for i := 10; i < 15; i++ {
+			m.Store(i, i)
+		}
+		if keys := keys(m); len(keys) != 10 || !slices.Contains(keys, 1) {
+			t.Errorf("DeleteFunc(): Expected 10 keys and no eviction, got %v", keys)
+		}
+	})
+}
+
+// lruEvictions returns the keys evicted from an LRU map of capacity 3 holding 0, 1 and 2, stored in this order,
+// once op is applied and three new keys are stored.
+func lruEvictions(op func(m *cache.TypedMap[int, int])) []int {
+	var evicted []int
+	m := cache.New(3, cache.NewLRU[int], func(key int, value int, reason removal.Reason) {
+		if reason == removal.Capacity {
+			evicted = append(evicted, key)
+		}
+	}, equality.Identical[int])
+	for i := 0; i < 3; i++ {
+		m.Store(i, i)
+	}
+	op(m)
+	for i := 3; i < 6; i++ {
+		m.Store(i, i)
+	}
+	return evicted
+}
+
+func TestLRURecency(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     func(m *cache.TypedMap[int, int])
+		expect []int
+	}{
+		{"None", func(m *cache.TypedMap[int, int]) {}, []int{0, 1, 2}},
+		{"Load", func(m *cache.TypedMap[int, int]) { m.Load(0) }, []int{1, 2, 0}},
+		{"Store", func(m *cache.TypedMap[int, int]) { m.Store(0, 10) }, []int{1, 2, 0}},
+		{"LoadOrStore", func(m *cache.TypedMap[int, int]) { m.LoadOrStore(0, 10) }, []int{1, 2, 0}},
+		{"Swap", func(m *cache.TypedMap[int, int]) { m.Swap(1, 10) }, []int{0, 2, 1}},
+		{"Update", func(m *cache.TypedMap[int, int]) { m.Update(1, func(v int, ok bool) int { return v + 1 }) }, []int{0, 2, 1}},
+		{"CompareAndSwap", func(m *cache.TypedMap[int, int]) { m.CompareAndSwap(0, 0, 10) }, []int{1, 2, 0}},
+		{"CompareAndSwap mismatch", func(m *cache.TypedMap[int, int]) { m.CompareAndSwap(0, 5, 10) }, []int{0, 1, 2}},
+		{"Peek", func(m *cache.TypedMap[int, int]) { m.Peek(0) }, []int{0, 1, 2}},
+		{"Has", func(m *cache.TypedMap[int, int]) { m.Has(0) }, []int{0, 1, 2}},
+		{"Range", func(m *cache.TypedMap[int, int]) { m.Range(func(k, v int) bool { return true }) }, []int{0, 1, 2}},
+		{"Loads", func(m *cache.TypedMap[int, int]) {
+			m.Load(1)
+			m.Load(0)
+		}, []int{2, 1, 0}},
+		{"Transaction", func(m *cache.TypedMap[int, int]) {
+			// loads do not change the recency, stores are applied in the order of their last write.
+			m.Transaction(func(tx txn.Tx[int, int]) error {
+				tx.Load(0)
+				tx.Store(2, 20)
+				tx.Store(1, 10)
+				return nil
+			})
+		}, []int{0, 2, 1}},
+	}
+	for _, test := range tests {
+		if evicted := lruEvictions(test.op); !slices.Equal(evicted, test.expect) {
+			t.Errorf("%s: Expected the keys to be evicted in order %v, got %v", test.name, test.expect, evicted)
+		}
+	}
+}
+
+func TestLRUCapacityOne(t *testing.T) {
+	var evicted []int
+	m := cache.New(1, cache.NewLRU[int], func(key int, value int, reason removal.Reason) {
+		evicted = append(evicted, key)
+	}, nil)
+	m.Store(1, 1)
+	m.Load(1)
+	m.Store(2, 2)
+	if v, ok := m.Load(2); !ok || v != 2 || m.Has(1) || m.Len() != 1 {
+		t.Errorf("Store(): Expected only 2 to be present, got %v", m.Keys())
+	}
+	m.Store(2, 20)
+	m.LoadOrStore(3, 3)
+	if !slices.Equal(evicted, []int{1, 2, 2}) || m.Len() != 1 || !m.Has(3) {
+		t.Errorf("Expected 1 and 2 to be evicted, 2 replaced first, got %v and keys %v", evicted, m.Keys())
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+// lfu evicts the least frequently used key, ties are broken evicting the least recently used key.
+// Keys are grouped in lists by access frequency so that every operation is O(1).
+type lfu[K comparable] struct {
+	capacity int
+	nodes    map[K]*node[K]
+	freqs    map[int]*nodeList[K]
+	minFreq  int
+}
+
+// NewLFU returns a policy that evicts the least frequently used key.
+func NewLFU[K comparable](capacity int) Policy[K] {
+	return &lfu[K]{capacity: capacity, nodes: make(map[K]*node[K]), freqs: make(map[int]*nodeList[K])}
+}
+
+// push inserts n in the list of its frequency.
+func (p *lfu[K]) push(n *node[K]) {
+	l, ok := p.freqs[n.Value.freq]
+	if !ok {
+		l = newList[K]()
+		p.freqs[n.Value.freq] = l
+	}
+	l.PushFront(n)
+}
+
+// unlink removes n from the list of its frequency, dropping the list if empty.
+func (p *lfu[K]) unlink(n *node[K]) {
+	l := n.List()
+	l.Remove(n)
+	if l.Len() == 0 {
+		delete(p.freqs, n.Value.freq)
+	}
+}
+
+func (p *lfu[K]) Hit(key K) {
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	p.unlink(n)
+	if n.Value.freq == p.minFreq && p.freqs[n.Value.freq] == nil {
+		p.minFreq++
+	}
+	n.Value.freq++
+	p.push(n)
+}
+
+func (p *lfu[K]) Add(key K, evict func(K)) {
+	if len(p.nodes) >= p.capacity {
+		// minFreq can only be stale after a Remove, which leaves room for the next key.
+		victim := p.freqs[p.minFreq].Back()
+		p.unlink(victim)
+		delete(p.nodes, victim.Value.key)
+		evict(victim.Value.key)
+	}
+	n := &node[K]{Value: entry[K]{key: key, freq: 1}}
+	p.nodes[key] = n
+	p.push(n)
+	p.minFreq = 1
+}
+
+func (p *lfu[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok {
+		p.unlink(n)
+		delete(p.nodes, key)
+	}
+}
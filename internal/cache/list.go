@@ -0,0 +1,21 @@
+package cache
+
+import "github.com/thetechpanda/typedmap/internal/list"
+
+// entry is a key tracked by a policy.
+type entry[K comparable] struct {
+	key K
+	// freq is a policy specific access counter.
+	freq int
+}
+
+// node is an entry linked in one of the policy lists.
+type node[K comparable] = list.Node[entry[K]]
+
+// nodeList is a list of nodes, the front of the list is the most recently inserted node.
+type nodeList[K comparable] = list.List[entry[K]]
+
+// newList returns an empty list.
+func newList[K comparable]() *nodeList[K] {
+	return list.New[entry[K]]()
+}
@@ -0,0 +1,38 @@
+package cache
+
+// lru evicts the least recently used key.
+type lru[K comparable] struct {
+	capacity int
+	nodes    map[K]*node[K]
+	list     *nodeList[K]
+}
+
+// NewLRU returns a policy that evicts the least recently used key.
+func NewLRU[K comparable](capacity int) Policy[K] {
+	return &lru[K]{capacity: capacity, nodes: make(map[K]*node[K]), list: newList[K]()}
+}
+
+func (p *lru[K]) Hit(key K) {
+	if n, ok := p.nodes[key]; ok {
+		p.list.MoveToFront(n)
+	}
+}
+
+func (p *lru[K]) Add(key K, evict func(K)) {
+	n := &node[K]{Value: entry[K]{key: key}}
+	p.nodes[key] = n
+	p.list.PushFront(n)
+	for p.list.Len() > p.capacity {
+		victim := p.list.Back()
+		p.list.Remove(victim)
+		delete(p.nodes, victim.Value.key)
+		evict(victim.Value.key)
+	}
+}
+
+func (p *lru[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok {
+		p.list.Remove(n)
+		delete(p.nodes, key)
+	}
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"github.com/thetechpanda/typedmap/internal/derive"
+	"github.com/thetechpanda/typedmap/internal/removal"
+)
+
+// Store sets the value for a key and records an access.
+// If the key is new the policy may evict other entries, or reject the key itself.
+func (m *TypedMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present. A found entry records an access.
+// The ok result indicates whether value was found in the map.
+func (m *TypedMap[K, V]) Load(key K) (v V, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok = m.data[key]
+	if ok {
+		m.policy.Hit(key)
+	}
+	return v, ok
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// In both cases an access is recorded.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *TypedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if actual, loaded = m.data[key]; loaded {
+		m.policy.Hit(key)
+		return actual, true
+	}
+	m.set(&b, key, value)
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, loaded = m.data[key]
+	if loaded {
+		m.remove(&b, key, removal.Deleted)
+	}
+	return value, loaded
+}
+
+// Delete removes the key from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// An access is recorded, if the key is new the policy may evict other entries, or reject the key itself.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, loaded = m.data[key]
+	m.set(&b, key, value)
+	return previous, loaded
+}
+
+// CompareAndSwap swaps the old and new values for key
+// if the value stored in the map is equal to old, recording an access.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+//
+// Returns true if the swap was performed.
+func (m *TypedMap[K, V]) CompareAndSwap(key K, old, new V) bool {
+	if !m.ValuesComparable() {
+		return false
+	}
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if !ok || !m.ValuesEqual(v, old) {
+		return false
+	}
+	m.set(&b, key, new)
+	return true
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
+//
+// If there is no current value for key in the map, CompareAndDelete
+// returns false (even if the old value is the nil interface value).
+func (m *TypedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if !ok || !m.ValuesEqual(v, old) {
+		return false
+	}
+	m.remove(&b, key, removal.Deleted)
+	return true
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// Range calls f sequentially for each key and value present in the map, without recording any access.
+// If f returns false, Range stops the iteration.
+// Avoid invoking any map functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Range(f func(K, V) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range m.data {
+		if !f(key, value) {
+			break
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package cache
+
+// Policy decides which keys are evicted from a bounded cache.
+// A policy is not safe for concurrent use, the cache serializes the calls.
+type Policy[K comparable] interface {
+	// Hit records an access to key, which is present in the cache.
+	Hit(key K)
+	// Add records the insertion of key, which is not present in the cache.
+	// evict is called for every key that must leave the cache to make room for key,
+	// a policy may also reject key itself by passing it to evict.
+	Add(key K, evict func(K))
+	// Remove stops tracking key, which has been removed from the cache.
+	Remove(key K)
+}
+
+// NewPolicy returns a new policy for a cache holding at most capacity keys.
+type NewPolicy[K comparable] func(capacity int) Policy[K]
@@ -0,0 +1,249 @@
+package cache_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/cache"
+)
+
+func TestLRUPolicy(t *testing.T) {
+	m := cache.New[int, int](3, cache.NewLRU[int], nil, nil)
+	m.Store(1, 1)
+	m.Store(2, 2)
+	m.Store(3, 3)
+	m.Load(1)
+	m.Peek(2)
+	m.Store(4, 4)
+	if got := keys(m); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("Expected keys [1 3 4], got %v", got)
+	}
+	m.Delete(3)
+	m.Store(5, 5)
+	if got := keys(m); !slices.Equal(got, []int{1, 4, 5}) {
+		t.Errorf("Expected keys [1 4 5], got %v", got)
+	}
+}
+
+func TestLFUPolicy(t *testing.T) {
+	m := cache.New[int, int](3, cache.NewLFU[int], nil, nil)
+	m.Store(1, 1)
+	m.Store(2, 2)
+	m.Store(3, 3)
+	for i := 0; i < 3; i++ {
+		m.Load(1)
+		m.Load(3)
+	}
+	m.Load(2)
+	// 2 is the least frequently used
+	m.Store(4, 4)
+	if got := keys(m); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("Expected keys [1 3 4], got %v", got)
+	}
+	// 4 has the lowest frequency
+	m.Store(5, 5)
+	if got := keys(m); !slices.Equal(got, []int{1, 3, 5}) {
+		t.Errorf("Expected keys [1 3 5], got %v", got)
+	}
+	// removing the least frequent key leaves a stale minimum frequency
+	m.Load(5)
+	m.Delete(5)
+	m.Store(6, 6)
+	m.Store(7, 7)
+	if got := keys(m); !slices.Equal(got, []int{1, 3, 7}) {
+		t.Errorf("Expected keys [1 3 7], got %v", got)
+	}
+}
+
+func TestARCPolicy(t *testing.T) {
+	m := cache.New[int, int](4, cache.NewARC[int], nil, nil)
+	// 1 and 2 are accessed twice, they move to the frequency list.
+	for i := 1; i <= 4; i++ {
+		m.Store(i, i)
+	}
+	m.Load(1)
+	m.Load(2)
+	// a scan evicts only keys seen once.
+	for i := 100; i < 110; i++ {
+		m.Store(i, i)
+	}
+	if !m.Has(1) || !m.Has(2) {
+		t.Errorf("Expected frequently used keys to survive a scan, got %v", keys(m))
+	}
+	if m.Len() != 4 {
+		t.Errorf("Len(): Expected length 4, got %d", m.Len())
+	}
+	// 3 is in the ghost list of keys recently evicted from the frequency list: storing it again puts it in the frequency list.
+	m.Store(3, 3)
+	m.Store(4, 4)
+	m.Store(5, 5)
+	m.Load(3)
+	m.Load(4)
+	for i := 200; i < 210; i++ {
+		m.Store(i, i)
+		m.Load(1)
+	}
+	if !m.Has(1) {
+		t.Errorf("Expected key 1 to be present, got %v", keys(m))
+	}
+	// keys evicted from the recency list are remembered and promoted to the frequency list when stored again.
+	r := cache.New[int, int](4, cache.NewARC[int], nil, nil)
+	for i := 1; i <= 4; i++ {
+		r.Store(i, i)
+	}
+	r.Load(1)
+	r.Store(5, 5)
+	if r.Has(2) {
+		t.Errorf("Expected key 2 to be evicted, got %v", keys(r))
+	}
+	r.Store(2, 2)
+	r.Store(6, 6)
+	r.Store(7, 7)
+	if !r.Has(1) || !r.Has(2) {
+		t.Errorf("Expected keys 1 and 2 to be in the frequency list, got %v", keys(r))
+	}
+
+	// keys evicted from the frequency list are remembered as well.
+	for i := 1; i <= 20; i++ {
+		m.Store(i, i)
+		m.Load(i)
+	}
+	for i := 1; i <= 20; i++ {
+		m.Store(i, i)
+	}
+	if m.Len() != 4 {
+		t.Errorf("Len(): Expected length 4, got %d", m.Len())
+	}
+	m.Delete(20)
+	m.Delete(19)
+	m.Store(300, 300)
+	m.Load(300)
+	m.Store(301, 301)
+	m.Store(302, 302)
+	if m.Len() > 4 {
+		t.Errorf("Len(): Expected map to be within capacity, got %d", m.Len())
+	}
+}
+
+func TestS3FIFOPolicy(t *testing.T) {
+	m := cache.New[int, int](10, cache.NewS3FIFO[int], nil, nil)
+	// keys accessed while in the small queue are promoted to the main queue.
+	for i := 0; i < 5; i++ {
+		m.Store(i, i)
+		m.Load(i)
+	}
+	// one-hit wonders are evicted from the small queue.
+	for i := 100; i < 200; i++ {
+		m.Store(i, i)
+	}
+	for i := 0; i < 5; i++ {
+		if !m.Has(i) {
+			t.Errorf("Expected key %d to survive a scan, got %v", i, keys(m))
+		}
+	}
+	// keys in the ghost queue are inserted in the main queue.
+	m.Store(190, 190)
+	for i := 300; i < 310; i++ {
+		m.Store(i, i)
+	}
+	if !m.Has(190) {
+		t.Errorf("Expected key 190 found in the ghost queue to be in the main queue, got %v", keys(m))
+	}
+	if m.Len() != 10 {
+		t.Errorf("Len(): Expected length 10, got %d", m.Len())
+	}
+}
+
+func TestS3FIFOPolicyMainQueue(t *testing.T) {
+	m := cache.New[int, int](10, cache.NewS3FIFO[int], nil, nil)
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+		m.Load(i)
+	}
+	// all keys are promoted to the main queue, the oldest one is evicted.
+	m.Store(10, 10)
+	if m.Has(0) || m.Len() != 10 {
+		t.Errorf("Expected key 0 to be evicted, got %v", keys(m))
+	}
+	// keys accessed while in the main queue are reinserted instead of being evicted.
+	m.Load(1)
+	m.Load(10)
+	m.Store(11, 11)
+	if !m.Has(1) || m.Has(2) {
+		t.Errorf("Expected key 1 to be reinserted and key 2 to be evicted, got %v", keys(m))
+	}
+	m.Delete(1)
+	m.Delete(11)
+	if m.Len() != 8 {
+		t.Errorf("Len(): Expected length 8, got %d", m.Len())
+	}
+}
+
+func TestWTinyLFUPolicy(t *testing.T) {
+	m := cache.New[int, int](100, cache.NewWTinyLFU[int], nil, nil)
+	// popular keys fill the main cache.
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	for n := 0; n < 5; n++ {
+		for i := 0; i < 100; i++ {
+			m.Load(i)
+		}
+	}
+	// a scan is not admitted in the main cache.
+	for i := 1000; i < 1500; i++ {
+		m.Store(i, i)
+	}
+	present := 0
+	for i := 0; i < 100; i++ {
+		if m.Has(i) {
+			present++
+		}
+	}
+	if present < 90 {
+		t.Errorf("Expected popular keys to survive a scan, got %d", present)
+	}
+	// a key accessed often enough is admitted.
+	for n := 0; n < 10; n++ {
+		m.Store(5000, 5000)
+		m.Load(5000)
+		m.Store(5001+n, 0)
+	}
+	if !m.Has(5000) {
+		t.Errorf("Expected frequently used key to be admitted")
+	}
+	m.Delete(5000)
+	m.Delete(1)
+
+	small := cache.New[int, int](1, cache.NewWTinyLFU[int], nil, nil)
+	small.Store(1, 1)
+	small.Store(2, 2)
+	if small.Len() != 1 {
+		t.Errorf("Len(): Expected length 1, got %d", small.Len())
+	}
+}
+
+func TestSketchAging(t *testing.T) {
+	m := cache.New[int, int](16, cache.NewWTinyLFU[int], nil, nil)
+	// enough accesses to reset the sketch several times.
+	for i := 0; i < 10000; i++ {
+		m.Store(i%64, i)
+		m.Load(i % 64)
+	}
+	if m.Len() > 16 {
+		t.Errorf("Len(): Expected map to be within capacity, got %d", m.Len())
+	}
+}
+
+func TestPolicyUnknownKeys(t *testing.T) {
+	for name, newPolicy := range policies {
+		p := newPolicy(2)
+		p.Hit(1)
+		p.Remove(1)
+		p.Add(1, func(key int) {
+			t.Errorf("%s: Expected no key to be evicted, got %d", name, key)
+		})
+		p.Hit(2)
+		p.Remove(2)
+	}
+}
@@ -0,0 +1,94 @@
+package cache
+
+// s3fifoMaxFreq is the maximum value of the access counter of a key.
+const s3fifoMaxFreq = 3
+
+// s3fifo implements the S3-FIFO policy.
+//
+// New keys enter the small queue, sized at 10% of the capacity, keys accessed while in the small queue are
+// promoted to the main queue, the others are evicted quickly and remembered in the ghost queue.
+// Keys found in the ghost queue are inserted directly in the main queue, which behaves as a FIFO with reinsertion.
+type s3fifo[K comparable] struct {
+	capacity           int
+	smallCap, ghostCap int
+	nodes              map[K]*node[K]
+	small, main, ghost *nodeList[K]
+}
+
+// NewS3FIFO returns a policy implementing the S3-FIFO algorithm, which quickly evicts keys accessed only once.
+func NewS3FIFO[K comparable](capacity int) Policy[K] {
+	smallCap := max(capacity/10, 1)
+	return &s3fifo[K]{
+		capacity: capacity,
+		smallCap: smallCap,
+		ghostCap: max(capacity-smallCap, 1),
+		nodes:    make(map[K]*node[K]),
+		small:    newList[K](),
+		main:     newList[K](),
+		ghost:    newList[K](),
+	}
+}
+
+func (p *s3fifo[K]) Hit(key K) {
+	if n, ok := p.nodes[key]; ok {
+		n.Value.freq = min(n.Value.freq+1, s3fifoMaxFreq)
+	}
+}
+
+func (p *s3fifo[K]) Add(key K, evict func(K)) {
+	if n, ok := p.nodes[key]; ok {
+		// key is in the ghost queue
+		p.ghost.Remove(n)
+		n.Value.freq = 0
+		p.main.PushFront(n)
+	} else {
+		n = &node[K]{Value: entry[K]{key: key}}
+		p.nodes[key] = n
+		p.small.PushFront(n)
+	}
+	for p.small.Len()+p.main.Len() > p.capacity {
+		if p.small.Len() > p.smallCap || p.main.Len() == 0 {
+			p.evictSmall(evict)
+		} else {
+			p.evictMain(evict)
+		}
+	}
+}
+
+// evictSmall promotes the oldest key of the small queue to the main queue if it was accessed, otherwise it is evicted.
+func (p *s3fifo[K]) evictSmall(evict func(K)) {
+	n := p.small.Back()
+	p.small.Remove(n)
+	if n.Value.freq > 0 {
+		n.Value.freq = 0
+		p.main.PushFront(n)
+		return
+	}
+	p.ghost.PushFront(n)
+	if p.ghost.Len() > p.ghostCap {
+		old := p.ghost.Back()
+		p.ghost.Remove(old)
+		delete(p.nodes, old.Value.key)
+	}
+	evict(n.Value.key)
+}
+
+// evictMain reinserts the oldest key of the main queue if it was accessed, otherwise it is evicted.
+func (p *s3fifo[K]) evictMain(evict func(K)) {
+	n := p.main.Back()
+	if n.Value.freq > 0 {
+		n.Value.freq--
+		p.main.MoveToFront(n)
+		return
+	}
+	p.main.Remove(n)
+	delete(p.nodes, n.Value.key)
+	evict(n.Value.key)
+}
+
+func (p *s3fifo[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok && n.List() != p.ghost {
+		n.List().Remove(n)
+		delete(p.nodes, key)
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"hash/maphash"
+
+	"github.com/thetechpanda/typedmap/internal/hashing"
+)
+
+// sketchDepth is the number of rows of the sketch.
+const sketchDepth = 4
+
+// sketchMaxCount is the maximum value of a counter, counters use 4 bits.
+const sketchMaxCount = 15
+
+// sketch is a count-min sketch estimating the access frequency of keys.
+// Counters are halved once the number of increments reaches the sample size, so that old accesses age out.
+type sketch[K comparable] struct {
+	seed       maphash.Seed
+	mask       uint64
+	rows       [sketchDepth][]uint8
+	additions  int
+	sampleSize int
+}
+
+// newSketch returns a sketch sized for a cache holding capacity keys.
+func newSketch[K comparable](capacity int) *sketch[K] {
+	// each row has at least four counters per key to keep collisions low.
+	width := 16
+	for width < 4*capacity {
+		width <<= 1
+	}
+	s := &sketch[K]{seed: maphash.MakeSeed(), mask: uint64(width - 1), sampleSize: 10 * max(capacity, 1)}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// indexes returns the position of key in each row of the sketch.
+func (s *sketch[K]) indexes(key K) (idx [sketchDepth]uint64) {
+	h := hashing.Comparable(s.seed, key)
+	h1, h2 := h, h>>32|h<<32|1
+	for i := range idx {
+		idx[i] = (h1 + uint64(i)*h2) & s.mask
+	}
+	return idx
+}
+
+// increment records an access to key.
+func (s *sketch[K]) increment(key K) {
+	for i, j := range s.indexes(key) {
+		if s.rows[i][j] < sketchMaxCount {
+			s.rows[i][j]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// estimate returns the estimated access frequency of key.
+func (s *sketch[K]) estimate(key K) int {
+	count := uint8(sketchMaxCount)
+	for i, j := range s.indexes(key) {
+		count = min(count, s.rows[i][j])
+	}
+	return int(count)
+}
+
+// reset halves all the counters.
+func (s *sketch[K]) reset() {
+	for _, row := range s.rows {
+		for j := range row {
+			row[j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
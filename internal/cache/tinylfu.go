@@ -0,0 +1,86 @@
+package cache
+
+// tinyLFU implements the W-TinyLFU policy.
+//
+// New keys enter a small LRU window, sized at 1% of the capacity. Keys leaving the window compete with the
+// eviction victim of the main segmented LRU and are admitted only if their estimated frequency, tracked by
+// a count-min sketch, is higher. The main cache is split between a probation and a protected segment,
+// keys accessed while in probation are promoted to the protected segment.
+type tinyLFU[K comparable] struct {
+	windowCap, mainCap, protectedCap int
+	nodes                            map[K]*node[K]
+	window, probation, protected     *nodeList[K]
+	sketch                           *sketch[K]
+}
+
+// NewWTinyLFU returns a policy implementing the W-TinyLFU algorithm, which admits new keys only if they are accessed more frequently than the keys they replace.
+func NewWTinyLFU[K comparable](capacity int) Policy[K] {
+	windowCap := max(capacity/100, 1)
+	mainCap := max(capacity-windowCap, 0)
+	return &tinyLFU[K]{
+		windowCap:    windowCap,
+		mainCap:      mainCap,
+		protectedCap: mainCap * 8 / 10,
+		nodes:        make(map[K]*node[K]),
+		window:       newList[K](),
+		probation:    newList[K](),
+		protected:    newList[K](),
+		sketch:       newSketch[K](capacity),
+	}
+}
+
+func (p *tinyLFU[K]) Hit(key K) {
+	p.sketch.increment(key)
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	switch n.List() {
+	case p.probation:
+		p.probation.Remove(n)
+		p.protected.PushFront(n)
+		if p.protected.Len() > p.protectedCap {
+			demoted := p.protected.Back()
+			p.protected.Remove(demoted)
+			p.probation.PushFront(demoted)
+		}
+	default:
+		n.List().MoveToFront(n)
+	}
+}
+
+func (p *tinyLFU[K]) Add(key K, evict func(K)) {
+	p.sketch.increment(key)
+	n := &node[K]{Value: entry[K]{key: key}}
+	p.nodes[key] = n
+	p.window.PushFront(n)
+	if p.window.Len() <= p.windowCap {
+		return
+	}
+	candidate := p.window.Back()
+	p.window.Remove(candidate)
+	if p.probation.Len()+p.protected.Len() < p.mainCap {
+		p.probation.PushFront(candidate)
+		return
+	}
+	victim := p.probation.Back()
+	if victim == nil {
+		victim = p.protected.Back()
+	}
+	if victim != nil && p.sketch.estimate(candidate.Value.key) > p.sketch.estimate(victim.Value.key) {
+		victim.List().Remove(victim)
+		delete(p.nodes, victim.Value.key)
+		evict(victim.Value.key)
+		p.probation.PushFront(candidate)
+		return
+	}
+	delete(p.nodes, candidate.Value.key)
+	evict(candidate.Value.key)
+}
+
+func (p *tinyLFU[K]) Remove(key K) {
+	if n, ok := p.nodes[key]; ok {
+		n.List().Remove(n)
+		delete(p.nodes, key)
+	}
+}
@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Clear removes all items from the map and resets the state of the policy.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Clear() {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range m.data {
+		b.Add(key, value, removal.Cleared)
+	}
+	m.data = make(map[K]V)
+	m.policy = m.newPolicy(m.capacity)
+}
+
+// Has returns true if the map contains the key, it does not record an access.
+func (m *TypedMap[K, V]) Has(key K) bool {
+	_, ok := m.Peek(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+// An access is recorded, if the key is new the policy may evict other entries, or reject the key itself.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	m.set(&b, key, f(v, ok))
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+// No access is recorded.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range m.data {
+		newValue, ok := f(key, value)
+		if !ok {
+			return
+		}
+		b.Replace(key, value, newValue)
+		m.data[key] = newValue
+	}
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated.
+// No access is recorded.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make(map[K]V, len(m.data))
+	for key, value := range m.data {
+		newValue, ok := f(key, value)
+		if !ok {
+			return false
+		}
+		values[key] = newValue
+	}
+	for key, value := range values {
+		b.Replace(key, m.data[key], value)
+		m.data[key] = value
+	}
+	return true
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+//
+// Once f returns the changes are applied to the map: missing entries are removed, new entries are added
+// to the policy, which may evict other entries, and changed values are updated without recording an access.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := make(map[K]V, len(m.data))
+	for key, value := range m.data {
+		data[key] = value
+	}
+	f(data)
+	for key := range m.data {
+		if value, ok := data[key]; ok {
+			b.Replace(key, m.data[key], value)
+			m.data[key] = value
+			delete(data, key)
+			continue
+		}
+		m.remove(&b, key, removal.Deleted)
+	}
+	for key, value := range data {
+		m.set(&b, key, value)
+	}
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics.
+//
+// Loads do not record an access, stored entries record one as Store does. Depending on the policy, storing new keys may evict
+// other entries, including the ones stored by the transaction, or reject the keys themselves.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of the map, the returned txn.Locked gives access to its content until it is unlocked.
+// Loads do not record an access, stored entries record one as Store does.
+// The removal hook is notified by Notify, once the locks of every map taking part in the transaction are released.
+func (m *TypedMap[K, V]) TxLock() txn.Locked[K, V] {
+	b := removal.NewBatch(m.onRemove)
+	m.mu.Lock()
+	return txn.Locked[K, V]{
+		Load: func(key K) (V, bool) {
+			v, ok := m.data[key]
+			return v, ok
+		},
+		Store: func(key K, value V) {
+			m.set(&b, key, value)
+		},
+		Remove: func(key K) {
+			if _, ok := m.data[key]; ok {
+				m.remove(&b, key, removal.Deleted)
+			}
+		},
+		Unlock: m.mu.Unlock,
+		Notify: b.Notify,
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The map is locked for the duration of the iteration, so the entries are removed atomically. No access is recorded.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range m.data {
+		if f(key, value) {
+			m.remove(&b, key, removal.Deleted)
+			n++
+		}
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map.
+func (m *TypedMap[K, V]) Len() (n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.data)
+}
+
+// Keys returns a slice of all the keys present in the map, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Keys() (keys []K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys = make([]K, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Values() (values []V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values = make([]V, 0, len(m.data))
+	for _, value := range m.data {
+		values = append(values, value)
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map.
+func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys = make([]K, 0, len(m.data))
+	values = make([]V, 0, len(m.data))
+	for key, value := range m.data {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
@@ -0,0 +1,46 @@
+package compute
+
+// Op is the operation Compute performs once the new value has been computed.
+type Op int
+
+const (
+	// Keep leaves the map unchanged.
+	Keep Op = iota
+	// Store sets the computed value for the key.
+	Store
+	// Delete removes the key from the map.
+	Delete
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case Keep:
+		return "Keep"
+	case Store:
+		return "Store"
+	case Delete:
+		return "Delete"
+	}
+	return "Unknown"
+}
+
+// IfAbsent returns a function for Compute that calls f only if the key is not present.
+func IfAbsent[V any](f func() (V, Op)) func(V, bool) (V, Op) {
+	return func(old V, loaded bool) (V, Op) {
+		if loaded {
+			return old, Keep
+		}
+		return f()
+	}
+}
+
+// IfPresent returns a function for Compute that calls f only if the key is present.
+func IfPresent[V any](f func(V) (V, Op)) func(V, bool) (V, Op) {
+	return func(old V, loaded bool) (V, Op) {
+		if !loaded {
+			return old, Keep
+		}
+		return f(old)
+	}
+}
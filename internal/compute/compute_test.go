@@ -0,0 +1,35 @@
+package compute_test
+
+import (
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/compute"
+)
+
+func TestOpString(t *testing.T) {
+	for op, name := range map[compute.Op]string{compute.Keep: "Keep", compute.Store: "Store", compute.Delete: "Delete", -1: "Unknown"} {
+		if op.String() != name {
+			t.Errorf("String(): Expected %s, got %s", name, op.String())
+		}
+	}
+}
+
+func TestIfAbsent(t *testing.T) {
+	f := compute.IfAbsent(func() (int, compute.Op) { return 1, compute.Store })
+	if v, op := f(0, false); v != 1 || op != compute.Store {
+		t.Errorf("IfAbsent(): Expected 1, Store for a missing key, got %d, %s", v, op)
+	}
+	if v, op := f(2, true); v != 2 || op != compute.Keep {
+		t.Errorf("IfAbsent(): Expected 2, Keep for a present key, got %d, %s", v, op)
+	}
+}
+
+func TestIfPresent(t *testing.T) {
+	f := compute.IfPresent(func(old int) (int, compute.Op) { return old + 1, compute.Store })
+	if v, op := f(0, false); v != 0 || op != compute.Keep {
+		t.Errorf("IfPresent(): Expected 0, Keep for a missing key, got %d, %s", v, op)
+	}
+	if v, op := f(2, true); v != 3 || op != compute.Store {
+		t.Errorf("IfPresent(): Expected 3, Store for a present key, got %d, %s", v, op)
+	}
+}
@@ -0,0 +1,47 @@
+package cow
+
+import (
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// Compute calls f with the current value for key, if any, and stores or deletes the key according to the returned Op.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+// The map is copied only if the Op is Store, or Delete of a present key.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Compute(key K, f func(old V, loaded bool) (V, compute.Op)) (value V, ok bool) {
+	m.update(func(d *draft[K, V]) {
+		old, loaded := d.data[key]
+		var op compute.Op
+		value, op = f(old, loaded)
+		switch op {
+		case compute.Store:
+			d.store(key, value)
+			ok = true
+		case compute.Delete:
+			d.delete(key)
+			var zero V
+			value = zero
+		default:
+			value, ok = old, loaded
+		}
+	})
+	return value, ok
+}
+
+// ComputeIfAbsent calls f only if key is not present, and stores the returned value if the Op is Store.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) ComputeIfAbsent(key K, f func() (V, compute.Op)) (value V, ok bool) {
+	return derive.ComputeIfAbsent(m.Load, m.Compute, key, f)
+}
+
+// ComputeIfPresent calls f only if key is present, and stores or deletes the key according to the returned Op.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) ComputeIfPresent(key K, f func(old V) (V, compute.Op)) (value V, ok bool) {
+	return derive.ComputeIfPresent(m.Compute, key, f)
+}
@@ -0,0 +1,126 @@
+package cow
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// TypedMap implements a thread-safe copy-on-write map: reads load an immutable snapshot of the map through an atomic pointer,
+// without locking, while writers copy the snapshot, modify the copy and publish it in its place.
+type TypedMap[K comparable, V any] struct {
+	// data points to the current snapshot, a published snapshot is never modified.
+	data atomic.Pointer[map[K]V]
+	// mu serializes the writers.
+	mu sync.Mutex
+	// pmu guards pending.
+	pmu sync.Mutex
+	// pending holds the writes of Store and Delete waiting to be applied, see apply.
+	pending []write[K, V]
+	equality.Comparer[V]
+	txn.ID
+}
+
+// write is a Store, or a Delete if deleted is set, waiting to be applied.
+type write[K comparable, V any] struct {
+	key     K
+	value   V
+	deleted bool
+}
+
+// draft is a snapshot copied on the first write, so that several writes share a single copy.
+type draft[K comparable, V any] struct {
+	data   map[K]V
+	copied bool
+}
+
+// New returns a new TypedMap holding a copy of m, m can be nil.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](m map[K]V, equal equality.Func[V]) *TypedMap[K, V] {
+	v := &TypedMap[K, V]{
+		Comparer: equality.NewComparer(equal, equality.Deep[V]),
+		ID:       txn.NewID(),
+	}
+	data := make(map[K]V, len(m))
+	maps.Copy(data, m)
+	v.data.Store(&data)
+	return v
+}
+
+// snapshot returns the current snapshot of the map, it must not be modified.
+func (m *TypedMap[K, V]) snapshot() map[K]V {
+	return *m.data.Load()
+}
+
+// draft returns a draft of the current snapshot, the caller must hold the lock.
+func (m *TypedMap[K, V]) draft() *draft[K, V] {
+	return &draft[K, V]{data: m.snapshot()}
+}
+
+// commit publishes d as the current snapshot if it has been written, the caller must hold the lock.
+// d must not be used afterwards.
+func (m *TypedMap[K, V]) commit(d *draft[K, V]) {
+	if d.copied {
+		data := d.data
+		m.data.Store(&data)
+	}
+}
+
+// update calls f with a draft of the current snapshot, publishing it once f returns.
+// If f panics the draft is discarded and the map is left untouched.
+func (m *TypedMap[K, V]) update(f func(d *draft[K, V])) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.draft()
+	f(d)
+	m.commit(d)
+}
+
+// apply queues w and applies it along with the writes queued concurrently, so that concurrent writers share a single copy of the map.
+// Once apply returns w is visible: it has been applied either by the caller or by a writer holding the lock before it.
+func (m *TypedMap[K, V]) apply(w write[K, V]) {
+	m.pmu.Lock()
+	m.pending = append(m.pending, w)
+	m.pmu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pmu.Lock()
+	pending := m.pending
+	m.pending = nil
+	m.pmu.Unlock()
+	d := m.draft()
+	for _, w := range pending {
+		if w.deleted {
+			d.delete(w.key)
+		} else {
+			d.store(w.key, w.value)
+		}
+	}
+	m.commit(d)
+}
+
+// copy copies the snapshot held by d, unless already copied.
+func (d *draft[K, V]) copy() {
+	if !d.copied {
+		d.data = maps.Clone(d.data)
+		d.copied = true
+	}
+}
+
+// store sets the value for key.
+func (d *draft[K, V]) store(key K, value V) {
+	d.copy()
+	d.data[key] = value
+}
+
+// delete removes key, the snapshot is not copied if key is missing.
+func (d *draft[K, V]) delete(key K) {
+	if _, ok := d.data[key]; ok {
+		d.copy()
+		delete(d.data, key)
+	}
+}
@@ -0,0 +1,475 @@
+package cow_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/cow"
+	"github.com/thetechpanda/typedmap/internal/equality"
+)
+
+func TestNew(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected a new map, got map with length %d", m.Len())
+	}
+	data := map[string]int{"a": 1}
+	m = cow.New(data, nil)
+	data["b"] = 2
+	if m.Len() != 1 || !m.Has("a") {
+		t.Errorf("New(): Expected a copy of the given map, got %v", m.Keys())
+	}
+}
+
+func TestLoadStore(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	key := "key"
+	value := 42
+	m.Store(key, value)
+	v, ok := m.Load(key)
+	if !ok || v != value {
+		t.Errorf("Load(): Expected value %d for key %q, got value %d", value, key, v)
+	}
+	if !m.Has(key) {
+		t.Errorf("Has(): Expected key %q to be present", key)
+	}
+	if _, ok := m.Load("not-existent"); ok {
+		t.Errorf("Load(): Expected key %q not to be present", "not-existent")
+	}
+}
+
+func TestLoadOrStore(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	key := "key"
+	value := 42
+	actual, loaded := m.LoadOrStore(key, value)
+	if loaded || actual != value {
+		t.Errorf("LoadOrStore(): Expected key %q to be stored with value %d, got %d", key, value, actual)
+	}
+	actual, loaded = m.LoadOrStore(key, 43)
+	if !loaded || actual != value {
+		t.Errorf("LoadOrStore(): Expected key %q to be loaded with value %d, got %d", key, value, actual)
+	}
+}
+
+func TestLoadAndDelete(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	key := "key"
+	m.Store(key, 42)
+	v, deleted := m.LoadAndDelete(key)
+	if !deleted || v != 42 {
+		t.Errorf("LoadAndDelete(): Expected key %q to be deleted with value 42, got %d", key, v)
+	}
+	if _, deleted := m.LoadAndDelete(key); deleted {
+		t.Errorf("LoadAndDelete(): Expected key %q to be missing", key)
+	}
+	m.Store(key, 42)
+	m.Delete(key)
+	if m.Has(key) {
+		t.Errorf("Has(): Expected key %q to be deleted", key)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	key := "key"
+	previous, loaded := m.Swap(key, 42)
+	if loaded || previous != 0 {
+		t.Errorf("Swap(): Key %q was present in an empty map", key)
+	}
+	previous, loaded = m.Swap(key, 43)
+	if !loaded || previous != 42 {
+		t.Errorf("Swap(): Expected previous value 42, got %d", previous)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	key := "key"
+	m.Store(key, 42)
+	if m.CompareAndSwap(key, 41, 43) {
+		t.Errorf("CompareAndSwap(): Expected key %q not to be swapped", key)
+	}
+	if !m.CompareAndSwap(key, 42, 43) {
+		t.Errorf("CompareAndSwap(): Expected key %q to be swapped", key)
+	}
+	if v, _ := m.Load(key); v != 43 {
+		t.Errorf("Load(): Expected value 43 for key %q, got value %d", key, v)
+	}
+	if m.CompareAndSwap("not-existent", 0, 1) {
+		t.Errorf("CompareAndSwap(): Expected missing key not to be swapped")
+	}
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	key := "key"
+	m.Store(key, 42)
+	if m.CompareAndDelete(key, 43) {
+		t.Errorf("CompareAndDelete(): Expected key %q to not be deleted", key)
+	}
+	if !m.CompareAndDelete(key, 42) {
+		t.Errorf("CompareAndDelete(): Expected key %q to be deleted", key)
+	}
+	if m.Has(key) {
+		t.Errorf("Has(): Expected key %q to be deleted", key)
+	}
+}
+
+func TestNotComparableType(t *testing.T) {
+	m := cow.New[int, []int](nil, nil)
+	m.Store(1, []int{1, 2, 3})
+	if m.CompareAndDelete(1, []int{1, 2, 3}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if m.CompareAndSwap(1, []int{1, 2, 3}, []int{1, 2, 3, 4}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := m.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := m.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := cow.New[int, []int](nil, slices.Equal[[]int])
+	e.Store(1, []int{1})
+	if swapped, err := e.TryCompareAndSwap(1, []int{1}, []int{2}); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := e.TryCompareAndDelete(1, []int{2}); !deleted || err != nil || e.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+}
+
+func TestKeysValuesEntries(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	if len(m.Keys()) != 0 || len(m.Values()) != 0 {
+		t.Errorf("Keys(), Values(): Expected empty slices")
+	}
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	sum := func(s []int) (n int) {
+		for _, v := range s {
+			n += v
+		}
+		return n
+	}
+	if keys := m.Keys(); len(keys) != 100 || sum(keys) != 4950 {
+		t.Errorf("Keys(): Expected 100 keys with sum 4950, got %d keys with sum %d", len(keys), sum(keys))
+	}
+	if values := m.Values(); len(values) != 100 || sum(values) != 4950 {
+		t.Errorf("Values(): Expected 100 values with sum 4950, got %d values with sum %d", len(values), sum(values))
+	}
+	keys, values := m.Entries()
+	if len(keys) != 100 || len(values) != 100 {
+		t.Fatalf("Entries(): Expected 100 entries, got %d keys and %d values", len(keys), len(values))
+	}
+	for i := range keys {
+		if keys[i] != values[i] {
+			t.Errorf("Entries(): Expected key %d to match value %d", keys[i], values[i])
+		}
+	}
+	if m.Len() != 100 {
+		t.Errorf("Len(): Expected length 100, got %d", m.Len())
+	}
+}
+
+func TestRange(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, 1)
+	}
+	var sum int
+	m.Range(func(key, value int) bool {
+		sum += value
+		return true
+	})
+	if sum != 100 {
+		t.Errorf("Range(): Expected sum 100, got %d", sum)
+	}
+	sum = 0
+	m.Range(func(key, value int) bool {
+		sum++
+		return false
+	})
+	if sum != 1 {
+		t.Errorf("Range(): Expected sum 1, got %d", sum)
+	}
+}
+
+func TestUpdateRange(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, 1)
+	}
+	m.UpdateRange(func(k, v int) (int, bool) {
+		return 2, true
+	})
+	for _, v := range m.Values() {
+		if v != 2 {
+			t.Fatalf("UpdateRange(): Expected value 2, got %d", v)
+		}
+	}
+	var mapKey, count int
+	m.UpdateRange(func(k, v int) (int, bool) {
+		count++
+		mapKey = k
+		return 0, false
+	})
+	if count != 1 {
+		t.Errorf("UpdateRange(): Expected 1 call, got %d", count)
+	}
+	if v, _ := m.Load(mapKey); v != 2 {
+		t.Errorf("Load(): Expected value 2, got %d", v)
+	}
+}
+
+func TestExclusive(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	m.Exclusive(func(data map[int]int) {
+		if len(data) != 100 {
+			t.Errorf("Exclusive(): Expected 100 entries, got %d", len(data))
+		}
+		for k := range data {
+			if k%2 == 0 {
+				delete(data, k)
+			}
+		}
+		data[1000] = 1000
+	})
+	if m.Len() != 51 {
+		t.Errorf("Len(): Expected length 51, got %d", m.Len())
+	}
+	for i := 0; i < 100; i++ {
+		if m.Has(i) == (i%2 == 0) {
+			t.Errorf("Has(): Unexpected presence of key %d", i)
+		}
+	}
+	if v, ok := m.Load(1000); !ok || v != 1000 {
+		t.Errorf("Load(): Expected key 1000 to be added by Exclusive")
+	}
+
+	func() {
+		defer func() { recover() }()
+		m.Exclusive(func(data map[int]int) {
+			data[2000] = 2000
+			panic("abort")
+		})
+	}()
+	if m.Len() != 51 || m.Has(2000) {
+		t.Errorf("Exclusive(): Expected the copy to be discarded after panic, got length %d", m.Len())
+	}
+}
+
+func TestClear(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	m.Clear()
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected length 0, got %d", m.Len())
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	m := cow.New[string, int](nil, nil)
+	var wg sync.WaitGroup
+	n := 100
+	loops := 10
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < loops; i++ {
+				m.Update("key", func(value int, ok bool) int {
+					return value + 1
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if value, _ := m.Load("key"); value != n*loops {
+		t.Errorf("Load(): Expected final value to be %d, got %d", n*loops, value)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	numGoroutines := 100
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			// uses context done to have all goroutines start at the same time
+			<-ctx.Done()
+			for j := 0; j < numGoroutines; j++ {
+				if _, ok := m.Load(j); !ok {
+					m.Store(j, i*i)
+				}
+				m.Keys()
+				m.Len()
+				m.Delete(j)
+			}
+		}(i)
+	}
+	cancel()
+	wg.Wait()
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected length 0, got %d", m.Len())
+	}
+}
+
+func TestConcurrentAccessUpdateRange(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	numGoroutines := 100
+	for i := 0; i < numGoroutines; i++ {
+		m.Store(i, 0)
+	}
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			<-ctx.Done()
+			for j := 0; j < numGoroutines; j++ {
+				m.UpdateRange(func(k, v int) (int, bool) {
+					return v + 1, true
+				})
+				m.Update(i, func(v int, ok bool) int {
+					return v + 1
+				})
+			}
+		}(i)
+	}
+	cancel()
+	wg.Wait()
+	m.Range(func(k, v int) bool {
+		if v != numGoroutines*numGoroutines+numGoroutines {
+			t.Errorf("Expected value %d, got %d", numGoroutines*numGoroutines+numGoroutines, v)
+			return false
+		}
+		return true
+	})
+}
+
+func TestIterators(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i*2)
+	}
+
+	clone := maps.Collect(m.All())
+	if len(clone) != 100 {
+		t.Errorf("All(): Expected 100 entries, got %d", len(clone))
+	}
+	for k, v := range clone {
+		if v != k*2 {
+			t.Errorf("All(): Expected value %d for key %d, got %d", k*2, k, v)
+		}
+	}
+
+	keys := slices.Sorted(m.KeysSeq())
+	if len(keys) != 100 || keys[0] != 0 || keys[99] != 99 {
+		t.Errorf("KeysSeq(): Expected sorted keys from 0 to 99, got %v", keys)
+	}
+
+	values := slices.Sorted(m.ValuesSeq())
+	if len(values) != 100 || values[0] != 0 || values[99] != 198 {
+		t.Errorf("ValuesSeq(): Expected sorted values from 0 to 198, got %v", values)
+	}
+
+	count := 0
+	for range m.All() {
+		count++
+		break
+	}
+	for range m.KeysSeq() {
+		count++
+		break
+	}
+	for range m.ValuesSeq() {
+		count++
+		break
+	}
+	if count != 3 {
+		t.Errorf("Expected iterators to stop after break, got %d iterations", count)
+	}
+
+	// the lock is released once the loop exits.
+	m.Store(100, 200)
+}
+
+func TestSnapshot(t *testing.T) {
+	m := cow.New(map[int]int{0: 0, 1: 1, 2: 2}, nil)
+	// the iteration observes the snapshot taken when it started, f can write to the map.
+	n := 0
+	m.Range(func(k, v int) bool {
+		m.Store(k+10, v)
+		m.Delete(k)
+		n++
+		return true
+	})
+	if n != 3 || m.Len() != 3 || !m.Has(10) || m.Has(0) {
+		t.Errorf("Range(): Expected 3 entries to be visited and moved, got %d visited, %v", n, m.Keys())
+	}
+	for k := range m.KeysSeq() {
+		m.Delete(k)
+	}
+	if m.Len() != 0 {
+		t.Errorf("KeysSeq(): Expected every key to be deleted, got %v", m.Keys())
+	}
+
+	// readers observe the previous snapshot while a writer holds the lock.
+	m.Store(1, 1)
+	m.Exclusive(func(data map[int]int) {
+		data[1] = 2
+		if v, _ := m.Load(1); v != 1 {
+			t.Errorf("Load(): Expected the published value 1, got %d", v)
+		}
+	})
+	if v, _ := m.Load(1); v != 2 {
+		t.Errorf("Load(): Expected value 2 once published, got %d", v)
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	m := cow.New[int, int](nil, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Store(i*100+j, j)
+				if v, ok := m.Load(i*100 + j); !ok || v != j {
+					t.Errorf("Load(): Expected the stored value %d to be visible, got %d, %v", j, v, ok)
+				}
+				if j%2 == 0 {
+					m.Delete(i*100 + j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if m.Len() != 5000 {
+		t.Errorf("Len(): Expected 5000 entries, got %d", m.Len())
+	}
+	// deleting a missing key does not copy the map.
+	m.Delete(-1)
+	if _, ok := m.LoadAndDelete(-1); ok {
+		t.Errorf("LoadAndDelete(): Expected key -1 to be missing")
+	}
+}
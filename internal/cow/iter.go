@@ -0,0 +1,25 @@
+package cow
+
+import (
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// All returns an iterator over the key-value pairs in the map, it iterates over a snapshot of the map as Range does:
+// the loop body can invoke any map function.
+func (m *TypedMap[K, V]) All() iter.Seq2[K, V] {
+	return derive.All(m.Range)
+}
+
+// KeysSeq returns an iterator over the keys in the map, it iterates over a snapshot of the map as Range does:
+// the loop body can invoke any map function.
+func (m *TypedMap[K, V]) KeysSeq() iter.Seq[K] {
+	return derive.Keys(m.Range)
+}
+
+// ValuesSeq returns an iterator over the values in the map, it iterates over a snapshot of the map as Range does:
+// the loop body can invoke any map function.
+func (m *TypedMap[K, V]) ValuesSeq() iter.Seq[V] {
+	return derive.Values(m.Range)
+}
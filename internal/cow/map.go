@@ -0,0 +1,126 @@
+package cow
+
+import "github.com/thetechpanda/typedmap/internal/derive"
+
+// Store sets the value for a key.
+// Concurrent calls to Store and Delete are applied together, using a single copy of the map.
+func (m *TypedMap[K, V]) Store(key K, value V) {
+	m.apply(write[K, V]{key: key, value: value})
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.
+// The ok result indicates whether value was found in the map.
+// Load does not lock the map.
+func (m *TypedMap[K, V]) Load(key K) (v V, ok bool) {
+	v, ok = m.snapshot()[key]
+	return v, ok
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *TypedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if actual, loaded = m.Load(key); loaded {
+		return actual, true
+	}
+	m.update(func(d *draft[K, V]) {
+		if actual, loaded = d.data[key]; !loaded {
+			actual = value
+			d.store(key, value)
+		}
+	})
+	return actual, loaded
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	if _, ok := m.Load(key); !ok {
+		return value, false
+	}
+	m.update(func(d *draft[K, V]) {
+		value, loaded = d.data[key]
+		d.delete(key)
+	})
+	return value, loaded
+}
+
+// Delete removes the key from the map.
+// Concurrent calls to Store and Delete are applied together, using a single copy of the map.
+func (m *TypedMap[K, V]) Delete(key K) {
+	if _, ok := m.Load(key); ok {
+		m.apply(write[K, V]{key: key, deleted: true})
+	}
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	m.update(func(d *draft[K, V]) {
+		previous, loaded = d.data[key]
+		d.store(key, value)
+	})
+	return previous, loaded
+}
+
+// CompareAndSwap swaps the old and new values for key
+// if the value stored in the map is equal to old.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+//
+// Returns true if the swap was performed.
+func (m *TypedMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	m.update(func(d *draft[K, V]) {
+		v, ok := d.data[key]
+		if swapped = ok && m.ValuesEqual(v, old); swapped {
+			d.store(key, new)
+		}
+	})
+	return swapped
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
+//
+// If there is no current value for key in the map, CompareAndDelete
+// returns false (even if the old value is the nil interface value).
+func (m *TypedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	m.update(func(d *draft[K, V]) {
+		v, ok := d.data[key]
+		if deleted = ok && m.ValuesEqual(v, old); deleted {
+			d.delete(key)
+		}
+	})
+	return deleted
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+//
+// Range iterates over a snapshot of the map without locking it: f can invoke any map function,
+// and the changes made while iterating are not observed.
+func (m *TypedMap[K, V]) Range(f func(K, V) bool) {
+	for key, value := range m.snapshot() {
+		if !f(key, value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package cow
+
+import "github.com/thetechpanda/typedmap/internal/txn"
+
+// Clear removes all items from the map.
+func (m *TypedMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := make(map[K]V)
+	m.data.Store(&data)
+}
+
+// Has returns true if the map contains the key.
+// Has does not lock the map.
+func (m *TypedMap[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	m.update(func(d *draft[K, V]) {
+		v, ok := d.data[key]
+		d.store(key, f(v, ok))
+	})
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+//
+// The values are written to a single copy of the map, published once the iteration stops.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	m.update(func(d *draft[K, V]) {
+		for key, value := range m.snapshot() {
+			newValue, ok := f(key, value)
+			if !ok {
+				return
+			}
+			d.store(key, newValue)
+		}
+	})
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) (updated bool) {
+	m.update(func(d *draft[K, V]) {
+		for key, value := range m.snapshot() {
+			newValue, ok := f(key, value)
+			if !ok {
+				// discards the values written so far.
+				d.copied = false
+				return
+			}
+			d.store(key, newValue)
+		}
+		updated = true
+	})
+	return updated
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+//
+// f receives a copy of the map, published once f returns: readers keep observing the previous snapshot while f runs.
+// If f panics the copy is discarded and the map is left untouched.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	m.update(func(d *draft[K, V]) {
+		d.copy()
+		f(d.data)
+	})
+}
+
+// Transaction calls f with a transaction staging the writes to the map, writers are locked out for the duration of f.
+// The staged writes are applied to a single copy of the map, published once f returns nil, they are discarded if f returns an error or panics.
+// Transaction is the way to batch several writes, amortizing the cost of copying the map.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of the writers, the returned txn.Locked gives access to a draft of the map,
+// published when it is unlocked.
+func (m *TypedMap[K, V]) TxLock() txn.Locked[K, V] {
+	m.mu.Lock()
+	d := m.draft()
+	return txn.Locked[K, V]{
+		Load: func(key K) (V, bool) {
+			v, ok := d.data[key]
+			return v, ok
+		},
+		Store:  d.store,
+		Remove: d.delete,
+		Unlock: func() {
+			m.commit(d)
+			m.mu.Unlock()
+		},
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The entries are removed from a single copy of the map, published once every entry has been visited.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	m.update(func(d *draft[K, V]) {
+		for key, value := range m.snapshot() {
+			if f(key, value) {
+				d.delete(key)
+				n++
+			}
+		}
+	})
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions writing to the map within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map.
+// Len does not lock the map.
+func (m *TypedMap[K, V]) Len() int {
+	return len(m.snapshot())
+}
+
+// Keys returns a slice of all the keys present in the map, an empty slice is returned if the map is empty.
+// The keys are taken from a single snapshot of the map, without locking it.
+func (m *TypedMap[K, V]) Keys() (keys []K) {
+	data := m.snapshot()
+	keys = make([]K, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, an empty slice is returned if the map is empty.
+// The values are taken from a single snapshot of the map, without locking it.
+func (m *TypedMap[K, V]) Values() (values []V) {
+	data := m.snapshot()
+	values = make([]V, 0, len(data))
+	for _, value := range data {
+		values = append(values, value)
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map.
+// The entries are taken from a single snapshot of the map, without locking it.
+func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
+	data := m.snapshot()
+	keys = make([]K, 0, len(data))
+	values = make([]V, 0, len(data))
+	for key, value := range data {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
@@ -0,0 +1,72 @@
+// Package derive implements the functions shared by the maps on top of their primitives:
+// the iterators on top of Range, ComputeIfAbsent and ComputeIfPresent on top of Compute,
+// TryCompareAndSwap and TryCompareAndDelete on top of CompareAndSwap and CompareAndDelete.
+package derive
+
+import (
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/equality"
+)
+
+// All returns an iterator over the key-value pairs visited by rangeFunc.
+func All[K, V any](rangeFunc func(f func(K, V) bool)) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		rangeFunc(yield)
+	}
+}
+
+// Keys returns an iterator over the keys visited by rangeFunc.
+func Keys[K, V any](rangeFunc func(f func(K, V) bool)) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		rangeFunc(func(key K, _ V) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over the values visited by rangeFunc.
+func Values[K, V any](rangeFunc func(f func(K, V) bool)) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		rangeFunc(func(_ K, value V) bool {
+			return yield(value)
+		})
+	}
+}
+
+// ComputeIfAbsent returns the value for key if load finds it, otherwise it calls computeFunc calling f only if key is still not present.
+func ComputeIfAbsent[K, V any](
+	load func(key K) (V, bool),
+	computeFunc func(key K, f func(old V, loaded bool) (V, compute.Op)) (V, bool),
+	key K, f func() (V, compute.Op),
+) (value V, ok bool) {
+	if value, ok := load(key); ok {
+		return value, true
+	}
+	return computeFunc(key, compute.IfAbsent(f))
+}
+
+// ComputeIfPresent calls computeFunc calling f only if key is present.
+func ComputeIfPresent[K, V any](
+	computeFunc func(key K, f func(old V, loaded bool) (V, compute.Op)) (V, bool),
+	key K, f func(old V) (V, compute.Op),
+) (value V, ok bool) {
+	return computeFunc(key, compute.IfPresent(f))
+}
+
+// TryCompareAndSwap calls compareAndSwap, unless the values are not comparable in which case it returns equality.ErrNotComparable.
+func TryCompareAndSwap[K, V any](canCompare bool, compareAndSwap func(key K, old, new V) bool, key K, old, new V) (swapped bool, err error) {
+	if !canCompare {
+		return false, equality.ErrNotComparable
+	}
+	return compareAndSwap(key, old, new), nil
+}
+
+// TryCompareAndDelete calls compareAndDelete, unless the values are not comparable in which case it returns equality.ErrNotComparable.
+func TryCompareAndDelete[K, V any](canCompare bool, compareAndDelete func(key K, old V) bool, key K, old V) (deleted bool, err error) {
+	if !canCompare {
+		return false, equality.ErrNotComparable
+	}
+	return compareAndDelete(key, old), nil
+}
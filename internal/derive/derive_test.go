@@ -0,0 +1,120 @@
+package derive_test
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/derive"
+	"github.com/thetechpanda/typedmap/internal/equality"
+)
+
+// testMap implements the primitives over a plain map.
+type testMap map[string]int
+
+func (m testMap) Range(f func(string, int) bool) {
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if !f(key, m[key]) {
+			return
+		}
+	}
+}
+
+func (m testMap) Load(key string) (int, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m testMap) Compute(key string, f func(old int, loaded bool) (int, compute.Op)) (int, bool) {
+	old, loaded := m[key]
+	value, op := f(old, loaded)
+	switch op {
+	case compute.Store:
+		m[key] = value
+		return value, true
+	case compute.Delete:
+		delete(m, key)
+		return 0, false
+	}
+	return old, loaded
+}
+
+func (m testMap) CompareAndSwap(key string, old, new int) bool {
+	if v, ok := m[key]; ok && v == old {
+		m[key] = new
+		return true
+	}
+	return false
+}
+
+func (m testMap) CompareAndDelete(key string, old int) bool {
+	if v, ok := m[key]; ok && v == old {
+		delete(m, key)
+		return true
+	}
+	return false
+}
+
+func TestIterators(t *testing.T) {
+	m := testMap{"a": 1, "b": 2, "c": 3}
+	if got := maps.Collect(derive.All(m.Range)); !maps.Equal(got, m) {
+		t.Errorf("All(): Expected %v, got %v", m, got)
+	}
+	if keys := slices.Collect(derive.Keys(m.Range)); !slices.Equal(keys, []string{"a", "b", "c"}) {
+		t.Errorf("Keys(): Expected [a b c], got %v", keys)
+	}
+	if values := slices.Collect(derive.Values(m.Range)); !slices.Equal(values, []int{1, 2, 3}) {
+		t.Errorf("Values(): Expected [1 2 3], got %v", values)
+	}
+	for range derive.All(m.Range) {
+		break
+	}
+	for range derive.Keys(m.Range) {
+		break
+	}
+	for range derive.Values(m.Range) {
+		break
+	}
+}
+
+func TestCompute(t *testing.T) {
+	m := testMap{"a": 1}
+	calls := 0
+	absent := func() (int, compute.Op) {
+		calls++
+		return 2, compute.Store
+	}
+	if v, ok := derive.ComputeIfAbsent(m.Load, m.Compute, "a", absent); !ok || v != 1 || calls != 0 {
+		t.Errorf("ComputeIfAbsent(): Expected the present value 1 without calling f, got %d, %v after %d calls", v, ok, calls)
+	}
+	if v, ok := derive.ComputeIfAbsent(m.Load, m.Compute, "b", absent); !ok || v != 2 || m["b"] != 2 {
+		t.Errorf("ComputeIfAbsent(): Expected 2 to be stored, got %d, %v", v, ok)
+	}
+	present := func(old int) (int, compute.Op) {
+		return old * 10, compute.Store
+	}
+	if v, ok := derive.ComputeIfPresent(m.Compute, "a", present); !ok || v != 10 {
+		t.Errorf("ComputeIfPresent(): Expected 10, got %d, %v", v, ok)
+	}
+	if v, ok := derive.ComputeIfPresent(m.Compute, "c", present); ok || v != 0 || len(m) != 2 {
+		t.Errorf("ComputeIfPresent(): Expected a missing key to be left missing, got %d, %v", v, ok)
+	}
+}
+
+func TestTryCompare(t *testing.T) {
+	m := testMap{"a": 1, "b": 2}
+	if swapped, err := derive.TryCompareAndSwap(true, m.CompareAndSwap, "a", 1, 3); !swapped || err != nil || m["a"] != 3 {
+		t.Errorf("TryCompareAndSwap(): Expected a swap, got %v, %v", swapped, err)
+	}
+	if swapped, err := derive.TryCompareAndSwap(false, m.CompareAndSwap, "a", 3, 4); swapped || !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v, %v", swapped, err)
+	}
+	if deleted, err := derive.TryCompareAndDelete(true, m.CompareAndDelete, "b", 2); !deleted || err != nil || len(m) != 1 {
+		t.Errorf("TryCompareAndDelete(): Expected a deletion, got %v, %v", deleted, err)
+	}
+	if deleted, err := derive.TryCompareAndDelete(false, m.CompareAndDelete, "a", 3); deleted || !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v, %v", deleted, err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package equality provides the functions used by the maps to compare values in CompareAndSwap and CompareAndDelete.
+package equality
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrNotComparable is returned when the values stored in a map cannot be compared.
+var ErrNotComparable = errors.New("typedmap: values are not comparable")
+
+// Func reports whether a and b are equal.
+type Func[V any] func(a, b V) bool
+
+// Equaler is implemented by the values providing their own equality.
+type Equaler[V any] interface {
+	Equal(V) bool
+}
+
+// For returns the function comparing the values of type V: f if not nil, the Equal method of V if V implements Equaler,
+// otherwise def if V is a comparable type. If none applies For returns nil, values of type V cannot be compared.
+//
+// Interface types are considered comparable, even if the values they hold might not be.
+func For[V any](f, def Func[V]) Func[V] {
+	if f != nil {
+		return f
+	}
+	t := reflect.TypeFor[V]()
+	if t.Implements(reflect.TypeFor[Equaler[V]]()) {
+		return method[V]
+	}
+	if t.Comparable() {
+		return def
+	}
+	return nil
+}
+
+// Comparer compares the values of a map for CompareAndSwap and CompareAndDelete, it is embedded by the maps.
+// The zero Comparer cannot compare values.
+type Comparer[V any] struct {
+	equal Func[V]
+}
+
+// NewComparer returns a Comparer comparing the values using the function returned by For(f, def).
+func NewComparer[V any](f, def Func[V]) Comparer[V] {
+	return Comparer[V]{equal: For(f, def)}
+}
+
+// ValuesComparable reports whether the values can be compared, if not CompareAndSwap and CompareAndDelete return false.
+func (c Comparer[V]) ValuesComparable() bool {
+	return c.equal != nil
+}
+
+// ValuesEqual reports whether a and b are equal, it must be called only if ValuesComparable returns true.
+func (c Comparer[V]) ValuesEqual(a, b V) bool {
+	return c.equal(a, b)
+}
+
+// Default reports whether For returns def for the values of type V when f is nil:
+// V is a comparable type not implementing Equaler.
+func Default[V any]() bool {
+	t := reflect.TypeFor[V]()
+	return t.Comparable() && !t.Implements(reflect.TypeFor[Equaler[V]]())
+}
+
+// method compares a and b using the Equal method of a.
+func method[V any](a, b V) bool {
+	if e, ok := any(a).(Equaler[V]); ok {
+		return e.Equal(b)
+	}
+	// a is a nil interface value.
+	return any(b) == nil
+}
+
+// Deep compares a and b using reflect.DeepEqual.
+func Deep[V any](a, b V) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// Identical compares a and b using ==, as sync.Map does.
+// It panics if V is an interface type and the values held by a and b are of the same non comparable type.
+func Identical[V any](a, b V) bool {
+	return any(a) == any(b)
+}
@@ -0,0 +1,87 @@
+package equality_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+)
+
+// named is an interface type whose values provide their own equality.
+type named interface {
+	Equal(named) bool
+}
+
+// name is a named compared ignoring the case.
+type name string
+
+func (n name) Equal(o named) bool {
+	other, ok := o.(name)
+	return ok && bytes.EqualFold([]byte(n), []byte(other))
+}
+
+func TestFor(t *testing.T) {
+	never := func(a, b int) bool { return false }
+	if f := equality.For(never, equality.Identical[int]); f(1, 1) {
+		t.Error("For(): Expected the given function to take precedence")
+	}
+	if f := equality.For(nil, equality.Identical[int]); !f(1, 1) || f(1, 2) {
+		t.Error("For(): Expected the default function for comparable types")
+	}
+	if f := equality.For(nil, equality.Deep[[]int]); f != nil {
+		t.Error("For(): Expected nil for non comparable types")
+	}
+	if f := equality.For(bytes.Equal, equality.Deep[[]byte]); !f([]byte("a"), []byte("a")) {
+		t.Error("For(): Expected the given function for non comparable types")
+	}
+
+	// the Equal method takes precedence over the default function.
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if f := equality.For(nil, equality.Identical[time.Time]); !f(at, at.In(time.FixedZone("X", 3600))) {
+		t.Error("For(): Expected time.Time to be compared using Equal")
+	}
+
+	// interface types are comparable, even if the values they hold are not.
+	if f := equality.For(nil, equality.Deep[any]); f == nil || !f([]int{1}, []int{1}) {
+		t.Error("For(): Expected interface values to be compared using the default function")
+	}
+
+	// interface types implementing Equaler use the method of the value held, nil values are equal only to nil.
+	f := equality.For(nil, equality.Identical[named])
+	if !f(name("a"), name("A")) || f(name("a"), name("b")) {
+		t.Error("For(): Expected named values to be compared using Equal")
+	}
+	if !f(nil, nil) || f(nil, name("a")) || f(name("a"), nil) {
+		t.Error("For(): Expected nil named values to be equal only to nil")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if !equality.Default[int]() || !equality.Default[any]() {
+		t.Error("Default(): Expected comparable types to use the default function")
+	}
+	if equality.Default[[]int]() || equality.Default[time.Time]() || equality.Default[named]() {
+		t.Error("Default(): Expected non comparable types and Equaler types not to use the default function")
+	}
+}
+
+func TestDeep(t *testing.T) {
+	a, b := &[]int{1}, &[]int{1}
+	if !equality.Deep(a, b) || equality.Deep(a, &[]int{2}) {
+		t.Error("Deep(): Expected values pointed to be compared")
+	}
+}
+
+func TestIdentical(t *testing.T) {
+	a, b := &[]int{1}, &[]int{1}
+	if equality.Identical(a, b) || !equality.Identical(a, a) {
+		t.Error("Identical(): Expected pointers to be compared by identity")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Identical(): Expected a panic comparing non comparable values")
+		}
+	}()
+	equality.Identical[any]([]int{1}, []int{1})
+}
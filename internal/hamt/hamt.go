@@ -0,0 +1,223 @@
+package hamt
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+const (
+	// bitsPerLevel is the number of bits of the hash consumed by each level of the trie.
+	bitsPerLevel = 5
+	levelMask    = 1<<bitsPerLevel - 1
+)
+
+// seed is the seed of the default hash function, shared by every map so that the zero Map is ready to use.
+var seed = maphash.MakeSeed()
+
+// node is a bitmap indexed node: bitmap has a bit set for each of the positions holding a slot,
+// slots are stored in the order of their positions.
+// Nodes are never modified once built, a change copies the nodes from the root down to the changed slot.
+type node[K comparable, V any] struct {
+	bitmap uint32
+	slots  []slot[K, V]
+}
+
+// slot is either a sub node or a leaf: only one of the fields is set.
+type slot[K comparable, V any] struct {
+	sub  *node[K, V]
+	leaf *leaf[K, V]
+}
+
+// leaf holds the entries whose keys have the same hash, usually a single one.
+type leaf[K comparable, V any] struct {
+	hash    uint64
+	entries []entry[K, V]
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// position returns the bit of the position of hash in a node at the level of shift.
+func position(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & levelMask)
+}
+
+// index returns the index in n.slots of the slot at bit.
+func (n *node[K, V]) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// load returns the value for key.
+func (n *node[K, V]) load(hash uint64, key K, shift uint) (v V, ok bool) {
+	for {
+		bit := position(hash, shift)
+		if n.bitmap&bit == 0 {
+			return v, false
+		}
+		s := n.slots[n.index(bit)]
+		if s.leaf != nil {
+			return s.leaf.load(hash, key)
+		}
+		n, shift = s.sub, shift+bitsPerLevel
+	}
+}
+
+// with returns a copy of n where key is set to value, added reports whether key was missing.
+func (n *node[K, V]) with(hash uint64, key K, value V, shift uint) (_ *node[K, V], added bool) {
+	bit := position(hash, shift)
+	i := n.index(bit)
+	if n.bitmap&bit == 0 {
+		return n.insert(bit, i, slot[K, V]{leaf: &leaf[K, V]{hash: hash, entries: []entry[K, V]{{key, value}}}}), true
+	}
+	s := n.slots[i]
+	switch {
+	case s.sub != nil:
+		s.sub, added = s.sub.with(hash, key, value, shift+bitsPerLevel)
+	case s.leaf.hash == hash:
+		s.leaf, added = s.leaf.with(key, value)
+	default:
+		// the hashes differ, a sub node is needed for the levels where they match.
+		s = slot[K, V]{sub: pair(s.leaf, &leaf[K, V]{hash: hash, entries: []entry[K, V]{{key, value}}}, shift+bitsPerLevel)}
+		added = true
+	}
+	return n.set(i, s), added
+}
+
+// pair returns a node holding a and b, whose hashes differ, placed at the level of shift.
+func pair[K comparable, V any](a, b *leaf[K, V], shift uint) *node[K, V] {
+	ba, bb := position(a.hash, shift), position(b.hash, shift)
+	if ba == bb {
+		return &node[K, V]{bitmap: ba, slots: []slot[K, V]{{sub: pair(a, b, shift+bitsPerLevel)}}}
+	}
+	if ba > bb {
+		a, b = b, a
+	}
+	return &node[K, V]{bitmap: ba | bb, slots: []slot[K, V]{{leaf: a}, {leaf: b}}}
+}
+
+// without returns a copy of n where key is missing, removed reports whether key was present.
+// The result is nil if no slot is left.
+func (n *node[K, V]) without(hash uint64, key K, shift uint) (_ *node[K, V], removed bool) {
+	bit := position(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	i := n.index(bit)
+	s := n.slots[i]
+	if s.sub != nil {
+		if s.sub, removed = s.sub.without(hash, key, shift+bitsPerLevel); !removed {
+			return n, false
+		}
+		if s.sub != nil && len(s.sub.slots) == 1 && s.sub.slots[0].leaf != nil {
+			// a single leaf is left, it takes the place of its node.
+			s = s.sub.slots[0]
+		}
+	} else {
+		if s.leaf.hash != hash {
+			return n, false
+		}
+		if s.leaf, removed = s.leaf.without(key); !removed {
+			return n, false
+		}
+	}
+	if s.sub == nil && s.leaf == nil {
+		return n.remove(bit, i), true
+	}
+	return n.set(i, s), true
+}
+
+// insert returns a copy of n where s is placed at bit, at index i.
+func (n *node[K, V]) insert(bit uint32, i int, s slot[K, V]) *node[K, V] {
+	slots := make([]slot[K, V], len(n.slots)+1)
+	copy(slots, n.slots[:i])
+	slots[i] = s
+	copy(slots[i+1:], n.slots[i:])
+	return &node[K, V]{bitmap: n.bitmap | bit, slots: slots}
+}
+
+// set returns a copy of n where the slot at index i is replaced by s.
+func (n *node[K, V]) set(i int, s slot[K, V]) *node[K, V] {
+	slots := make([]slot[K, V], len(n.slots))
+	copy(slots, n.slots)
+	slots[i] = s
+	return &node[K, V]{bitmap: n.bitmap, slots: slots}
+}
+
+// remove returns a copy of n without the slot at bit, at index i, or nil if no slot is left.
+func (n *node[K, V]) remove(bit uint32, i int) *node[K, V] {
+	if len(n.slots) == 1 {
+		return nil
+	}
+	slots := make([]slot[K, V], 0, len(n.slots)-1)
+	slots = append(slots, n.slots[:i]...)
+	slots = append(slots, n.slots[i+1:]...)
+	return &node[K, V]{bitmap: n.bitmap &^ bit, slots: slots}
+}
+
+// load returns the value for key, l holds the entries whose keys have the same hash as key.
+func (l *leaf[K, V]) load(hash uint64, key K) (v V, ok bool) {
+	if l.hash != hash {
+		return v, false
+	}
+	for _, e := range l.entries {
+		if e.key == key {
+			return e.value, true
+		}
+	}
+	return v, false
+}
+
+// with returns a copy of l where key is set to value, added reports whether key was missing.
+func (l *leaf[K, V]) with(key K, value V) (_ *leaf[K, V], added bool) {
+	entries := make([]entry[K, V], len(l.entries), len(l.entries)+1)
+	copy(entries, l.entries)
+	added = true
+	for i := range entries {
+		if entries[i].key == key {
+			entries[i].value = value
+			added = false
+			break
+		}
+	}
+	if added {
+		entries = append(entries, entry[K, V]{key, value})
+	}
+	return &leaf[K, V]{hash: l.hash, entries: entries}, added
+}
+
+// without returns a copy of l without key, or nil if no entry is left. removed reports whether key was present.
+func (l *leaf[K, V]) without(key K) (_ *leaf[K, V], removed bool) {
+	for i, e := range l.entries {
+		if e.key != key {
+			continue
+		}
+		if len(l.entries) == 1 {
+			return nil, true
+		}
+		entries := make([]entry[K, V], 0, len(l.entries)-1)
+		entries = append(entries, l.entries[:i]...)
+		entries = append(entries, l.entries[i+1:]...)
+		return &leaf[K, V]{hash: l.hash, entries: entries}, true
+	}
+	return l, false
+}
+
+// iter calls f for each entry held by n, it returns false if f did.
+func (n *node[K, V]) iter(f func(K, V) bool) bool {
+	for _, s := range n.slots {
+		if s.sub != nil {
+			if !s.sub.iter(f) {
+				return false
+			}
+			continue
+		}
+		for _, e := range s.leaf.entries {
+			if !f(e.key, e.value) {
+				return false
+			}
+		}
+	}
+	return true
+}
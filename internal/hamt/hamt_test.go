@@ -0,0 +1,167 @@
+package hamt_test
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/hamt"
+)
+
+// hashes are the hash functions the tests are run with: the default one, the identity, placing keys differing
+// only in their high bits deep in the trie, and one where keys collide, sharing the same leaf.
+var hashes = map[string]func(int) uint64{
+	"maphash":   nil,
+	"identity":  func(k int) uint64 { return uint64(k) },
+	"collision": func(k int) uint64 { return uint64(k % 4) },
+}
+
+// forEachHash runs f as a subtest for each of the hash functions.
+func forEachHash(t *testing.T, f func(t *testing.T, m hamt.Map[int, int])) {
+	for name, hash := range hashes {
+		t.Run(name, func(t *testing.T) {
+			f(t, hamt.New[int, int](hash))
+		})
+	}
+}
+
+// equal reports whether m holds the same entries as want.
+func equal(m hamt.Map[int, int], want map[int]int) bool {
+	return m.Len() == len(want) && maps.Equal(maps.Collect(m.All()), want)
+}
+
+func TestZero(t *testing.T) {
+	var m hamt.Map[string, int]
+	if m.Len() != 0 || m.Has("key") {
+		t.Errorf("Len(): Expected an empty map, got length %d", m.Len())
+	}
+	if m.Without("key").Len() != 0 {
+		t.Errorf("Without(): Expected an empty map")
+	}
+	n := m.With("key", 1)
+	if v, ok := n.Load("key"); !ok || v != 1 {
+		t.Errorf("Load(): Expected value 1, got %d, %v", v, ok)
+	}
+	if m.Has("key") {
+		t.Errorf("With(): Expected the map to be left untouched")
+	}
+}
+
+func TestWithWithout(t *testing.T) {
+	forEachHash(t, func(t *testing.T, m hamt.Map[int, int]) {
+		for i := 0; i < 100; i++ {
+			m = m.With(i, i)
+		}
+		// keys differing only in the high bits share the positions of every level but the last ones.
+		m = m.With(1<<60, 60).With(1<<61|1<<60, 61)
+		for i := 0; i < 100; i++ {
+			if v, ok := m.Load(i); !ok || v != i {
+				t.Errorf("Load(): Expected value %d for key %d, got %d, %v", i, i, v, ok)
+			}
+		}
+		if m.Len() != 102 || m.Has(100) || !m.Has(1<<61|1<<60) {
+			t.Errorf("Len(): Expected length 102, got %d", m.Len())
+		}
+		m = m.With(5, 0)
+		if v, _ := m.Load(5); v != 0 || m.Len() != 102 {
+			t.Errorf("With(): Expected key 5 to be replaced, got %d and length %d", v, m.Len())
+		}
+		if n := m.Without(100); n.Len() != 102 {
+			t.Errorf("Without(): Expected missing key not to be removed, got length %d", n.Len())
+		}
+		for i := 0; i < 100; i++ {
+			m = m.Without(i)
+			if m.Has(i) {
+				t.Errorf("Has(): Expected key %d to be removed", i)
+			}
+		}
+		m = m.Without(1 << 60).Without(1<<61 | 1<<60)
+		if m.Len() != 0 || len(slices.Collect(m.KeysSeq())) != 0 {
+			t.Errorf("Without(): Expected an empty map, got length %d", m.Len())
+		}
+	})
+}
+
+func TestPersistence(t *testing.T) {
+	forEachHash(t, func(t *testing.T, m hamt.Map[int, int]) {
+		r := rand.New(rand.NewSource(1))
+		var versions []hamt.Map[int, int]
+		var states []map[int]int
+		want := map[int]int{}
+		for i := 0; i < 2000; i++ {
+			key := r.Intn(200)
+			if r.Intn(3) == 0 {
+				m = m.Without(key)
+				delete(want, key)
+			} else {
+				m = m.With(key, i)
+				want[key] = i
+			}
+			if i%100 == 0 {
+				versions = append(versions, m)
+				states = append(states, maps.Clone(want))
+			}
+		}
+		if !equal(m, want) {
+			t.Errorf("Expected the map to hold %v, got %v", want, maps.Collect(m.All()))
+		}
+		for i, v := range versions {
+			if !equal(v, states[i]) {
+				t.Errorf("Expected version %d to be left untouched", i)
+			}
+		}
+	})
+}
+
+func TestRange(t *testing.T) {
+	forEachHash(t, func(t *testing.T, m hamt.Map[int, int]) {
+		for i := 0; i < 100; i++ {
+			m = m.With(i, 1)
+		}
+		var sum int
+		m.Range(func(key, value int) bool {
+			sum += value
+			return true
+		})
+		if sum != 100 {
+			t.Errorf("Range(): Expected sum 100, got %d", sum)
+		}
+		var count int
+		m.Range(func(key, value int) bool {
+			count++
+			return count < 10
+		})
+		if count != 10 {
+			t.Errorf("Range(): Expected iteration to stop after 10 entries, got %d", count)
+		}
+	})
+}
+
+func TestIterators(t *testing.T) {
+	forEachHash(t, func(t *testing.T, m hamt.Map[int, int]) {
+		want := map[int]int{}
+		for i := 0; i < 50; i++ {
+			m = m.With(i, i*2)
+			want[i] = i * 2
+		}
+		if !maps.Equal(maps.Collect(m.All()), want) {
+			t.Errorf("All(): Expected all the entries")
+		}
+		if keys := slices.Sorted(m.KeysSeq()); !slices.Equal(keys, slices.Sorted(maps.Keys(want))) {
+			t.Errorf("KeysSeq(): Expected all the keys, got %v", keys)
+		}
+		if values := slices.Sorted(m.ValuesSeq()); !slices.Equal(values, slices.Sorted(maps.Values(want))) {
+			t.Errorf("ValuesSeq(): Expected all the values, got %v", values)
+		}
+		for range m.All() {
+			break
+		}
+		for range m.KeysSeq() {
+			break
+		}
+		for range m.ValuesSeq() {
+			break
+		}
+	})
+}
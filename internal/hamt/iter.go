@@ -0,0 +1,22 @@
+package hamt
+
+import (
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// All returns an iterator over the key-value pairs in the map.
+func (m Map[K, V]) All() iter.Seq2[K, V] {
+	return derive.All(m.Range)
+}
+
+// KeysSeq returns an iterator over the keys in the map.
+func (m Map[K, V]) KeysSeq() iter.Seq[K] {
+	return derive.Keys(m.Range)
+}
+
+// ValuesSeq returns an iterator over the values in the map.
+func (m Map[K, V]) ValuesSeq() iter.Seq[V] {
+	return derive.Values(m.Range)
+}
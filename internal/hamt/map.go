@@ -0,0 +1,84 @@
+package hamt
+
+import "github.com/thetechpanda/typedmap/internal/hashing"
+
+// Map is an immutable map implemented as a hash array mapped trie (HAMT): With and Without return a new Map
+// sharing with m every node but the ones from the root down to the changed key, so that both are O(log N).
+// Copying a Map is O(1) and the copies never change, they can be shared between goroutines without locking.
+//
+// The zero Map is an empty map ready to use.
+type Map[K comparable, V any] struct {
+	root *node[K, V]
+	n    int
+	// hash hashes the keys, if nil hash/maphash is used.
+	hash func(K) uint64
+}
+
+// New returns an empty Map hashing the keys using hash, if nil hash/maphash is used.
+func New[K comparable, V any](hash func(K) uint64) Map[K, V] {
+	return Map[K, V]{hash: hash}
+}
+
+// hashOf returns the hash of key.
+func (m Map[K, V]) hashOf(key K) uint64 {
+	if m.hash == nil {
+		return hashing.Comparable(seed, key)
+	}
+	return m.hash(key)
+}
+
+// Load returns the value stored in the map for a key.
+// The ok result indicates whether value was found in the map.
+func (m Map[K, V]) Load(key K) (v V, ok bool) {
+	if m.root == nil {
+		return v, false
+	}
+	return m.root.load(m.hashOf(key), key, 0)
+}
+
+// Has returns true if the map contains the key.
+func (m Map[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Len returns the number of items in the map.
+func (m Map[K, V]) Len() int {
+	return m.n
+}
+
+// With returns a copy of the map where the value for key is set to value, m is left untouched.
+func (m Map[K, V]) With(key K, value V) Map[K, V] {
+	root := m.root
+	if root == nil {
+		root = &node[K, V]{}
+	}
+	root, added := root.with(m.hashOf(key), key, value, 0)
+	m.root = root
+	if added {
+		m.n++
+	}
+	return m
+}
+
+// Without returns a copy of the map without key, m is left untouched.
+// If key is missing the result shares every node with m.
+func (m Map[K, V]) Without(key K) Map[K, V] {
+	if m.root == nil {
+		return m
+	}
+	root, removed := m.root.without(m.hashOf(key), key, 0)
+	if removed {
+		m.root = root
+		m.n--
+	}
+	return m
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+func (m Map[K, V]) Range(f func(K, V) bool) {
+	if m.root != nil {
+		m.root.iter(f)
+	}
+}
@@ -0,0 +1,117 @@
+// Package hashing provides the hash function used by the maps to hash keys of any comparable type.
+package hashing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// Comparable returns the hash of key using seed, keys equal as compared by == have the same hash.
+// It behaves as maphash.Comparable, available since Go 1.24: strings, integers and pointers are hashed directly,
+// keys of any other type are hashed walking their value using reflection.
+//
+// As ==, it panics if key is, or contains, an interface value holding a non comparable type.
+func Comparable[K comparable](seed maphash.Seed, key K) uint64 {
+	t := reflect.TypeFor[K]()
+	p := unsafe.Pointer(&key)
+	switch t.Kind() {
+	case reflect.String:
+		return maphash.String(seed, *(*string)(p))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		// the value is read as an unsigned integer of the same size, pointers are hashed by address.
+		switch t.Size() {
+		case 1:
+			return integer(seed, uint64(*(*uint8)(p)))
+		case 2:
+			return integer(seed, uint64(*(*uint16)(p)))
+		case 4:
+			return integer(seed, uint64(*(*uint32)(p)))
+		case 8:
+			return integer(seed, *(*uint64)(p))
+		}
+	}
+	return reflected(seed, key)
+}
+
+// reflected returns the hash of key using seed, walking its value using reflection.
+// It is kept apart from Comparable so that only the keys hashed using reflection escape to the heap.
+func reflected[K comparable](seed maphash.Seed, key K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	write(&h, reflect.ValueOf(&key).Elem())
+	return h.Sum64()
+}
+
+// integer returns the hash of v using seed.
+func integer(seed maphash.Seed, v uint64) uint64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return maphash.Bytes(seed, b[:])
+}
+
+// writeUint64 writes v to h.
+func writeUint64(h *maphash.Hash, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	h.Write(b[:])
+}
+
+// writeFloat writes f to h, +0 and -0 are equal and written the same way.
+func writeFloat(h *maphash.Hash, f float64) {
+	if f == 0 {
+		f = 0
+	}
+	writeUint64(h, math.Float64bits(f))
+}
+
+// write writes to h the parts of v compared by ==.
+func write(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeFloat(h, real(c))
+		writeFloat(h, imag(c))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint64(h, uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		write(h, v.Elem())
+	case reflect.Array:
+		for i := range v.Len() {
+			write(h, v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range v.NumField() {
+			// blank fields are not compared by ==.
+			if t.Field(i).Name != "_" {
+				write(h, v.Field(i))
+			}
+		}
+	default:
+		panic(fmt.Sprintf("typedmap: hash of unhashable type %s", v.Type()))
+	}
+}
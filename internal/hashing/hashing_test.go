@@ -0,0 +1,81 @@
+package hashing_test
+
+import (
+	"hash/maphash"
+	"math"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/hashing"
+)
+
+// point is a struct key, its blank field is not compared by ==.
+type point struct {
+	x, y float64
+	_    int
+	name string
+	next *point
+}
+
+// id is a named integer type, hashed using reflection.
+type id int
+
+// check verifies that a and b, equal as compared by ==, have the same hash.
+func check[K comparable](t *testing.T, seed maphash.Seed, a, b K) {
+	t.Helper()
+	if a != b {
+		t.Fatalf("check(): Expected %v and %v to be equal", a, b)
+	}
+	if ha, hb := hashing.Comparable(seed, a), hashing.Comparable(seed, b); ha != hb {
+		t.Errorf("Comparable(): Expected %v and %v to have the same hash, got %d and %d", a, b, ha, hb)
+	}
+}
+
+func TestComparable(t *testing.T) {
+	seed := maphash.MakeSeed()
+	p := &point{}
+	check(t, seed, "key", "key")
+	check(t, seed, 1, 1)
+	check(t, seed, uint64(math.MaxUint64), uint64(math.MaxUint64))
+	check(t, seed, id(1), id(1))
+	check(t, seed, int8(-1), int8(-1))
+	check(t, seed, true, true)
+	check(t, seed, math.Copysign(0, -1), 0.0)
+	check(t, seed, complex(math.Copysign(0, -1), 1), complex(0, 1))
+	check(t, seed, p, p)
+	check(t, seed, [2]string{"a", "b"}, [2]string{"a", "b"})
+	check(t, seed, point{x: math.Copysign(0, -1), y: 1, name: "a", next: p}, point{y: 1, name: "a", next: p})
+	check[any](t, seed, 1, 1)
+	check[any](t, seed, nil, nil)
+	check[any](t, seed, point{name: "a"}, point{name: "a"})
+
+	// different keys are expected to have different hashes.
+	if hashing.Comparable(seed, "a") == hashing.Comparable(seed, "b") {
+		t.Error("Comparable(): Expected different strings to have different hashes")
+	}
+	if hashing.Comparable(seed, point{name: "a"}) == hashing.Comparable(seed, point{name: "b"}) {
+		t.Error("Comparable(): Expected different structs to have different hashes")
+	}
+	if hashing.Comparable(seed, "a") == hashing.Comparable(maphash.MakeSeed(), "a") {
+		t.Error("Comparable(): Expected different seeds to give different hashes")
+	}
+
+	// as ==, hashing an interface holding a non comparable type panics.
+	defer func() {
+		if recover() == nil {
+			t.Error("Comparable(): Expected a panic hashing a slice")
+		}
+	}()
+	hashing.Comparable[any](seed, []int{1})
+}
+
+func TestComparableAllocs(t *testing.T) {
+	seed := maphash.MakeSeed()
+	key := 1 << 20
+	if n := testing.AllocsPerRun(100, func() { hashing.Comparable(seed, key) }); n != 0 {
+		t.Errorf("Comparable(): Expected no allocation hashing an int, got %v", n)
+	}
+	s := "key"
+	if n := testing.AllocsPerRun(100, func() { hashing.Comparable(seed, s) }); n != 0 {
+		t.Errorf("Comparable(): Expected no allocation hashing a string, got %v", n)
+	}
+}
@@ -0,0 +1,251 @@
+package hashtrie
+
+import (
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/hashing"
+)
+
+const (
+	// nChildrenLog2 is the number of bits of the hash consumed by each level of the trie.
+	nChildrenLog2 = 4
+	nChildren     = 1 << nChildrenLog2
+	nChildrenMask = nChildren - 1
+	// hashBits is the number of bits of the hash.
+	hashBits = 64
+)
+
+// HashTrieMap is a concurrent hash-trie: the keys are placed in the trie following the bits of their hash,
+// each level consuming nChildrenLog2 bits. Lookups never lock, writers lock only the indirect node holding the key.
+//
+// Entries are immutable, writers replace them: readers always observe a key and a value stored together.
+type HashTrieMap[K comparable, V any] struct {
+	root atomic.Pointer[indirect[K, V]]
+	hash func(K) uint64
+	equality.Comparer[V]
+}
+
+// node is a child of an indirect node, either an entry or an indirect node: only one of the fields is set.
+// Entries and indirect nodes embed the node referring to themselves, so that no allocation is needed to refer to them.
+type node[K comparable, V any] struct {
+	entry    *entry[K, V]
+	indirect *indirect[K, V]
+}
+
+// indirect is an inner node of the trie.
+type indirect[K comparable, V any] struct {
+	node node[K, V]
+	// mu serializes the writers of the children.
+	mu sync.Mutex
+	// dead is set once the node has been removed from its parent, its children must no longer be written.
+	dead     atomic.Bool
+	parent   *indirect[K, V]
+	children [nChildren]atomic.Pointer[node[K, V]]
+	// n is the number of keys in the trie, it is only used by the root.
+	n atomic.Int64
+}
+
+// entry holds a key and its value, entries whose keys have the same hash are chained through overflow.
+type entry[K comparable, V any] struct {
+	node     node[K, V]
+	overflow atomic.Pointer[entry[K, V]]
+	key      K
+	value    V
+}
+
+// New returns a new HashTrieMap hashing the keys using hash, if nil hash/maphash is used.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or ==.
+func New[K comparable, V any](hash func(K) uint64, equal equality.Func[V]) *HashTrieMap[K, V] {
+	if hash == nil {
+		seed := maphash.MakeSeed()
+		hash = func(key K) uint64 {
+			return hashing.Comparable(seed, key)
+		}
+	}
+	m := &HashTrieMap[K, V]{
+		hash:     hash,
+		Comparer: equality.NewComparer(equal, equality.Identical[V]),
+	}
+	m.root.Store(newIndirect[K, V](nil))
+	return m
+}
+
+func newIndirect[K comparable, V any](parent *indirect[K, V]) *indirect[K, V] {
+	i := &indirect[K, V]{parent: parent}
+	i.node.indirect = i
+	return i
+}
+
+func newEntry[K comparable, V any](key K, value V) *entry[K, V] {
+	e := &entry[K, V]{key: key, value: value}
+	e.node.entry = e
+	return e
+}
+
+// index returns the position of hash among the children of the indirect node at the level of shift.
+func index(hash uint64, shift uint) uint64 {
+	return (hash >> shift) & nChildrenMask
+}
+
+// empty reports whether i has no children, the caller must hold the lock of i.
+func (i *indirect[K, V]) empty() bool {
+	for j := range i.children {
+		if i.children[j].Load() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// slot is a position in the trie, found by locate: the child of i at the level of shift.
+type slot[K comparable, V any] struct {
+	root  *indirect[K, V]
+	i     *indirect[K, V]
+	shift uint
+	child *atomic.Pointer[node[K, V]]
+}
+
+// locate returns the slot for hash, locked: the slot either is empty or holds an entry.
+// If stop is not nil and returns true for the entries held by the slot, nil if it is empty, locate returns false, leaving the slot unlocked.
+// stop is called before locking, so that the operations with nothing to do never lock, and once the slot is locked.
+//
+// The caller must unlock s.i once done, if ok is true.
+func (m *HashTrieMap[K, V]) locate(hash uint64, stop func(e *entry[K, V]) bool) (s slot[K, V], ok bool) {
+	for {
+		s.root = m.root.Load()
+		s.i = s.root
+		s.shift = hashBits
+		var n *node[K, V]
+		for {
+			s.shift -= nChildrenLog2
+			s.child = &s.i.children[index(hash, s.shift)]
+			n = s.child.Load()
+			if n == nil || n.entry != nil {
+				break
+			}
+			s.i = n.indirect
+		}
+		if stop != nil && stop(entryOf(n)) {
+			return s, false
+		}
+		// locks and checks that the slot has not changed meanwhile.
+		s.i.mu.Lock()
+		n = s.child.Load()
+		if (n == nil || n.entry != nil) && !s.i.dead.Load() {
+			if stop != nil && stop(entryOf(n)) {
+				s.i.mu.Unlock()
+				return s, false
+			}
+			return s, true
+		}
+		s.i.mu.Unlock()
+	}
+}
+
+// entryOf returns the entry of n, or nil if n is nil.
+func entryOf[K comparable, V any](n *node[K, V]) *entry[K, V] {
+	if n == nil {
+		return nil
+	}
+	return n.entry
+}
+
+// head returns the entry held by s, nil if the slot is empty. The caller must hold the lock of s.i.
+func (s *slot[K, V]) head() *entry[K, V] {
+	return entryOf(s.child.Load())
+}
+
+// insert adds e to the slot s, whose entry holds different keys, expanding it into indirect nodes if the hashes differ.
+// The caller must hold the lock of s.i.
+func (m *HashTrieMap[K, V]) insert(s slot[K, V], hash uint64, e *entry[K, V]) {
+	s.root.n.Add(1)
+	old := s.head()
+	if old == nil {
+		s.child.Store(&e.node)
+		return
+	}
+	oldHash := m.hash(old.key)
+	if oldHash == hash {
+		e.overflow.Store(old)
+		s.child.Store(&e.node)
+		return
+	}
+	// the hashes are equal down to the level of s, a new indirect node is needed for each level they keep matching.
+	top := newIndirect(s.i)
+	i := top
+	shift := s.shift
+	for {
+		shift -= nChildrenLog2
+		oi, ni := index(oldHash, shift), index(hash, shift)
+		if oi != ni {
+			i.children[oi].Store(&old.node)
+			i.children[ni].Store(&e.node)
+			break
+		}
+		next := newIndirect(i)
+		i.children[oi].Store(&next.node)
+		i = next
+	}
+	// published last, so that readers never observe old missing from the trie.
+	s.child.Store(&top.node)
+}
+
+// replace sets the head of the entries held by s, removing the indirect nodes left empty.
+// The caller must hold the lock of s.i, if indirect nodes are removed the lock is handed over to their ancestors:
+// replace returns the node whose lock is held, to be unlocked by the caller.
+func (m *HashTrieMap[K, V]) replace(s slot[K, V], hash uint64, head *entry[K, V]) (locked *indirect[K, V]) {
+	if head != nil {
+		s.child.Store(&head.node)
+		return s.i
+	}
+	s.child.Store(nil)
+	i, shift := s.i, s.shift
+	for i.parent != nil && i.empty() {
+		shift += nChildrenLog2
+		parent := i.parent
+		parent.mu.Lock()
+		i.dead.Store(true)
+		parent.children[index(hash, shift)].Store(nil)
+		i.mu.Unlock()
+		i = parent
+	}
+	return i
+}
+
+// lookup returns the value for key in the chain of entries starting at e.
+func (e *entry[K, V]) lookup(key K) (v V, ok bool) {
+	for ; e != nil; e = e.overflow.Load() {
+		if e.key == key {
+			return e.value, true
+		}
+	}
+	return v, false
+}
+
+// without returns the chain starting at head where the entry for key is replaced by e, or removed if e is nil.
+// Entries are never modified, the entries preceding the one for key are copied.
+// The result reports whether the entry was found, along with its value.
+func (head *entry[K, V]) without(key K, e *entry[K, V]) (*entry[K, V], V, bool) {
+	if head == nil {
+		var zero V
+		return nil, zero, false
+	}
+	if head.key == key {
+		rest := head.overflow.Load()
+		if e == nil {
+			return rest, head.value, true
+		}
+		e.overflow.Store(rest)
+		return e, head.value, true
+	}
+	rest, v, ok := head.overflow.Load().without(key, e)
+	if !ok {
+		return head, v, false
+	}
+	copied := newEntry(head.key, head.value)
+	copied.overflow.Store(rest)
+	return copied, v, true
+}
@@ -0,0 +1,509 @@
+package hashtrie_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/hashtrie"
+)
+
+// hashes are the hash functions the tests are run with: the default one, the identity, placing small keys deep in the trie,
+// and one where keys collide, chaining the entries.
+var hashes = map[string]func(int) uint64{
+	"maphash":   nil,
+	"identity":  func(k int) uint64 { return uint64(k) },
+	"collision": func(k int) uint64 { return uint64(k % 4) },
+}
+
+// forEachHash runs f as a subtest for each of the hash functions.
+func forEachHash(t *testing.T, f func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int])) {
+	for name, hash := range hashes {
+		t.Run(name, func(t *testing.T) {
+			f(t, func() *hashtrie.HashTrieMap[int, int] {
+				return hashtrie.New[int, int](hash, nil)
+			})
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	m := hashtrie.New[string, int](nil, nil)
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected a new map, got map with length %d", m.Len())
+	}
+	if _, ok := m.Load("key"); ok {
+		t.Errorf("Load(): Expected an empty map")
+	}
+}
+
+func TestLoadStore(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 100; i++ {
+			m.Store(i, i*2)
+		}
+		for i := 0; i < 100; i++ {
+			if v, ok := m.Load(i); !ok || v != i*2 {
+				t.Errorf("Load(): Expected value %d for key %d, got %d, %v", i*2, i, v, ok)
+			}
+		}
+		if m.Has(100) || !m.Has(99) {
+			t.Errorf("Has(): Expected key 99 to be present and 100 missing")
+		}
+		m.Store(5, 0)
+		if v, _ := m.Load(5); v != 0 || m.Len() != 100 {
+			t.Errorf("Store(): Expected key 5 to be replaced, got %d and length %d", v, m.Len())
+		}
+	})
+}
+
+func TestLoadOrStore(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 20; i++ {
+			if actual, loaded := m.LoadOrStore(i, i); loaded || actual != i {
+				t.Errorf("LoadOrStore(): Expected key %d to be stored with value %d, got %d", i, i, actual)
+			}
+		}
+		for i := 0; i < 20; i++ {
+			if actual, loaded := m.LoadOrStore(i, -1); !loaded || actual != i {
+				t.Errorf("LoadOrStore(): Expected key %d to be loaded with value %d, got %d", i, i, actual)
+			}
+		}
+		if m.Len() != 20 {
+			t.Errorf("Len(): Expected length 20, got %d", m.Len())
+		}
+	})
+}
+
+func TestLoadAndDelete(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 20; i++ {
+			m.Store(i, i)
+		}
+		// deletes in an order leaving both heads and tails of the chains.
+		for _, i := range []int{0, 17, 5, 18, 1, 19, 2, 3} {
+			if v, loaded := m.LoadAndDelete(i); !loaded || v != i {
+				t.Errorf("LoadAndDelete(): Expected key %d to be deleted with value %d, got %d, %v", i, i, v, loaded)
+			}
+			if _, loaded := m.LoadAndDelete(i); loaded {
+				t.Errorf("LoadAndDelete(): Expected key %d to be missing", i)
+			}
+		}
+		m.Delete(4)
+		m.Delete(100)
+		if m.Len() != 11 {
+			t.Errorf("Len(): Expected length 11, got %d", m.Len())
+		}
+		for i := 0; i < 20; i++ {
+			m.Delete(i)
+		}
+		if m.Len() != 0 || len(slices.Collect(m.KeysSeq())) != 0 {
+			t.Errorf("Delete(): Expected an empty map, got length %d", m.Len())
+		}
+		// the trie is still usable once emptied.
+		m.Store(7, 7)
+		if v, ok := m.Load(7); !ok || v != 7 {
+			t.Errorf("Load(): Expected value 7, got %d, %v", v, ok)
+		}
+	})
+}
+
+func TestSwap(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 10; i++ {
+			if previous, loaded := m.Swap(i, i); loaded || previous != 0 {
+				t.Errorf("Swap(): Key %d was present in an empty map", i)
+			}
+		}
+		for i := 0; i < 10; i++ {
+			if previous, loaded := m.Swap(i, i+1); !loaded || previous != i {
+				t.Errorf("Swap(): Expected previous value %d, got %d", i, previous)
+			}
+		}
+		if m.Len() != 10 {
+			t.Errorf("Len(): Expected length 10, got %d", m.Len())
+		}
+	})
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 10; i++ {
+			m.Store(i, i)
+		}
+		for i := 0; i < 10; i++ {
+			if m.CompareAndSwap(i, i+1, 0) {
+				t.Errorf("CompareAndSwap(): Expected key %d not to be swapped", i)
+			}
+			if !m.CompareAndSwap(i, i, i*10) {
+				t.Errorf("CompareAndSwap(): Expected key %d to be swapped", i)
+			}
+			if v, _ := m.Load(i); v != i*10 {
+				t.Errorf("Load(): Expected value %d for key %d, got value %d", i*10, i, v)
+			}
+		}
+		if m.CompareAndSwap(100, 0, 1) {
+			t.Errorf("CompareAndSwap(): Expected missing key not to be swapped")
+		}
+	})
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 10; i++ {
+			m.Store(i, i)
+		}
+		for i := 9; i >= 0; i-- {
+			if m.CompareAndDelete(i, i+1) {
+				t.Errorf("CompareAndDelete(): Expected key %d not to be deleted", i)
+			}
+			if !m.CompareAndDelete(i, i) {
+				t.Errorf("CompareAndDelete(): Expected key %d to be deleted", i)
+			}
+			if m.Has(i) {
+				t.Errorf("Has(): Expected key %d to be deleted", i)
+			}
+		}
+		if m.CompareAndDelete(100, 0) || m.Len() != 0 {
+			t.Errorf("CompareAndDelete(): Expected an empty map, got length %d", m.Len())
+		}
+	})
+}
+
+func TestNotComparableType(t *testing.T) {
+	m := hashtrie.New[int, []int](nil, nil)
+	m.Store(1, []int{1, 2, 3})
+	if m.CompareAndDelete(1, []int{1, 2, 3}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if m.CompareAndSwap(1, []int{1, 2, 3}, []int{1, 2, 3, 4}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := m.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := m.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := hashtrie.New[int, []int](nil, slices.Equal[[]int])
+	e.Store(1, []int{1})
+	if swapped, err := e.TryCompareAndSwap(1, []int{1}, []int{2}); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := e.TryCompareAndDelete(1, []int{2}); !deleted || err != nil || e.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+}
+
+func TestComparableType(t *testing.T) {
+	type value struct {
+		p *int
+	}
+	m := hashtrie.New[int, value](nil, nil)
+	a, b := 1, 1
+	m.Store(0, value{&a})
+	// values are compared using ==, as sync.Map does: pointers are compared by identity.
+	if m.CompareAndSwap(0, value{&b}, value{}) {
+		t.Errorf("CompareAndSwap(): Expected different pointers not to be equal")
+	}
+	if !m.CompareAndSwap(0, value{&a}, value{&b}) {
+		t.Errorf("CompareAndSwap(): Expected same pointers to be equal")
+	}
+}
+
+func TestRange(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 100; i++ {
+			m.Store(i, 1)
+		}
+		var sum int
+		m.Range(func(key, value int) bool {
+			sum += value
+			return true
+		})
+		if sum != 100 {
+			t.Errorf("Range(): Expected sum 100, got %d", sum)
+		}
+		sum = 0
+		m.Range(func(key, value int) bool {
+			sum++
+			return false
+		})
+		if sum != 1 {
+			t.Errorf("Range(): Expected sum 1, got %d", sum)
+		}
+		// f can write to the map.
+		m.Range(func(key, value int) bool {
+			m.Delete(key)
+			return true
+		})
+		if m.Len() != 0 {
+			t.Errorf("Range(): Expected every key to be deleted, got length %d", m.Len())
+		}
+	})
+}
+
+func TestIterators(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 100; i++ {
+			m.Store(i, i*2)
+		}
+
+		clone := maps.Collect(m.All())
+		if len(clone) != 100 {
+			t.Errorf("All(): Expected 100 entries, got %d", len(clone))
+		}
+		for k, v := range clone {
+			if v != k*2 {
+				t.Errorf("All(): Expected value %d for key %d, got %d", k*2, k, v)
+			}
+		}
+
+		keys := slices.Sorted(m.KeysSeq())
+		if len(keys) != 100 || keys[0] != 0 || keys[99] != 99 {
+			t.Errorf("KeysSeq(): Expected sorted keys from 0 to 99, got %v", keys)
+		}
+
+		values := slices.Sorted(m.ValuesSeq())
+		if len(values) != 100 || values[0] != 0 || values[99] != 198 {
+			t.Errorf("ValuesSeq(): Expected sorted values from 0 to 198, got %v", values)
+		}
+
+		count := 0
+		for range m.All() {
+			count++
+			break
+		}
+		for range m.KeysSeq() {
+			count++
+			break
+		}
+		for range m.ValuesSeq() {
+			count++
+			break
+		}
+		if count != 3 {
+			t.Errorf("Expected iterators to stop after break, got %d iterations", count)
+		}
+	})
+}
+
+func TestClear(t *testing.T) {
+	m := hashtrie.New[int, int](nil, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	m.Clear()
+	if m.Len() != 0 || m.Has(1) {
+		t.Errorf("Clear(): Expected an empty map, got length %d", m.Len())
+	}
+	m.Store(1, 1)
+	if m.Len() != 1 {
+		t.Errorf("Len(): Expected length 1, got %d", m.Len())
+	}
+}
+
+func TestConcurrentAccessSet(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		numGoroutines := 100
+		var wg sync.WaitGroup
+		wg.Add(numGoroutines)
+		ctx, cancel := context.WithCancel(context.Background())
+		for i := 0; i < numGoroutines; i++ {
+			go func(i int) {
+				defer wg.Done()
+				// uses context done to have all goroutines start at the same time
+				<-ctx.Done()
+				for j := 0; j < numGoroutines; j++ {
+					if _, ok := m.Load(j); !ok {
+						m.Store(j, i*i)
+					}
+					m.Delete(j)
+				}
+			}(i)
+		}
+		cancel()
+		wg.Wait()
+		if m.Len() != 0 {
+			t.Errorf("Len(): Expected length 0, got %d", m.Len())
+		}
+	})
+}
+
+func TestConcurrentLoadOrStore(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		numGoroutines := 100
+		var stored [100]int
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		wg.Add(numGoroutines)
+		ctx, cancel := context.WithCancel(context.Background())
+		for i := 0; i < numGoroutines; i++ {
+			go func(i int) {
+				defer wg.Done()
+				<-ctx.Done()
+				for j := 0; j < 100; j++ {
+					if actual, loaded := m.LoadOrStore(j, i); !loaded {
+						mu.Lock()
+						stored[j]++
+						mu.Unlock()
+					} else if v, _ := m.Load(j); v != actual {
+						t.Errorf("LoadOrStore(): Expected the loaded value %d to be stored, got %d", actual, v)
+					}
+				}
+			}(i)
+		}
+		cancel()
+		wg.Wait()
+		for j, n := range stored {
+			if n != 1 {
+				t.Errorf("LoadOrStore(): Expected key %d to be stored once, got %d", j, n)
+			}
+		}
+		if m.Len() != 100 {
+			t.Errorf("Len(): Expected length 100, got %d", m.Len())
+		}
+	})
+}
+
+func TestConcurrentCompareAndSwap(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		for i := 0; i < 8; i++ {
+			m.Store(i, 0)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					key := j % 8
+					for {
+						v, _ := m.Load(key)
+						if m.CompareAndSwap(key, v, v+1) {
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		for i := 0; i < 8; i++ {
+			if v, _ := m.Load(i); v != 1000 {
+				t.Errorf("CompareAndSwap(): Expected 1000 increments of key %d, got %d", i, v)
+			}
+		}
+	})
+}
+
+func TestConcurrentAccessRange(t *testing.T) {
+	forEachHash(t, func(t *testing.T, newMap func() *hashtrie.HashTrieMap[int, int]) {
+		m := newMap()
+		numGoroutines := 50
+		// the even keys are never written, the odd keys are deleted and stored again while iterating.
+		for i := 0; i < 2*numGoroutines; i++ {
+			m.Store(i, i)
+		}
+		var wg sync.WaitGroup
+		wg.Add(2 * numGoroutines)
+		ctx, cancel := context.WithCancel(context.Background())
+		for i := 0; i < numGoroutines; i++ {
+			go func(i int) {
+				defer wg.Done()
+				<-ctx.Done()
+				for j := 0; j < 10; j++ {
+					m.Delete(2*i + 1)
+					m.Store(2*i+1, 2*i+1)
+				}
+			}(i)
+			go func() {
+				defer wg.Done()
+				<-ctx.Done()
+				for j := 0; j < 10; j++ {
+					seen := make(map[int]bool)
+					m.Range(func(k, v int) bool {
+						if seen[k] || k != v {
+							t.Errorf("Range(): Expected key %d to be visited once with its value, got %d", k, v)
+						}
+						seen[k] = true
+						return true
+					})
+					for k := 0; k < 2*numGoroutines; k += 2 {
+						if !seen[k] {
+							t.Errorf("Range(): Expected key %d to be visited", k)
+						}
+					}
+				}
+			}()
+		}
+		cancel()
+		wg.Wait()
+		if m.Len() != 2*numGoroutines {
+			t.Errorf("Len(): Expected length %d, got %d", 2*numGoroutines, m.Len())
+		}
+	})
+}
+
+func TestConcurrentClear(t *testing.T) {
+	m := hashtrie.New[int, int](nil, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Store(i*1000+j, j)
+				if j%100 == 0 {
+					m.Clear()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := len(slices.Collect(m.KeysSeq())); n != m.Len() {
+		t.Errorf("Len(): Expected length %d, got %d", n, m.Len())
+	}
+}
+
+func TestChangedBeforeLocking(t *testing.T) {
+	// hook runs within the equality function, called once before locking the slot and once after.
+	var hook func()
+	m := hashtrie.New[int, int](func(k int) uint64 { return uint64(k) }, func(a, b int) bool {
+		if h := hook; h != nil {
+			hook = nil
+			h()
+		}
+		return a == b
+	})
+
+	// the value changes before the slot is locked.
+	m.Store(0, 0)
+	hook = func() { m.Store(0, 1) }
+	if m.CompareAndSwap(0, 0, 2) {
+		t.Errorf("CompareAndSwap(): Expected the value changed concurrently not to be swapped")
+	}
+	// the entry is moved to a new indirect node before the slot is locked: the search starts over.
+	hook = func() { m.Store(1, 1) }
+	if !m.CompareAndSwap(0, 1, 2) {
+		t.Errorf("CompareAndSwap(): Expected the value to be swapped once found again")
+	}
+	if v, _ := m.Load(0); v != 2 || !m.Has(1) {
+		t.Errorf("Load(): Expected value 2, got %d", v)
+	}
+}
@@ -0,0 +1,176 @@
+package hashtrie
+
+import "github.com/thetechpanda/typedmap/internal/derive"
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.
+// The ok result indicates whether value was found in the map.
+// Load does not lock the map.
+func (m *HashTrieMap[K, V]) Load(key K) (v V, ok bool) {
+	hash := m.hash(key)
+	i := m.root.Load()
+	for shift := uint(hashBits); ; {
+		shift -= nChildrenLog2
+		n := i.children[index(hash, shift)].Load()
+		if n == nil {
+			return v, false
+		}
+		if n.entry != nil {
+			return n.entry.lookup(key)
+		}
+		i = n.indirect
+	}
+}
+
+// Store sets the value for a key.
+func (m *HashTrieMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+// If the key is present LoadOrStore does not lock the map.
+func (m *HashTrieMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	hash := m.hash(key)
+	s, ok := m.locate(hash, func(e *entry[K, V]) bool {
+		actual, loaded = e.lookup(key)
+		return loaded
+	})
+	if !ok {
+		return actual, true
+	}
+	defer s.i.mu.Unlock()
+	m.insert(s, hash, newEntry(key, value))
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *HashTrieMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	return m.delete(key, nil)
+}
+
+// Delete removes the key from the map.
+func (m *HashTrieMap[K, V]) Delete(key K) {
+	m.delete(key, nil)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *HashTrieMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	hash := m.hash(key)
+	s, _ := m.locate(hash, nil)
+	defer s.i.mu.Unlock()
+	e := newEntry(key, value)
+	head, previous, loaded := s.head().without(key, e)
+	if loaded {
+		m.replace(s, hash, head)
+		return previous, true
+	}
+	m.insert(s, hash, e)
+	return previous, false
+}
+
+// CompareAndSwap swaps the old and new values for key
+// if the value stored in the map is equal to old.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+//
+// Returns true if the swap was performed.
+func (m *HashTrieMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	hash := m.hash(key)
+	s, ok := m.locate(hash, func(e *entry[K, V]) bool {
+		v, ok := e.lookup(key)
+		return !ok || !m.ValuesEqual(v, old)
+	})
+	if !ok {
+		return false
+	}
+	defer s.i.mu.Unlock()
+	head, _, _ := s.head().without(key, newEntry(key, new))
+	m.replace(s, hash, head)
+	return true
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
+//
+// If there is no current value for key in the map, CompareAndDelete
+// returns false (even if the old value is the nil interface value).
+func (m *HashTrieMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	_, deleted = m.delete(key, func(v V) bool {
+		return m.ValuesEqual(v, old)
+	})
+	return deleted
+}
+
+// delete removes the entry for key if its value satisfies match, or if match is nil.
+// If the key is missing delete does not lock the map.
+func (m *HashTrieMap[K, V]) delete(key K, match func(V) bool) (value V, deleted bool) {
+	hash := m.hash(key)
+	s, ok := m.locate(hash, func(e *entry[K, V]) bool {
+		v, ok := e.lookup(key)
+		return !ok || (match != nil && !match(v))
+	})
+	if !ok {
+		return value, false
+	}
+	locked := s.i
+	defer func() {
+		locked.mu.Unlock()
+	}()
+	head, value, _ := s.head().without(key, nil)
+	s.root.n.Add(-1)
+	locked = m.replace(s, hash, head)
+	return value, true
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *HashTrieMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *HashTrieMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+//
+// Range does not lock the map and does not correspond to any consistent snapshot of its content:
+// no key is visited more than once, but keys stored or deleted concurrently, including by f, may or may not be visited.
+// f can invoke any map function.
+func (m *HashTrieMap[K, V]) Range(f func(K, V) bool) {
+	m.root.Load().iter(f)
+}
+
+// iter calls f for each entry below i, it returns false if f stopped the iteration.
+func (i *indirect[K, V]) iter(f func(K, V) bool) bool {
+	for j := range i.children {
+		n := i.children[j].Load()
+		if n == nil {
+			continue
+		}
+		if n.indirect != nil {
+			if !n.indirect.iter(f) {
+				return false
+			}
+			continue
+		}
+		for e := n.entry; e != nil; e = e.overflow.Load() {
+			if !f(e.key, e.value) {
+				return false
+			}
+		}
+	}
+	return true
+}
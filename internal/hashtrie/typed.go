@@ -0,0 +1,20 @@
+package hashtrie
+
+// Has returns true if the map contains the key.
+// Has does not lock the map.
+func (m *HashTrieMap[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Len returns the number of items in the map.
+// Len is an O(1) operation, the number of keys is tracked by an atomic counter updated by every operation adding or removing a key.
+func (m *HashTrieMap[K, V]) Len() int {
+	return int(m.root.Load().n.Load())
+}
+
+// Clear removes all items from the map, replacing the trie with an empty one.
+// Operations running concurrently with Clear may apply to either trie.
+func (m *HashTrieMap[K, V]) Clear() {
+	m.root.Store(newIndirect[K, V](nil))
+}
@@ -0,0 +1,44 @@
+package indexed
+
+import (
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// Compute calls f with the current value for key, if any, and stores or deletes the key according to the returned Op.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+// If the value to store would violate a unique index, Compute panics, see Store.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Compute(key K, f func(old V, loaded bool) (V, compute.Op)) (value V, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, loaded := m.data[key]
+	value, op := f(old, loaded)
+	switch op {
+	case compute.Store:
+		must(m.set(key, value))
+		return value, true
+	case compute.Delete:
+		m.remove(key)
+		var zero V
+		return zero, false
+	}
+	return old, loaded
+}
+
+// ComputeIfAbsent calls f only if key is not present, and stores the returned value if the Op is Store.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) ComputeIfAbsent(key K, f func() (V, compute.Op)) (value V, ok bool) {
+	return derive.ComputeIfAbsent(m.Load, m.Compute, key, f)
+}
+
+// ComputeIfPresent calls f only if key is present, and stores or deletes the key according to the returned Op.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) ComputeIfPresent(key K, f func(old V) (V, compute.Op)) (value V, ok bool) {
+	return derive.ComputeIfPresent(m.Compute, key, f)
+}
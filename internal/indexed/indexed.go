@@ -0,0 +1,299 @@
+package indexed
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// ErrDuplicate is returned by the writes that would store a value whose key in a unique index belongs to another entry.
+var ErrDuplicate = errors.New("typedmap: duplicate key in unique index")
+
+// change is a write applied to a TypedMap, deleted is true for a removal.
+type change[K comparable, V any] struct {
+	key     K
+	value   V
+	deleted bool
+}
+
+// index is implemented by the indexes of a TypedMap, the caller must hold the lock of the map.
+type index[K comparable, V any] interface {
+	// stage computes the keys in the index of the values of changes without changing it, and returns the function
+	// applying the changes to the index, or an error if applying them would violate the index.
+	stage(changes []change[K, V]) (apply func(), err error)
+	// remove removes key from the index.
+	remove(key K)
+	// clear empties the index.
+	clear()
+	// entriesOf returns the keys of the map whose values have key in the index, it panics if key is not of the type of the keys of the index.
+	entriesOf(key any) map[K]struct{}
+}
+
+// TypedMap implements a thread-safe map maintaining secondary indexes on its values:
+// every write updates the indexes under the same lock, so that the indexes always match the content of the map.
+type TypedMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	equality.Comparer[V]
+	txn.ID
+	data    map[K]V
+	indexes []index[K, V]
+	// named holds the indexes by name, for LookupBy and RangeBy.
+	named map[string]index[K, V]
+}
+
+// New returns a new TypedMap without indexes, see NewIndex.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](equal equality.Func[V]) *TypedMap[K, V] {
+	return &TypedMap[K, V]{
+		Comparer: equality.NewComparer(equal, equality.Deep[V]),
+		ID:       txn.NewID(),
+		data:     make(map[K]V),
+		named:    make(map[string]index[K, V]),
+	}
+}
+
+// apply applies changes to the map and to the indexes, unless they would violate a unique index,
+// in which case it returns an error wrapping ErrDuplicate and the map is left untouched.
+// The changes are checked together, so that for example two entries can exchange their keys in a unique index.
+// The keys of the values in every index are computed before any change, so that an index function panicking leaves the map untouched.
+// The keys of changes must be distinct, the caller must hold the lock.
+func (m *TypedMap[K, V]) apply(changes ...change[K, V]) error {
+	apply := make([]func(), len(m.indexes))
+	for i, idx := range m.indexes {
+		var err error
+		if apply[i], err = idx.stage(changes); err != nil {
+			return err
+		}
+	}
+	for _, c := range changes {
+		if c.deleted {
+			delete(m.data, c.key)
+		} else {
+			m.data[c.key] = c.value
+		}
+	}
+	for _, f := range apply {
+		f()
+	}
+	return nil
+}
+
+// set stores value for key and updates the indexes, see apply. The caller must hold the lock.
+func (m *TypedMap[K, V]) set(key K, value V) error {
+	return m.apply(change[K, V]{key: key, value: value})
+}
+
+// remove deletes key and its value from the map and from the indexes, the caller must hold the lock.
+func (m *TypedMap[K, V]) remove(key K) (value V, ok bool) {
+	if value, ok = m.data[key]; ok {
+		delete(m.data, key)
+		for _, idx := range m.indexes {
+			idx.remove(key)
+		}
+	}
+	return value, ok
+}
+
+// index returns the index named name, it panics if there is none. The caller must hold the lock.
+func (m *TypedMap[K, V]) index(name string) index[K, V] {
+	idx, ok := m.named[name]
+	if !ok {
+		panic(fmt.Sprintf("typedmap: no index named %q", name))
+	}
+	return idx
+}
+
+// LookupBy returns the entry whose value has key in the index named name, as Index.Lookup does.
+// It panics if the map has no index named name, or if key is not of the type of the keys of the index.
+func (m *TypedMap[K, V]) LookupBy(name string, key any) (k K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for k := range m.index(name).entriesOf(key) {
+		return k, m.data[k], true
+	}
+	return k, value, false
+}
+
+// RangeBy returns an iterator over the entries whose value has key in the index named name, as Index.Range does.
+// The iterator panics as LookupBy does.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V]) RangeBy(name string, key any) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		for k := range m.index(name).entriesOf(key) {
+			if !yield(k, m.data[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Index is a secondary index of a TypedMap: each value is indexed under the keys returned by the index function.
+type Index[K comparable, V any, IK comparable] struct {
+	m *TypedMap[K, V]
+	// keys calls yield for each key of value in the index.
+	keys   func(value V, yield func(IK))
+	unique bool
+	// entries holds, for each key of the index, the keys of the map whose values have it.
+	entries map[IK]map[K]struct{}
+	// indexed holds the keys of the index under which each key of the map is indexed,
+	// as the value stored for it may have been changed in place since, e.g. through a pointer.
+	indexed map[K][]IK
+}
+
+// NewIndex adds to m an index named name, keys calls yield for each key of a value in the index.
+// If unique, a key of the index can belong to a single entry and NewIndex returns an error wrapping ErrDuplicate
+// if the entries already in m violate the index, in which case the index is not added.
+// NewIndex panics if m already has an index named name.
+func NewIndex[K comparable, V any, IK comparable](m *TypedMap[K, V], name string, keys func(value V, yield func(IK)), unique bool) (*Index[K, V, IK], error) {
+	idx := &Index[K, V, IK]{m: m, keys: keys, unique: unique, entries: make(map[IK]map[K]struct{}), indexed: make(map[K][]IK)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.named[name]; ok {
+		panic(fmt.Sprintf("typedmap: index %q already added", name))
+	}
+	changes := make([]change[K, V], 0, len(m.data))
+	for key, value := range m.data {
+		changes = append(changes, change[K, V]{key: key, value: value})
+	}
+	apply, err := idx.stage(changes)
+	if err != nil {
+		return nil, err
+	}
+	apply()
+	m.indexes = append(m.indexes, idx)
+	m.named[name] = idx
+	return idx, nil
+}
+
+func (idx *Index[K, V, IK]) stage(changes []change[K, V]) (apply func(), err error) {
+	keys := make([][]IK, len(changes))
+	for i, c := range changes {
+		if !c.deleted {
+			idx.keys(c.value, func(ik IK) {
+				keys[i] = append(keys[i], ik)
+			})
+		}
+	}
+	if idx.unique {
+		if err := idx.check(changes, keys); err != nil {
+			return nil, err
+		}
+	}
+	return func() {
+		// the previous keys of every entry are removed first, as they may have been given to another entry.
+		for _, c := range changes {
+			idx.remove(c.key)
+		}
+		for i, c := range changes {
+			for _, ik := range keys[i] {
+				entries, ok := idx.entries[ik]
+				if !ok {
+					entries = make(map[K]struct{}, 1)
+					idx.entries[ik] = entries
+				}
+				entries[c.key] = struct{}{}
+			}
+			if len(keys[i]) > 0 {
+				idx.indexed[c.key] = keys[i]
+			}
+		}
+	}, nil
+}
+
+// check returns an error wrapping ErrDuplicate if, once changes are applied, a key of the index would belong to several entries.
+// keys holds the keys in the index of the value of each change.
+func (idx *Index[K, V, IK]) check(changes []change[K, V], keys [][]IK) error {
+	// changed holds the keys of the map being changed, they give up their current keys in the index,
+	// owners holds the entries the changes give the keys of the index to. Both are only needed for several changes.
+	var changed map[K]struct{}
+	var owners map[IK]K
+	if len(changes) > 1 {
+		changed = make(map[K]struct{}, len(changes))
+		owners = make(map[IK]K)
+		for _, c := range changes {
+			changed[c.key] = struct{}{}
+		}
+	}
+	for i, c := range changes {
+		for _, ik := range keys[i] {
+			if owners != nil {
+				if owner, ok := owners[ik]; ok && owner != c.key {
+					return fmt.Errorf("%w: key %v belongs to %v", ErrDuplicate, ik, owner)
+				}
+				owners[ik] = c.key
+			}
+			for owner := range idx.entries[ik] {
+				if _, ok := changed[owner]; !ok && owner != c.key {
+					return fmt.Errorf("%w: key %v belongs to %v", ErrDuplicate, ik, owner)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (idx *Index[K, V, IK]) remove(key K) {
+	for _, ik := range idx.indexed[key] {
+		entries := idx.entries[ik]
+		delete(entries, key)
+		if len(entries) == 0 {
+			delete(idx.entries, ik)
+		}
+	}
+	delete(idx.indexed, key)
+}
+
+func (idx *Index[K, V, IK]) clear() {
+	clear(idx.entries)
+	clear(idx.indexed)
+}
+
+func (idx *Index[K, V, IK]) entriesOf(key any) map[K]struct{} {
+	ik, ok := key.(IK)
+	// a nil key is the zero value of an interface type.
+	if !ok && (key != nil || reflect.TypeFor[IK]().Kind() != reflect.Interface) {
+		panic(fmt.Sprintf("typedmap: key of type %T used with an index of keys of type %s", key, reflect.TypeFor[IK]()))
+	}
+	return idx.entries[ik]
+}
+
+// Lookup returns the entry whose value has key in the index, the ok result reports whether there is one.
+// If the index is not unique and several entries have key, any of them is returned, see Range.
+func (idx *Index[K, V, IK]) Lookup(key IK) (k K, value V, ok bool) {
+	idx.m.mu.RLock()
+	defer idx.m.mu.RUnlock()
+	for k := range idx.entries[key] {
+		return k, idx.m.data[k], true
+	}
+	return k, value, false
+}
+
+// Range returns an iterator over the entries whose value has key in the index, in no particular order.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (idx *Index[K, V, IK]) Range(key IK) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		idx.m.mu.RLock()
+		defer idx.m.mu.RUnlock()
+		for k := range idx.entries[key] {
+			if !yield(k, idx.m.data[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Count returns the number of entries whose value has key in the index.
+func (idx *Index[K, V, IK]) Count(key IK) int {
+	idx.m.mu.RLock()
+	defer idx.m.mu.RUnlock()
+	return len(idx.entries[key])
+}
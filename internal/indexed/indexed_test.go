@@ -0,0 +1,489 @@
+package indexed_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/indexed"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+type user struct {
+	email  string
+	tenant int
+}
+
+// users is a map of users indexed by email, unique, and by tenant.
+type users struct {
+	*indexed.TypedMap[int, user]
+	byEmail  *indexed.Index[int, user, string]
+	byTenant *indexed.Index[int, user, int]
+}
+
+// single returns the keys function of an index with a single key per value.
+func single[V, IK any](f func(V) IK) func(V, func(IK)) {
+	return func(value V, yield func(IK)) {
+		yield(f(value))
+	}
+}
+
+func newUsers() users {
+	m := indexed.New[int, user](nil)
+	byEmail, _ := indexed.NewIndex(m, "email", single(func(u user) string { return u.email }), true)
+	byTenant, _ := indexed.NewIndex(m, "tenant", single(func(u user) int { return u.tenant }), false)
+	return users{m, byEmail, byTenant}
+}
+
+// keysBy returns the sorted keys of the entries having key in idx.
+func keysBy[IK comparable](idx *indexed.Index[int, user, IK], key IK) []int {
+	return slices.Sorted(maps.Keys(maps.Collect(idx.Range(key))))
+}
+
+func TestNew(t *testing.T) {
+	m := newUsers()
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected a new map, got map with length %d", m.Len())
+	}
+	if _, _, ok := m.byEmail.Lookup("a"); ok {
+		t.Errorf("Lookup(): Expected an empty map")
+	}
+	if n := m.byTenant.Count(1); n != 0 {
+		t.Errorf("Count(): Expected an empty map, got %d", n)
+	}
+}
+
+func TestNewIndex(t *testing.T) {
+	m := indexed.New[int, user](nil)
+	m.Store(1, user{"a", 1})
+	m.Store(2, user{"a", 1})
+	// the entries already in the map are indexed.
+	byTenant, err := indexed.NewIndex(m, "tenant", single(func(u user) int { return u.tenant }), false)
+	if err != nil || byTenant.Count(1) != 2 {
+		t.Errorf("NewIndex(): Expected the entries to be indexed, got %v", err)
+	}
+	byEmail, err := indexed.NewIndex(m, "email", single(func(u user) string { return u.email }), true)
+	if !errors.Is(err, indexed.ErrDuplicate) || byEmail != nil {
+		t.Errorf("NewIndex(): Expected ErrDuplicate, got %v", err)
+	}
+	// the rejected index is not maintained.
+	if err := m.TryStore(3, user{"a", 2}); err != nil {
+		t.Errorf("TryStore(): Unexpected error %v", err)
+	}
+}
+
+func TestMultiIndex(t *testing.T) {
+	type article struct {
+		tags []string
+	}
+	m := indexed.New[string, article](nil)
+	byTag, _ := indexed.NewIndex(m, "tag", func(a article, yield func(string)) {
+		for _, tag := range a.tags {
+			yield(tag)
+		}
+	}, false)
+	m.Store("a", article{[]string{"go", "maps"}})
+	m.Store("b", article{[]string{"go", "go"}})
+	if n := byTag.Count("go"); n != 2 {
+		t.Errorf("Count(): Expected 2 articles tagged go, got %d", n)
+	}
+	m.Store("a", article{[]string{"sets"}})
+	if k, _, ok := byTag.Lookup("sets"); !ok || k != "a" || byTag.Count("maps") != 0 || byTag.Count("go") != 1 {
+		t.Errorf("Lookup(): Expected the tags of a to be replaced, got %v %v", k, ok)
+	}
+	m.Delete("b")
+	if n := byTag.Count("go"); n != 0 {
+		t.Errorf("Count(): Expected no articles tagged go, got %d", n)
+	}
+
+	// a unique multi-valued index rejects a value sharing any of its keys with another entry.
+	aliases := indexed.New[int, []string](nil)
+	byAlias, _ := indexed.NewIndex(aliases, "alias", func(names []string, yield func(string)) {
+		for _, name := range names {
+			yield(name)
+		}
+	}, true)
+	aliases.Store(1, []string{"a", "b", "b"})
+	if err := aliases.TryStore(2, []string{"c", "b", "a"}); !errors.Is(err, indexed.ErrDuplicate) {
+		t.Errorf("TryStore(): Expected ErrDuplicate, got %v", err)
+	}
+	if k, _, _ := byAlias.Lookup("b"); k != 1 || byAlias.Count("c") != 0 {
+		t.Errorf("Lookup(): Expected b to belong to 1, got %d", k)
+	}
+}
+
+func TestMapOperations(t *testing.T) {
+	m := newUsers()
+
+	if err := m.TryStore(1, user{"a", 1}); err != nil {
+		t.Errorf("TryStore(): Unexpected error %v", err)
+	}
+	if v, ok := m.Load(1); !ok || v != (user{"a", 1}) {
+		t.Errorf("Load(): Expected {a 1}, got %v", v)
+	}
+	if !m.Has(1) || m.Has(2) {
+		t.Errorf("Has(): Expected key 1 only")
+	}
+
+	actual, loaded, err := m.TryLoadOrStore(1, user{"b", 1})
+	if err != nil || !loaded || actual != (user{"a", 1}) {
+		t.Errorf("TryLoadOrStore(): Expected {a 1} to be loaded, got %v %v %v", actual, loaded, err)
+	}
+	actual, loaded, err = m.TryLoadOrStore(2, user{"b", 1})
+	if err != nil || loaded || actual != (user{"b", 1}) {
+		t.Errorf("TryLoadOrStore(): Expected {b 1} to be stored, got %v %v %v", actual, loaded, err)
+	}
+
+	previous, loaded, err := m.TrySwap(2, user{"c", 2})
+	if err != nil || !loaded || previous != (user{"b", 1}) {
+		t.Errorf("TrySwap(): Expected {b 1} to be swapped, got %v %v %v", previous, loaded, err)
+	}
+	previous, loaded, err = m.TrySwap(3, user{"d", 2})
+	if err != nil || loaded || previous != (user{}) {
+		t.Errorf("TrySwap(): Expected a new key, got %v %v %v", previous, loaded, err)
+	}
+
+	if err := m.TryUpdate(3, func(u user, ok bool) user {
+		u.tenant = 1
+		return u
+	}); err != nil {
+		t.Errorf("TryUpdate(): Unexpected error %v", err)
+	}
+
+	if keys := keysBy(m.byTenant, 1); !slices.Equal(keys, []int{1, 3}) {
+		t.Errorf("Range(): Expected keys [1 3], got %v", keys)
+	}
+	if keys := keysBy(m.byTenant, 2); !slices.Equal(keys, []int{2}) {
+		t.Errorf("Range(): Expected keys [2], got %v", keys)
+	}
+	if keys := keysBy(m.byEmail, "b"); len(keys) != 0 {
+		t.Errorf("Range(): Expected the swapped value to be removed from the index, got %v", keys)
+	}
+	if k, v, ok := m.byEmail.Lookup("c"); !ok || k != 2 || v != (user{"c", 2}) {
+		t.Errorf("Lookup(): Expected 2 {c 2}, got %v %v %v", k, v, ok)
+	}
+	for range m.byTenant.Range(1) {
+		break
+	}
+
+	if v, loaded := m.LoadAndDelete(3); !loaded || v != (user{"d", 1}) {
+		t.Errorf("LoadAndDelete(): Expected {d 1}, got %v", v)
+	}
+	if _, loaded := m.LoadAndDelete(3); loaded {
+		t.Errorf("LoadAndDelete(): Expected a missing key")
+	}
+	m.Delete(2)
+	if _, _, ok := m.byEmail.Lookup("c"); ok {
+		t.Errorf("Lookup(): Expected the deleted value to be removed from the index")
+	}
+	if keys := keysBy(m.byTenant, 1); !slices.Equal(keys, []int{1}) {
+		t.Errorf("Range(): Expected keys [1], got %v", keys)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len(): Expected 1, got %d", m.Len())
+	}
+
+	m.Clear()
+	if m.Len() != 0 {
+		t.Errorf("Clear(): Expected an empty map, got %d entries", m.Len())
+	}
+	if keys := keysBy(m.byTenant, 1); len(keys) != 0 {
+		t.Errorf("Clear(): Expected empty indexes, got %v", keys)
+	}
+}
+
+func TestUnique(t *testing.T) {
+	m := newUsers()
+	m.Store(1, user{"a", 1})
+	m.Store(2, user{"b", 1})
+
+	// check expects the map to be left untouched by a failed write.
+	check := func(op string, err error) {
+		t.Helper()
+		if !errors.Is(err, indexed.ErrDuplicate) {
+			t.Errorf("%s: Expected ErrDuplicate, got %v", op, err)
+		}
+		if k, _, _ := m.byEmail.Lookup("a"); k != 1 {
+			t.Errorf("%s: Expected a to belong to 1, got %d", op, k)
+		}
+		if k, _, _ := m.byEmail.Lookup("b"); k != 2 {
+			t.Errorf("%s: Expected b to belong to 2, got %d", op, k)
+		}
+		if keys := keysBy(m.byTenant, 1); !slices.Equal(keys, []int{1, 2}) {
+			t.Errorf("%s: Expected keys [1 2], got %v", op, keys)
+		}
+		if m.Len() != 2 {
+			t.Errorf("%s: Expected 2 entries, got %d", op, m.Len())
+		}
+	}
+
+	check("TryStore()", m.TryStore(2, user{"a", 2}))
+	check("TryStore()", m.TryStore(3, user{"a", 2}))
+	_, _, err := m.TryLoadOrStore(3, user{"b", 2})
+	check("TryLoadOrStore()", err)
+	previous, loaded, err := m.TrySwap(1, user{"b", 1})
+	if loaded || previous != (user{}) {
+		t.Errorf("TrySwap(): Expected no previous value on error, got %v %v", previous, loaded)
+	}
+	check("TrySwap()", err)
+	check("TryUpdate()", m.TryUpdate(1, func(u user, ok bool) user {
+		u.email = "b"
+		return u
+	}))
+
+	// storing the same key in a unique index for the same entry is not a violation.
+	if err := m.TryStore(1, user{"a", 2}); err != nil {
+		t.Errorf("TryStore(): Unexpected error %v", err)
+	}
+	m.Delete(1)
+	if err := m.TryStore(3, user{"a", 2}); err != nil {
+		t.Errorf("TryStore(): Expected the key of the deleted entry to be available, got %v", err)
+	}
+}
+
+func TestUniquePanic(t *testing.T) {
+	m := newUsers()
+	m.Store(1, user{"a", 1})
+	m.Store(2, user{"b", 1})
+	// every write of TypedMap violating the index panics with ErrDuplicate and leaves the map untouched.
+	for op, f := range map[string]func(){
+		"Store()":       func() { m.Store(2, user{"a", 2}) },
+		"LoadOrStore()": func() { m.LoadOrStore(3, user{"a", 2}) },
+		"Swap()":        func() { m.Swap(2, user{"a", 2}) },
+		"Update()":      func() { m.Update(2, func(u user, ok bool) user { return user{"a", 2} }) },
+		"CompareAndSwap()": func() {
+			m.CompareAndSwap(2, user{"b", 1}, user{"a", 2})
+		},
+		"Compute()": func() {
+			m.Compute(3, func(old user, loaded bool) (user, compute.Op) { return user{"a", 2}, compute.Store })
+		},
+		"UpdateRange()": func() {
+			m.UpdateRange(func(k int, u user) (user, bool) { return user{"a", 2}, true })
+		},
+		"UpdateRangeAtomic()": func() {
+			m.UpdateRangeAtomic(func(k int, u user) (user, bool) { return user{"a", 2}, true })
+		},
+		"Exclusive()": func() {
+			m.Exclusive(func(data map[int]user) { data[3] = user{"b", 2} })
+		},
+	} {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, indexed.ErrDuplicate) {
+					t.Errorf("%s: Expected a panic with ErrDuplicate, got %v", op, err)
+				}
+			}()
+			f()
+		}()
+		if k, _, _ := m.byEmail.Lookup("a"); k != 1 || m.Len() != 2 || m.byTenant.Count(1) != 2 || m.byTenant.Count(2) != 0 {
+			t.Errorf("%s: Expected the map to be left untouched", op)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	m := newUsers()
+	m.Store(1, user{"a", 1})
+	m.Store(2, user{"b", 1})
+
+	// the writes of a batch are checked together: the entries can exchange their keys in a unique index.
+	exchange := func(k int, u user) (user, bool) {
+		u.email = map[string]string{"a": "b", "b": "a"}[u.email]
+		return u, true
+	}
+	if !m.UpdateRangeAtomic(exchange) {
+		t.Errorf("UpdateRangeAtomic(): Expected the values to be updated")
+	}
+	if k, _, _ := m.byEmail.Lookup("a"); k != 2 {
+		t.Errorf("UpdateRangeAtomic(): Expected a to belong to 2, got %d", k)
+	}
+	m.UpdateRange(exchange)
+	if k, _, _ := m.byEmail.Lookup("a"); k != 1 {
+		t.Errorf("UpdateRange(): Expected a to belong to 1, got %d", k)
+	}
+	m.Exclusive(func(data map[int]user) {
+		delete(data, 1)
+		data[3] = user{"a", 2}
+	})
+	if k, _, _ := m.byEmail.Lookup("a"); k != 3 || m.Has(1) || m.byTenant.Count(1) != 1 {
+		t.Errorf("Exclusive(): Expected a to belong to 3, got %d", k)
+	}
+
+	err := m.Transaction(func(tx txn.Tx[int, user]) error {
+		tx.Delete(3)
+		tx.Store(2, user{"a", 2})
+		tx.Store(4, user{"b", 1})
+		return nil
+	})
+	if k, _, _ := m.byEmail.Lookup("b"); err != nil || k != 4 || m.Has(3) {
+		t.Errorf("Transaction(): Expected b to belong to 4, got %d, %v", k, err)
+	}
+	err = m.Transaction(func(tx txn.Tx[int, user]) error {
+		tx.Delete(2)
+		tx.Store(5, user{"b", 2})
+		return nil
+	})
+	if !errors.Is(err, indexed.ErrDuplicate) || !m.Has(2) || m.Has(5) {
+		t.Errorf("Transaction(): Expected ErrDuplicate and the map to be left untouched, got %v", err)
+	}
+}
+
+func TestLookupBy(t *testing.T) {
+	m := newUsers()
+	m.Store(1, user{"a", 1})
+	m.Store(2, user{"b", 1})
+	if k, v, ok := m.LookupBy("email", "b"); !ok || k != 2 || v != (user{"b", 1}) {
+		t.Errorf("LookupBy(): Expected 2 {b 1}, got %v %v %v", k, v, ok)
+	}
+	if _, _, ok := m.LookupBy("email", "c"); ok {
+		t.Errorf("LookupBy(): Expected no entry")
+	}
+	if keys := slices.Sorted(maps.Keys(maps.Collect(m.RangeBy("tenant", 1)))); !slices.Equal(keys, []int{1, 2}) {
+		t.Errorf("RangeBy(): Expected keys [1 2], got %v", keys)
+	}
+	for range m.RangeBy("tenant", 1) {
+		break
+	}
+
+	// the keys of an index of interface type can be nil.
+	n := indexed.New[int, user](nil)
+	byTenant, _ := indexed.NewIndex(n, "tenant", single(func(u user) any {
+		if u.tenant == 0 {
+			return nil
+		}
+		return u.tenant
+	}), false)
+	n.Store(1, user{"a", 0})
+	if k, _, ok := n.LookupBy("tenant", nil); !ok || k != 1 || byTenant.Count(nil) != 1 {
+		t.Errorf("LookupBy(): Expected 1, got %v %v", k, ok)
+	}
+
+	for name, f := range map[string]func(){
+		"LookupBy() of a missing index":         func() { m.LookupBy("name", "a") },
+		"LookupBy() of a key of the wrong type": func() { m.LookupBy("email", 1) },
+		"LookupBy() of a nil key":               func() { m.LookupBy("email", nil) },
+		"RangeBy() of a missing index": func() {
+			for range m.RangeBy("name", "a") {
+			}
+		},
+		"NewIndex() of an existing name": func() { indexed.NewIndex(m.TypedMap, "email", single(func(u user) int { return u.tenant }), false) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Expected a panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestChangedInPlace(t *testing.T) {
+	m := indexed.New[int, *user](nil)
+	byEmail, _ := indexed.NewIndex(m, "email", single(func(u *user) string { return u.email }), true)
+	u := &user{"a", 1}
+	m.Store(1, u)
+	u.email = "b"
+	m.Store(1, u)
+	if n := byEmail.Count("a"); n != 0 {
+		t.Errorf("Count(): Expected the previous key to be removed, got %d entries", n)
+	}
+	if k, _, ok := byEmail.Lookup("b"); !ok || k != 1 {
+		t.Errorf("Lookup(): Expected b to belong to 1, got %d, %v", k, ok)
+	}
+	u.email = "c"
+	m.Delete(1)
+	if n := byEmail.Count("b"); n != 0 {
+		t.Errorf("Count(): Expected the key of the deleted entry to be removed, got %d entries", n)
+	}
+	if err := m.TryStore(2, &user{"b", 1}); err != nil {
+		t.Errorf("TryStore(): Expected the key of the deleted entry to be available, got %v", err)
+	}
+}
+
+func TestIndexPanic(t *testing.T) {
+	m := newUsers()
+	byName, _ := indexed.NewIndex(m.TypedMap, "name", single(func(u user) string {
+		if u.email == "" {
+			panic("no email")
+		}
+		return u.email
+	}), false)
+	m.Store(1, user{"a", 1})
+	func() {
+		defer func() {
+			recover()
+		}()
+		m.Store(1, user{"", 2})
+	}()
+	if v, _ := m.Load(1); v != (user{"a", 1}) {
+		t.Errorf("Store(): Expected the map to be left untouched by a panicking index, got %v", v)
+	}
+	if m.byEmail.Count("a") != 1 || m.byTenant.Count(1) != 1 || m.byTenant.Count(2) != 0 || byName.Count("a") != 1 {
+		t.Errorf("Store(): Expected the indexes to be left untouched by a panicking index")
+	}
+}
+
+func TestIterators(t *testing.T) {
+	m := newUsers()
+	expect := map[int]user{1: {"a", 1}, 2: {"b", 1}, 3: {"c", 2}}
+	for k, v := range expect {
+		m.Store(k, v)
+	}
+	if got := maps.Collect(m.All()); !maps.Equal(got, expect) {
+		t.Errorf("All(): Expected %v, got %v", expect, got)
+	}
+	if keys := slices.Sorted(m.KeysSeq()); !slices.Equal(keys, []int{1, 2, 3}) {
+		t.Errorf("KeysSeq(): Expected [1 2 3], got %v", keys)
+	}
+	if values := slices.Collect(m.ValuesSeq()); len(values) != 3 {
+		t.Errorf("ValuesSeq(): Expected 3 values, got %v", values)
+	}
+	for range m.All() {
+		break
+	}
+	for range m.KeysSeq() {
+		break
+	}
+	for range m.ValuesSeq() {
+		break
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	m := newUsers()
+	const goroutines = 8
+	ctx, start := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	// every goroutine competes for the same emails, each email must end up belonging to a single entry.
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			for i := 0; i < 100; i++ {
+				key := g*100 + i
+				email := string(rune('a' + i%10))
+				if m.TryStore(key, user{email, g}) == nil {
+					m.Delete(key)
+				}
+				m.byEmail.Lookup(email)
+				for range m.byTenant.Range(g) {
+				}
+			}
+		}()
+	}
+	start()
+	wg.Wait()
+	if m.Len() != 0 {
+		t.Errorf("Expected an empty map, got %d entries", m.Len())
+	}
+	if _, _, ok := m.byEmail.Lookup("a"); ok {
+		t.Errorf("Expected empty indexes")
+	}
+}
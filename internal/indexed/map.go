@@ -0,0 +1,156 @@
+package indexed
+
+import (
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// must panics with err, if not nil: the writes of the map panic if they would violate a unique index, see TryStore.
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Store sets the value for a key and updates the indexes.
+// If the value would violate a unique index, Store panics with an error wrapping ErrDuplicate and the map is left untouched, see TryStore.
+func (m *TypedMap[K, V]) Store(key K, value V) {
+	must(m.TryStore(key, value))
+}
+
+// TryStore is Store, but it returns an error wrapping ErrDuplicate if the value would violate a unique index.
+func (m *TypedMap[K, V]) TryStore(key K, value V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.set(key, value)
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.
+// The ok result indicates whether value was found in the map.
+func (m *TypedMap[K, V]) Load(key K) (value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok = m.data[key]
+	return value, ok
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value, unless it would violate a unique index, see Store.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *TypedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	actual, loaded, err := m.TryLoadOrStore(key, value)
+	must(err)
+	return actual, loaded
+}
+
+// TryLoadOrStore is LoadOrStore, but it returns an error wrapping ErrDuplicate if the value would violate a unique index.
+func (m *TypedMap[K, V]) TryLoadOrStore(key K, value V) (actual V, loaded bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if actual, ok := m.data[key]; ok {
+		return actual, true, nil
+	}
+	if err := m.set(key, value); err != nil {
+		return actual, false, err
+	}
+	return value, false, nil
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(key)
+}
+
+// Delete removes the key from the map and from the indexes.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+// If the value would violate a unique index, Swap panics, see Store.
+func (m *TypedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	previous, loaded, err := m.TrySwap(key, value)
+	must(err)
+	return previous, loaded
+}
+
+// TrySwap is Swap, but it returns an error wrapping ErrDuplicate if the value would violate a unique index.
+func (m *TypedMap[K, V]) TrySwap(key K, value V) (previous V, loaded bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, loaded = m.data[key]
+	if err := m.set(key, value); err != nil {
+		var zero V
+		return zero, false, err
+	}
+	return previous, loaded, nil
+}
+
+// CompareAndSwap swaps the old and new values for key
+// if the value stored in the map is equal to old.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+// If the new value would violate a unique index, CompareAndSwap panics, see Store.
+//
+// Returns true if the swap was performed.
+func (m *TypedMap[K, V]) CompareAndSwap(key K, old, new V) bool {
+	if !m.ValuesComparable() {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if !ok || !m.ValuesEqual(v, old) {
+		return false
+	}
+	must(m.set(key, new))
+	return true
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
+//
+// If there is no current value for key in the map, CompareAndDelete
+// returns false (even if the old value is the nil interface value).
+func (m *TypedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if !ok || !m.ValuesEqual(v, old) {
+		return false
+	}
+	m.remove(key)
+	return true
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+// Avoid invoking any map functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Range(f func(K, V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for key, value := range m.data {
+		if !f(key, value) {
+			break
+		}
+	}
+}
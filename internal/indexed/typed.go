@@ -0,0 +1,240 @@
+package indexed
+
+import (
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Clear removes all items from the map and from the indexes.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.data)
+	for _, idx := range m.indexes {
+		idx.clear()
+	}
+}
+
+// Has returns true if the map contains the key.
+func (m *TypedMap[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+// If the value returned by f would violate a unique index, Update panics, see Store.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	must(m.TryUpdate(key, f))
+}
+
+// TryUpdate is Update, but it returns an error wrapping ErrDuplicate if the value returned by f would violate a unique index.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) TryUpdate(key K, f func(V, bool) V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.data[key]
+	return m.set(key, f(value, ok))
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+// The values returned by f are applied together once the iteration stops: if they would violate a unique index,
+// UpdateRange panics and the map is left untouched, see Store.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changes := make([]change[K, V], 0, len(m.data))
+	for key, value := range m.data {
+		newValue, ok := f(key, value)
+		if !ok {
+			break
+		}
+		changes = append(changes, change[K, V]{key: key, value: newValue})
+	}
+	must(m.apply(changes...))
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated. If they would violate a unique index, UpdateRangeAtomic panics, see Store.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changes := make([]change[K, V], 0, len(m.data))
+	for key, value := range m.data {
+		newValue, ok := f(key, value)
+		if !ok {
+			return false
+		}
+		changes = append(changes, change[K, V]{key: key, value: newValue})
+	}
+	must(m.apply(changes...))
+	return true
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+// f receives a copy of the map, the entries it stores or removes are applied to the map and the indexes once f returns:
+// if they would violate a unique index, Exclusive panics and the map is left untouched, see Store.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := make(map[K]V, len(m.data))
+	for key, value := range m.data {
+		data[key] = value
+	}
+	f(data)
+	// every value is indexed again, as f may have changed them in place.
+	changes := make([]change[K, V], 0, len(data))
+	for key := range m.data {
+		if _, ok := data[key]; !ok {
+			changes = append(changes, change[K, V]{key: key, deleted: true})
+		}
+	}
+	for key, value := range data {
+		changes = append(changes, change[K, V]{key: key, value: value})
+	}
+	must(m.apply(changes...))
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics.
+// The writes are checked together against the unique indexes: if they would violate one, they are discarded
+// and Transaction returns an error wrapping ErrDuplicate.
+//
+// The map cannot take part in a transaction spanning several maps, as its writes could fail once the other maps are written.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	b := &batch[K, V]{m: m, keys: make(map[K]int)}
+	if err := txn.Run(b, f); err != nil {
+		return err
+	}
+	return b.err
+}
+
+// batch is the txn.Map used by Transaction: the writes of the transaction are recorded when committed,
+// then applied together before the map is unlocked, so that they are checked against the unique indexes at once.
+type batch[K comparable, V any] struct {
+	m       *TypedMap[K, V]
+	changes []change[K, V]
+	// keys holds the position in changes of the last write of each key.
+	keys map[K]int
+	err  error
+}
+
+func (b *batch[K, V]) TxID() uint64 {
+	return b.m.TxID()
+}
+
+func (b *batch[K, V]) TxLock() txn.Locked[K, V] {
+	b.m.mu.Lock()
+	return txn.Locked[K, V]{
+		Load: func(key K) (V, bool) {
+			v, ok := b.m.data[key]
+			return v, ok
+		},
+		Store: func(key K, value V) {
+			b.record(change[K, V]{key: key, value: value})
+		},
+		Remove: func(key K) {
+			b.record(change[K, V]{key: key, deleted: true})
+		},
+		Unlock: b.unlock,
+	}
+}
+
+// record records c, replacing the previous write of the same key if any.
+func (b *batch[K, V]) record(c change[K, V]) {
+	if i, ok := b.keys[c.key]; ok {
+		b.changes[i] = c
+		return
+	}
+	b.keys[c.key] = len(b.changes)
+	b.changes = append(b.changes, c)
+}
+
+// unlock applies the recorded writes, if any, and releases the lock of the map.
+func (b *batch[K, V]) unlock() {
+	defer b.m.mu.Unlock()
+	if len(b.changes) > 0 {
+		b.err = b.m.apply(b.changes...)
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The map is locked for the duration of the iteration, so the entries are removed atomically.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range m.data {
+		if f(key, value) {
+			m.remove(key)
+			n++
+		}
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map.
+func (m *TypedMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.data)
+}
+
+// Keys returns a slice of all the keys present in the map, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Keys() (keys []K) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Values() (values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values = make([]V, 0, len(m.data))
+	for _, value := range m.data {
+		values = append(values, value)
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map.
+func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, len(m.data))
+	values = make([]V, 0, len(m.data))
+	for key, value := range m.data {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
@@ -0,0 +1,90 @@
+// Package list implements the doubly linked lists used by the bounded maps to keep their keys in order of recency.
+package list
+
+// Node is an element of a List.
+type Node[T any] struct {
+	Value      T
+	prev, next *Node[T]
+	// list is the list holding the node, nil if the node is not linked.
+	list *List[T]
+}
+
+// Next returns the node following n in its list, nil if n is the back of the list or is not linked.
+func (n *Node[T]) Next() *Node[T] {
+	if n.list == nil || n.next == &n.list.root {
+		return nil
+	}
+	return n.next
+}
+
+// List returns the list holding n, nil if n is not linked.
+func (n *Node[T]) List() *List[T] {
+	return n.list
+}
+
+// List is a doubly linked list of nodes, the front of the list is the most recently inserted node.
+type List[T any] struct {
+	root Node[T]
+	len  int
+}
+
+// New returns an empty list.
+func New[T any]() *List[T] {
+	return new(List[T]).Init()
+}
+
+// Init empties the list and returns it, the nodes it was holding must not be used with it anymore.
+func (l *List[T]) Init() *List[T] {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+	return l
+}
+
+// Len returns the number of nodes in the list.
+func (l *List[T]) Len() int {
+	return l.len
+}
+
+// Front returns the first node of the list or nil if the list is empty.
+func (l *List[T]) Front() *Node[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+// Back returns the last node of the list or nil if the list is empty.
+func (l *List[T]) Back() *Node[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// PushFront inserts n, which must not be linked, at the front of the list.
+func (l *List[T]) PushFront(n *Node[T]) {
+	n.prev = &l.root
+	n.next = l.root.next
+	l.root.next.prev = n
+	l.root.next = n
+	n.list = l
+	l.len++
+}
+
+// Remove unlinks n, which must belong to l, from the list.
+func (l *List[T]) Remove(n *Node[T]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.prev, n.next, n.list = nil, nil, nil
+	l.len--
+}
+
+// MoveToFront moves n, which must belong to l, to the front of the list.
+func (l *List[T]) MoveToFront(n *Node[T]) {
+	if l.root.next == n {
+		return
+	}
+	l.Remove(n)
+	l.PushFront(n)
+}
@@ -0,0 +1,57 @@
+package list_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/list"
+)
+
+// values returns the values of l from the front to the back.
+func values(l *list.List[int]) (values []int) {
+	for n := l.Front(); n != nil; n = n.Next() {
+		values = append(values, n.Value)
+	}
+	return values
+}
+
+func TestList(t *testing.T) {
+	l := list.New[int]()
+	if l.Len() != 0 || l.Front() != nil || l.Back() != nil {
+		t.Errorf("New(): Expected an empty list, got %v", values(l))
+	}
+	nodes := make([]*list.Node[int], 3)
+	for i := range nodes {
+		nodes[i] = &list.Node[int]{Value: i}
+		l.PushFront(nodes[i])
+	}
+	if got := values(l); !slices.Equal(got, []int{2, 1, 0}) || l.Len() != 3 {
+		t.Errorf("PushFront(): Expected [2 1 0], got %v", got)
+	}
+	if l.Front() != nodes[2] || l.Back() != nodes[0] || nodes[0].List() != l {
+		t.Errorf("Front(), Back(): Expected 2 and 0, got %d and %d", l.Front().Value, l.Back().Value)
+	}
+
+	l.MoveToFront(nodes[0])
+	l.MoveToFront(nodes[0])
+	if got := values(l); !slices.Equal(got, []int{0, 2, 1}) {
+		t.Errorf("MoveToFront(): Expected [0 2 1], got %v", got)
+	}
+
+	l.Remove(nodes[2])
+	if got := values(l); !slices.Equal(got, []int{0, 1}) || l.Len() != 2 {
+		t.Errorf("Remove(): Expected [0 1], got %v", got)
+	}
+	if nodes[2].List() != nil || nodes[2].Next() != nil {
+		t.Errorf("Remove(): Expected the node to be unlinked")
+	}
+
+	l.Init()
+	if l.Len() != 0 || l.Front() != nil {
+		t.Errorf("Init(): Expected an empty list, got %v", values(l))
+	}
+	l.PushFront(nodes[2])
+	if got := values(l); !slices.Equal(got, []int{2}) {
+		t.Errorf("PushFront(): Expected [2], got %v", got)
+	}
+}
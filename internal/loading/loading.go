@@ -0,0 +1,357 @@
+package loading
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Map is the map holding the loaded values.
+type Map[K comparable, V any] interface {
+	Load(key K) (value V, ok bool)
+	Store(key K, value V)
+	Has(key K) bool
+}
+
+// pruneThreshold is the minimum number of tracked load times before the keys no longer present in the map are dropped.
+const pruneThreshold = 1024
+
+// Loader returns the value for key, it is called when the value is not present in the map.
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Group loads the values missing from a map using a Loader, concurrent loads of the same key are coalesced into a single call.
+type Group[K comparable, V any] struct {
+	m            Map[K, V]
+	loader       Loader[K, V]
+	now          func() time.Time
+	negativeTTL  time.Duration
+	refreshAfter time.Duration
+	expireAfter  time.Duration
+	mu           sync.Mutex
+	calls        map[K]*call[V]
+	failures     map[K]failure
+	pruneAt      int
+	// pruning holds the keys to check against the map once the lock is released, see setLoaded and unlock.
+	pruning []loadTime[K]
+	// loaded holds the time at which the values have been loaded, if refreshAfter or expireAfter are set.
+	// It is written holding both mu and ages, so that it can be read holding either of them:
+	// the values found fresh are returned holding only the read lock of ages.
+	ages   sync.RWMutex
+	loaded map[K]time.Time
+}
+
+// loadTime is the time at which the value for a key has been loaded.
+type loadTime[K comparable] struct {
+	key K
+	at  time.Time
+}
+
+// call is an in-flight, or completed, invocation of the loader.
+type call[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+	// background is set for refreshes, which are never cancelled.
+	background bool
+}
+
+// failure is an error returned by the loader, cached until deadline.
+type failure struct {
+	err      error
+	deadline time.Time
+}
+
+// New returns a new Group storing in m the values returned by loader.
+// If negativeTTL is positive the errors returned by the loader are cached for negativeTTL, according to now.
+// If refreshAfter is positive values are reloaded in the background once older than refreshAfter,
+// if expireAfter is positive values older than expireAfter are no longer returned.
+func New[K comparable, V any](m Map[K, V], loader Loader[K, V], now func() time.Time, negativeTTL, refreshAfter, expireAfter time.Duration) *Group[K, V] {
+	return &Group[K, V]{
+		m:            m,
+		loader:       loader,
+		now:          now,
+		negativeTTL:  negativeTTL,
+		refreshAfter: refreshAfter,
+		expireAfter:  expireAfter,
+		calls:        make(map[K]*call[V]),
+		failures:     make(map[K]failure),
+		loaded:       make(map[K]time.Time),
+		pruneAt:      pruneThreshold,
+	}
+}
+
+// Get returns the value for key from the map, loading and storing it if not present.
+// Concurrent calls for the same key wait for a single load, a caller stops waiting when ctx is done
+// and the load is cancelled once no caller is waiting for it.
+//
+// If refreshAfter is set, values older than refreshAfter are returned while being reloaded in the background,
+// if expireAfter is set, values older than expireAfter are loaded again as if they were missing.
+func (g *Group[K, V]) Get(ctx context.Context, key K) (V, error) {
+	v, ok := g.m.Load(key)
+	if ok && g.fresh(key) {
+		return v, nil
+	}
+	g.mu.Lock()
+	if !ok {
+		// the value might have been stored by a load completed after the first lookup.
+		v, ok = g.m.Load(key)
+	}
+	if ok && g.usable(ctx, key) {
+		g.unlock()
+		return v, nil
+	}
+	if err, ok := g.failed(key); ok {
+		g.unlock()
+		var zero V
+		return zero, err
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		c = g.start(ctx, key)
+	}
+	c.waiters++
+	g.unlock()
+	return g.wait(ctx, key, c)
+}
+
+// aging reports whether the age of the values is tracked.
+func (g *Group[K, V]) aging() bool {
+	return g.refreshAfter > 0 || g.expireAfter > 0
+}
+
+// fresh reports whether the value stored for key is younger than both refreshAfter and expireAfter,
+// values not seen yet by the group are not fresh so that usable records their load time.
+func (g *Group[K, V]) fresh(key K) bool {
+	if !g.aging() {
+		return true
+	}
+	g.ages.RLock()
+	at, ok := g.loaded[key]
+	g.ages.RUnlock()
+	if !ok {
+		return false
+	}
+	age := g.now().Sub(at)
+	return (g.expireAfter <= 0 || age < g.expireAfter) && (g.refreshAfter <= 0 || age < g.refreshAfter)
+}
+
+// usable reports whether the value stored for key can be returned, starting a background refresh if it is older than refreshAfter.
+// Values not stored by the group are considered loaded the first time they are seen. The caller must hold the lock.
+func (g *Group[K, V]) usable(ctx context.Context, key K) bool {
+	if !g.aging() {
+		return true
+	}
+	at, ok := g.loaded[key]
+	if !ok {
+		g.setLoaded(key)
+		return true
+	}
+	age := g.now().Sub(at)
+	if g.expireAfter > 0 && age >= g.expireAfter {
+		return false
+	}
+	if g.refreshAfter > 0 && age >= g.refreshAfter {
+		if _, ok := g.calls[key]; !ok {
+			if _, ok := g.failed(key); !ok {
+				g.start(ctx, key).background = true
+			}
+		}
+	}
+	return true
+}
+
+// Expired reports whether the value stored for key is older than expireAfter, it is always false if expireAfter is not set.
+// Values not loaded by the group are not expired until they are seen by Get.
+func (g *Group[K, V]) Expired(key K) bool {
+	if g.expireAfter <= 0 {
+		return false
+	}
+	g.ages.RLock()
+	defer g.ages.RUnlock()
+	at, ok := g.loaded[key]
+	return ok && g.now().Sub(at) >= g.expireAfter
+}
+
+// ExpiredKeys returns the keys whose value is older than expireAfter, nil if there are none, see Expired.
+// The map is not accessed, so ExpiredKeys can be called while holding its lock.
+func (g *Group[K, V]) ExpiredKeys() (expired map[K]struct{}) {
+	if g.expireAfter <= 0 {
+		return nil
+	}
+	g.ages.RLock()
+	defer g.ages.RUnlock()
+	now := g.now()
+	for key, at := range g.loaded {
+		if now.Sub(at) >= g.expireAfter {
+			if expired == nil {
+				expired = make(map[K]struct{})
+			}
+			expired[key] = struct{}{}
+		}
+	}
+	return expired
+}
+
+// Stored records that the values of keys have been written to the map now, by a write not made by the group:
+// their age restarts from zero.
+func (g *Group[K, V]) Stored(keys ...K) {
+	if !g.aging() {
+		return
+	}
+	g.mu.Lock()
+	defer g.unlock()
+	g.setLoaded(keys...)
+}
+
+// Removed records that keys have been removed from the map, a value stored again for them is considered loaded once seen.
+func (g *Group[K, V]) Removed(keys ...K) {
+	if !g.aging() {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ages.Lock()
+	defer g.ages.Unlock()
+	for _, key := range keys {
+		delete(g.loaded, key)
+	}
+}
+
+// Reset drops the load time of every key, then records that the values of keys have been written to the map now.
+func (g *Group[K, V]) Reset(keys ...K) {
+	if !g.aging() {
+		return
+	}
+	g.mu.Lock()
+	defer g.unlock()
+	g.ages.Lock()
+	clear(g.loaded)
+	g.ages.Unlock()
+	g.pruneAt = pruneThreshold
+	g.pruning = nil
+	g.setLoaded(keys...)
+}
+
+// setLoaded records that the values for keys have been loaded now, the caller must hold the lock.
+// Whenever the number of tracked keys doubles, the tracked keys are collected so that unlock drops the ones no longer present in the map.
+func (g *Group[K, V]) setLoaded(keys ...K) {
+	now := g.now()
+	g.ages.Lock()
+	defer g.ages.Unlock()
+	for _, key := range keys {
+		g.loaded[key] = now
+	}
+	if len(g.loaded) < g.pruneAt {
+		return
+	}
+	g.pruning = make([]loadTime[K], 0, len(g.loaded))
+	for key, at := range g.loaded {
+		g.pruning = append(g.pruning, loadTime[K]{key, at})
+	}
+	g.pruneAt = 2 * len(g.loaded)
+}
+
+// unlock releases the lock, then drops the load times of the keys collected by setLoaded that are no longer present in the map.
+// The map is accessed holding neither the lock nor ages, so that neither is held while waiting for the lock of the map,
+// whose writes may call Stored or Removed.
+func (g *Group[K, V]) unlock() {
+	pruning := g.pruning
+	g.pruning = nil
+	g.mu.Unlock()
+	if pruning == nil {
+		return
+	}
+	var gone []loadTime[K]
+	for _, l := range pruning {
+		if !g.m.Has(l.key) {
+			gone = append(gone, l)
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ages.Lock()
+	defer g.ages.Unlock()
+	for _, l := range gone {
+		// the key might have been stored, and loaded, again once checked.
+		if at, ok := g.loaded[l.key]; ok && at.Equal(l.at) {
+			delete(g.loaded, l.key)
+		}
+	}
+	g.pruneAt = max(pruneThreshold, 2*len(g.loaded))
+}
+
+// failed returns the cached error for key, if any. The caller must hold the lock.
+func (g *Group[K, V]) failed(key K) (error, bool) {
+	f, ok := g.failures[key]
+	if !ok {
+		return nil, false
+	}
+	if g.now().Before(f.deadline) {
+		return f.err, true
+	}
+	delete(g.failures, key)
+	return nil, false
+}
+
+// start invokes the loader for key in a new goroutine, the caller must hold the lock.
+// The load uses the values of ctx, but it is cancelled only when all the callers waiting for it are gone.
+func (g *Group[K, V]) start(ctx context.Context, key K) *call[V] {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &call[V]{done: make(chan struct{}), cancel: cancel}
+	g.calls[key] = c
+	go func() {
+		defer cancel()
+		c.value, c.err = g.load(ctx, key)
+		if c.err == nil {
+			// stored before removing the call, see the second lookup in Get.
+			g.m.Store(key, c.value)
+		}
+		g.mu.Lock()
+		if c.err == nil && g.aging() {
+			g.setLoaded(key)
+		}
+		if c.err != nil && ctx.Err() == nil && g.negativeTTL > 0 {
+			g.failures[key] = failure{err: c.err, deadline: g.now().Add(g.negativeTTL)}
+		}
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.unlock()
+		close(c.done)
+	}()
+	return c
+}
+
+// load calls the loader, turning a panic into an error.
+func (g *Group[K, V]) load(ctx context.Context, key K) (v V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("typedmap: loader panic: %v", r)
+		}
+	}()
+	return g.loader(ctx, key)
+}
+
+// wait returns the result of c, or the error of ctx if it is done first.
+func (g *Group[K, V]) wait(ctx context.Context, key K, c *call[V]) (V, error) {
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+	}
+	g.mu.Lock()
+	c.waiters--
+	if c.waiters == 0 && !c.background {
+		// nobody is waiting for the result, the next caller starts a new load.
+		c.cancel()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+	}
+	g.mu.Unlock()
+	var zero V
+	return zero, ctx.Err()
+}
@@ -0,0 +1,465 @@
+package loading_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thetechpanda/typedmap/internal/loading"
+	"github.com/thetechpanda/typedmap/internal/mutex"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var errLoad = errors.New("load failed")
+
+func TestGet(t *testing.T) {
+	m := mutex.New(map[string]int{"cached": 1})
+	var calls atomic.Int64
+	g := loading.New(m, func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		if key == "missing" {
+			return 0, errLoad
+		}
+		return len(key), nil
+	}, time.Now, 0, 0, 0)
+
+	if v, err := g.Get(context.Background(), "cached"); err != nil || v != 1 {
+		t.Errorf("Get(): Expected cached value 1, got %d, %v", v, err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Get(): Expected no load for a cached value, got %d", calls.Load())
+	}
+	if v, err := g.Get(context.Background(), "key"); err != nil || v != 3 {
+		t.Errorf("Get(): Expected loaded value 3, got %d, %v", v, err)
+	}
+	if v, ok := m.Load("key"); !ok || v != 3 {
+		t.Errorf("Load(): Expected loaded value to be stored, got %d", v)
+	}
+	if v, err := g.Get(context.Background(), "key"); err != nil || v != 3 || calls.Load() != 1 {
+		t.Errorf("Get(): Expected value 3 loaded once, got %d, %v after %d loads", v, err, calls.Load())
+	}
+
+	// errors are not cached by default.
+	for i := 0; i < 2; i++ {
+		if _, err := g.Get(context.Background(), "missing"); !errors.Is(err, errLoad) {
+			t.Errorf("Get(): Expected load error, got %v", err)
+		}
+	}
+	if calls.Load() != 3 || m.Has("missing") {
+		t.Errorf("Get(): Expected failed loads to be retried and not stored, got %d loads", calls.Load())
+	}
+}
+
+func TestNegativeCaching(t *testing.T) {
+	c := newClock()
+	var calls atomic.Int64
+	g := loading.New(mutex.New(map[string]int{}), func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		return 0, errLoad
+	}, c.Now, time.Minute, 0, 0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := g.Get(context.Background(), "key"); !errors.Is(err, errLoad) {
+			t.Errorf("Get(): Expected load error, got %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Get(): Expected the error to be cached, got %d loads", calls.Load())
+	}
+	c.Advance(time.Minute)
+	if _, err := g.Get(context.Background(), "key"); !errors.Is(err, errLoad) || calls.Load() != 2 {
+		t.Errorf("Get(): Expected the error to expire, got %v after %d loads", err, calls.Load())
+	}
+}
+
+func TestDeduplication(t *testing.T) {
+	var calls atomic.Int64
+	release := make(chan struct{})
+	g := loading.New(mutex.New(map[int]int{}), func(ctx context.Context, key int) (int, error) {
+		calls.Add(1)
+		<-release
+		return key * 2, nil
+	}, time.Now, 0, 0, 0)
+
+	var wg sync.WaitGroup
+	var started sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			if v, err := g.Get(context.Background(), 21); err != nil || v != 42 {
+				t.Errorf("Get(): Expected value 42, got %d, %v", v, err)
+			}
+		}()
+	}
+	started.Wait()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("Get(): Expected a single load, got %d", calls.Load())
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	release := make(chan struct{})
+	cancelled := make(chan struct{})
+	var calls atomic.Int64
+	g := loading.New(mutex.New(map[string]int{}), func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			close(cancelled)
+			return 0, ctx.Err()
+		}
+	}, time.Now, time.Minute, 0, 0)
+
+	// a waiting caller giving up does not affect the others.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := g.Get(ctx, "key")
+		done <- err
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	result := make(chan int)
+	go func() {
+		v, _ := g.Get(context.Background(), "key")
+		result <- v
+	}()
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Get(): Expected context.Canceled, got %v", err)
+	}
+	close(release)
+	if v := <-result; v != 1 {
+		t.Errorf("Get(): Expected value 1, got %d", v)
+	}
+
+	// the load is cancelled once nobody waits for it.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release = make(chan struct{})
+	if _, err := g.Get(ctx, "other"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get(): Expected context.DeadlineExceeded, got %v", err)
+	}
+	<-cancelled
+	// cancelled loads are not cached as failures.
+	go close(release)
+	if v, err := g.Get(context.Background(), "other"); err != nil || v != 1 {
+		t.Errorf("Get(): Expected value 1, got %d, %v", v, err)
+	}
+}
+
+func TestLoaderPanic(t *testing.T) {
+	g := loading.New(mutex.New(map[string]int{}), func(ctx context.Context, key string) (int, error) {
+		panic("boom")
+	}, time.Now, 0, 0, 0)
+	if _, err := g.Get(context.Background(), "key"); err == nil || err.Error() != "typedmap: loader panic: boom" {
+		t.Errorf("Get(): Expected loader panic error, got %v", err)
+	}
+}
+
+// racyMap is a Map where a value is stored by someone else right after the first lookup.
+type racyMap struct {
+	loads int
+}
+
+func (m *racyMap) Load(key string) (int, bool) {
+	m.loads++
+	return 1, m.loads > 1
+}
+
+func (m *racyMap) Store(key string, value int) {}
+
+func (m *racyMap) Has(key string) bool { return true }
+
+func TestStoredConcurrently(t *testing.T) {
+	g := loading.New[string, int](&racyMap{}, func(ctx context.Context, key string) (int, error) {
+		t.Error("Get(): Expected the value stored concurrently to be used")
+		return 0, nil
+	}, time.Now, 0, 0, 0)
+	if v, err := g.Get(context.Background(), "key"); err != nil || v != 1 {
+		t.Errorf("Get(): Expected value 1, got %d, %v", v, err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	c := newClock()
+	m := mutex.New(map[string]int{})
+	var calls atomic.Int64
+	release := make(chan struct{}, 1)
+	g := loading.New(m, func(ctx context.Context, key string) (int, error) {
+		n := calls.Add(1)
+		if n > 1 {
+			<-release
+		}
+		if n == 3 {
+			return 0, errLoad
+		}
+		return int(n), nil
+	}, c.Now, time.Minute, time.Minute, time.Hour)
+	get := func(expect int) {
+		t.Helper()
+		if v, err := g.Get(context.Background(), "key"); err != nil || v != expect {
+			t.Errorf("Get(): Expected value %d, got %d, %v", expect, v, err)
+		}
+	}
+	refreshed := func(expect int) {
+		t.Helper()
+		for i := 0; i < 1000; i++ {
+			if v, _ := m.Load("key"); v == expect {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Errorf("Expected the value to be refreshed to %d", expect)
+	}
+
+	get(1)
+	c.Advance(30 * time.Second)
+	get(1)
+	if calls.Load() != 1 {
+		t.Errorf("Get(): Expected fresh value not to be reloaded, got %d loads", calls.Load())
+	}
+
+	// stale values are returned while a single refresh runs in the background.
+	c.Advance(time.Minute)
+	get(1)
+	get(1)
+	release <- struct{}{}
+	refreshed(2)
+	get(2)
+	if calls.Load() != 2 {
+		t.Errorf("Get(): Expected a single refresh, got %d loads", calls.Load())
+	}
+
+	// a failed refresh keeps the stale value, the error is cached for the negative ttl.
+	c.Advance(time.Minute)
+	get(2)
+	release <- struct{}{}
+	for calls.Load() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	get(2)
+	if calls.Load() != 3 {
+		t.Errorf("Get(): Expected the failed refresh not to be retried, got %d loads", calls.Load())
+	}
+
+	// expired values are loaded again, callers wait for the load.
+	c.Advance(time.Hour)
+	release <- struct{}{}
+	get(4)
+	if calls.Load() != 4 {
+		t.Errorf("Get(): Expected the expired value to be reloaded, got %d loads", calls.Load())
+	}
+
+	// values stored directly in the map are considered loaded when first seen.
+	m.Store("other", 10)
+	getKey := func(key string, expect int) {
+		t.Helper()
+		if v, err := g.Get(context.Background(), key); err != nil || v != expect {
+			t.Errorf("Get(): Expected value %d, got %d, %v", expect, v, err)
+		}
+	}
+	getKey("other", 10)
+	c.Advance(30 * time.Second)
+	getKey("other", 10)
+	if calls.Load() != 4 {
+		t.Errorf("Get(): Expected stored value not to be reloaded, got %d loads", calls.Load())
+	}
+}
+
+func TestRefreshPrune(t *testing.T) {
+	c := newClock()
+	m := mutex.New(map[int]int{})
+	var calls atomic.Int64
+	g := loading.New(m, func(ctx context.Context, key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}, c.Now, 0, 0, time.Minute)
+	for i := 0; i < 5000; i++ {
+		g.Get(context.Background(), i)
+		m.Delete(i - 1)
+	}
+	c.Advance(time.Minute)
+	if v, err := g.Get(context.Background(), 4999); err != nil || v != 4999 || calls.Load() != 5001 {
+		t.Errorf("Get(): Expected expired value to be reloaded, got %d, %v after %d loads", v, err, calls.Load())
+	}
+}
+
+// hookMap is a map calling the group back when accessed, as a map whose removal hook writes through the LoadingMap does.
+type hookMap struct {
+	*mutex.TypedMap[int, int]
+	g *loading.Group[int, int]
+}
+
+func (m *hookMap) Has(key int) bool {
+	m.g.Removed(-1)
+	return m.TypedMap.Has(key)
+}
+
+func TestPruneUnlocked(t *testing.T) {
+	c := newClock()
+	m := &hookMap{TypedMap: mutex.New(map[int]int{})}
+	m.g = loading.New[int, int](m, nil, c.Now, 0, 0, time.Minute)
+	for i := 0; i < 2000; i++ {
+		m.Store(i, i)
+		m.g.Stored(i)
+		m.Delete(i - 1)
+	}
+	c.Advance(time.Minute)
+	if m.g.Expired(0) || !m.g.Expired(1999) {
+		t.Errorf("Stored(): Expected the load times of the deleted keys to be pruned")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	c := newClock()
+	m := mutex.New(map[string]int{"stored": 1})
+	g := loading.New(m, func(ctx context.Context, key string) (int, error) {
+		return len(key), nil
+	}, c.Now, 0, 0, time.Minute)
+	g.Get(context.Background(), "key")
+	if g.Expired("key") || g.ExpiredKeys() != nil {
+		t.Errorf("Expired(): Expected a fresh value")
+	}
+	c.Advance(time.Minute)
+	if !g.Expired("key") || g.Expired("stored") || g.Expired("missing") {
+		t.Errorf("Expired(): Expected only key to be expired")
+	}
+	if expired := g.ExpiredKeys(); len(expired) != 1 {
+		t.Errorf("ExpiredKeys(): Expected [key], got %v", expired)
+	}
+
+	// without expireAfter values never expire.
+	g = loading.New(m, func(ctx context.Context, key string) (int, error) {
+		return len(key), nil
+	}, c.Now, 0, time.Minute, 0)
+	g.Get(context.Background(), "key")
+	c.Advance(time.Hour)
+	if g.Expired("key") || g.ExpiredKeys() != nil {
+		t.Errorf("Expired(): Expected values not to expire without expireAfter")
+	}
+}
+
+func TestStoredRemoved(t *testing.T) {
+	c := newClock()
+	m := mutex.New(map[string]int{})
+	var calls atomic.Int64
+	g := loading.New(m, func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		return len(key), nil
+	}, c.Now, 0, 0, time.Minute)
+	g.Get(context.Background(), "a")
+	g.Get(context.Background(), "bb")
+	c.Advance(2 * time.Minute)
+
+	// a value written after expiring is fresh again.
+	m.Store("a", 42)
+	g.Stored("a")
+	if g.Expired("a") || !g.Expired("bb") {
+		t.Errorf("Stored(): Expected a to be fresh and bb expired")
+	}
+	if v, err := g.Get(context.Background(), "a"); err != nil || v != 42 || calls.Load() != 2 {
+		t.Errorf("Get(): Expected stored value 42 without reload, got %d, %v after %d loads", v, err, calls.Load())
+	}
+
+	// a removed key no longer carries its load time.
+	m.Delete("bb")
+	g.Removed("bb")
+	m.Store("bb", 7)
+	if g.Expired("bb") || g.ExpiredKeys() != nil {
+		t.Errorf("Removed(): Expected bb not to be expired once stored again")
+	}
+
+	c.Advance(2 * time.Minute)
+	g.Reset("bb")
+	if g.Expired("a") || g.Expired("bb") {
+		t.Errorf("Reset(): Expected a untracked and bb fresh")
+	}
+	c.Advance(2 * time.Minute)
+	if expired := g.ExpiredKeys(); len(expired) != 1 {
+		t.Errorf("ExpiredKeys(): Expected [bb], got %v", expired)
+	}
+
+	// without aging nothing is tracked.
+	g = loading.New(m, func(ctx context.Context, key string) (int, error) {
+		return len(key), nil
+	}, c.Now, 0, 0, 0)
+	g.Stored("a")
+	g.Removed("a")
+	g.Reset("a")
+	if g.Expired("a") {
+		t.Errorf("Expired(): Expected values not to expire without aging")
+	}
+}
+
+func TestConcurrentHits(t *testing.T) {
+	c := newClock()
+	m := mutex.New(map[int]int{})
+	var calls atomic.Int64
+	g := loading.New(m, func(ctx context.Context, key int) (int, error) {
+		calls.Add(1)
+		return key, nil
+	}, c.Now, 0, time.Minute, time.Hour)
+	for i := 0; i < 10; i++ {
+		g.Get(context.Background(), i)
+	}
+
+	// the fresh values are returned while their load times are being written.
+	numGoroutines := 50
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			<-ctx.Done()
+			for j := 0; j < 100; j++ {
+				if i%10 == 0 {
+					g.Stored(j % 10)
+					continue
+				}
+				if v, err := g.Get(context.Background(), j%10); err != nil || v != j%10 {
+					t.Errorf("Get(): Expected value %d, got %d, %v", j%10, v, err)
+				}
+			}
+		}(i)
+	}
+	cancel()
+	wg.Wait()
+	if calls.Load() != 10 {
+		t.Errorf("Get(): Expected fresh values not to be loaded again, got %d loads", calls.Load())
+	}
+}
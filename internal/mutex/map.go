@@ -1,6 +1,9 @@
 package mutex
 
-import "reflect"
+import (
+	"github.com/thetechpanda/typedmap/internal/derive"
+	"github.com/thetechpanda/typedmap/internal/removal"
+)
 
 // Store sets the value for a key.
 func (m *TypedMap[K, V]) Store(key K, value V) {
@@ -34,12 +37,12 @@ func (m *TypedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 // LoadAndDelete deletes the value for a key, returning the previous value if any.
 // The loaded result reports whether the key was present.
 func (m *TypedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	value, loaded = m.data[key]
-	if loaded {
-		delete(m.data, key)
-	}
+	m.remove(&b, key, removal.Deleted)
 	return value, loaded
 }
 
@@ -52,56 +55,72 @@ func (m *TypedMap[K, V]) Delete(key K) {
 // Swap swaps the value for a key and returns the previous value if any.
 // The loaded result reports whether the key was present.
 func (m *TypedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	previous, loaded = m.data[key]
-	m.data[key] = value
+	m.put(&b, key, value)
 	return previous, loaded
 }
 
 // CompareAndSwap swaps the old and new values for key
 // if the value stored in the map is equal to old.
 //
-// The old value must be of a comparable type or this function will return false.
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
 //
 // Returns true if the swap was performed.
 //
-// ! this function uses reflect.DeepEqual to compare the values.
+// ! this function uses reflect.DeepEqual to compare the values, unless the map has been created using NewComparable or NewEqual,
+// or V implements Equal(V) bool.
 func (m *TypedMap[K, V]) CompareAndSwap(key K, old, new V) bool {
-	if !m.valueComparable {
+	if !m.ValuesComparable() {
 		return false
 	}
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	v, ok := m.data[key]
-	if !ok || !reflect.DeepEqual(v, old) {
+	if !ok || !m.ValuesEqual(v, old) {
 		return false
 	}
-	m.data[key] = new
+	m.put(&b, key, new)
 	return true
 }
 
 // CompareAndDelete deletes the entry for key if its value is equal to old.
-// The old value must be of a comparable type or this function will return false.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
 //
 // If there is no current value for key in the map, CompareAndDelete
 // returns false (even if the old value is the nil interface value).
-//
-// ! this function uses reflect.DeepEqual to compare the values.
 func (m *TypedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
-	if !m.valueComparable {
+	if !m.ValuesComparable() {
 		return false
 	}
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	v, ok := m.data[key]
-	if !ok || !reflect.DeepEqual(v, old) {
+	if !ok || !m.ValuesEqual(v, old) {
 		return false
 	}
-	delete(m.data, key)
+	m.remove(&b, key, removal.Deleted)
 	return true
 }
 
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
 // Range calls f sequentially for each key and value present in the map.
 // If f returns false, Range stops the iteration.
 // Avoid invoking any map functions within 'f' to prevent a deadlock.
@@ -1,14 +1,40 @@
 package mutex
 
-import "reflect"
+import (
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
 
 // New returns a new TypedMap, initialized with the given map. if m is nil, an empty map is created.
 // m key, values are copied, so that the caller can safely modify the map after creating a TypedMap.
+//
+// CompareAndSwap and CompareAndDelete compare values using their Equal method, if V implements it, or reflect.DeepEqual.
+// If V is not comparable they always return false.
 func New[K comparable, V any](m map[K]V) *TypedMap[K, V] {
+	return NewEqual(m, nil, nil)
+}
+
+// NewEqual returns a new TypedMap, initialized with the given map, as New does.
+// If onRemove is not nil it is called, without holding the map lock, for every entry removed from the map.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil values are compared as New does.
+func NewEqual[K comparable, V any](m map[K]V, onRemove removal.Hook[K, V], equal equality.Func[V]) *TypedMap[K, V] {
+	return newTypedMap(m, onRemove, equality.NewComparer(equal, equality.Deep[V]))
+}
+
+// NewComparable returns a new TypedMap, initialized with the given map, as New does.
+// CompareAndSwap and CompareAndDelete compare values using ==, as sync.Map does: pointers are compared by identity.
+func NewComparable[K, V comparable](m map[K]V) *TypedMap[K, V] {
+	return newTypedMap(m, nil, equality.NewComparer(func(a, b V) bool {
+		return a == b
+	}, nil))
+}
+
+// newTypedMap returns a new TypedMap holding a copy of m, notifying onRemove and whose values are compared using c.
+func newTypedMap[K comparable, V any](m map[K]V, onRemove removal.Hook[K, V], c equality.Comparer[V]) *TypedMap[K, V] {
 	var v map[K]V = make(map[K]V, len(m))
 	for key, value := range m {
 		v[key] = value
 	}
-	var z V
-	return &TypedMap[K, V]{data: v, valueComparable: reflect.TypeOf(z).Comparable()}
+	return &TypedMap[K, V]{Comparer: c, ID: txn.NewID(), data: v, onRemove: onRemove}
 }
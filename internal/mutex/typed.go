@@ -1,19 +1,34 @@
 package mutex
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
 
 // TypedMap implements a simple thread-safe map that uses generics.
 type TypedMap[K comparable, V any] struct {
-	mu              sync.RWMutex
-	valueComparable bool
-	data            map[K]V
+	mu sync.RWMutex
+	equality.Comparer[V]
+	txn.ID
+	data     map[K]V
+	onRemove removal.Hook[K, V]
 }
 
 // Clear removes all items from the map.
 // This is a locking operation.
 func (m *TypedMap[K, V]) Clear() {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.onRemove != nil {
+		for key, value := range m.data {
+			b.Add(key, value, removal.Cleared)
+		}
+	}
 	m.data = make(map[K]V)
 }
 
@@ -27,10 +42,12 @@ func (m *TypedMap[K, V]) Has(key K) bool {
 //
 // ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
 func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	v, ok := m.data[key]
-	m.data[key] = f(v, ok)
+	m.put(&b, key, f(v, ok))
 }
 
 // UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
@@ -38,6 +55,8 @@ func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
 //
 // ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
 func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for key, value := range m.data {
@@ -45,17 +64,141 @@ func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
 		if !ok {
 			return
 		}
+		b.Replace(key, value, newValue)
 		m.data[key] = newValue
 	}
 }
 
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make(map[K]V, len(m.data))
+	for key, value := range m.data {
+		newValue, ok := f(key, value)
+		if !ok {
+			return false
+		}
+		values[key] = newValue
+	}
+	for key, value := range values {
+		m.put(&b, key, value)
+	}
+	return true
+}
+
 // Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+// If the map has a removal hook, f receives a copy of the map and the entries it removes or replaces are reported once f returns.
 //
 // ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
 func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	f(m.data)
+	if m.onRemove == nil {
+		f(m.data)
+		return
+	}
+	data := make(map[K]V, len(m.data))
+	for key, value := range m.data {
+		data[key] = value
+	}
+	f(data)
+	for key, old := range m.data {
+		if value, ok := data[key]; ok {
+			b.Replace(key, old, value)
+		} else {
+			b.Add(key, old, removal.Deleted)
+		}
+	}
+	m.data = data
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of the map, the returned txn.Locked gives access to its content until it is unlocked.
+// The removal hook is notified by Notify, once the locks of every map taking part in the transaction are released.
+func (m *TypedMap[K, V]) TxLock() txn.Locked[K, V] {
+	b := removal.NewBatch(m.onRemove)
+	m.mu.Lock()
+	return txn.Locked[K, V]{
+		Load: func(key K) (V, bool) {
+			v, ok := m.data[key]
+			return v, ok
+		},
+		Store: func(key K, value V) {
+			m.put(&b, key, value)
+		},
+		Remove: func(key K) {
+			m.remove(&b, key, removal.Deleted)
+		},
+		Unlock: m.mu.Unlock,
+		Notify: b.Notify,
+	}
+}
+
+// TxRLock acquires the read lock of the map, the returned txn.RLocked gives access to its content until it is unlocked.
+func (m *TypedMap[K, V]) TxRLock() txn.RLocked[K, V] {
+	m.mu.RLock()
+	return txn.RLocked[K, V]{
+		Load: func(key K) (V, bool) {
+			v, ok := m.data[key]
+			return v, ok
+		},
+		Range: func(f func(K, V) bool) {
+			for key, value := range m.data {
+				if !f(key, value) {
+					return
+				}
+			}
+		},
+		Len: func() int {
+			return len(m.data)
+		},
+		RUnlock: m.mu.RUnlock,
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The map is locked for the duration of the iteration, so the entries are removed atomically.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range m.data {
+		if f(key, value) {
+			m.remove(&b, key, removal.Deleted)
+			n++
+		}
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
 }
 
 // Len returns the number of items in the map.
@@ -120,3 +263,21 @@ func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
 	}
 	return keys, values
 }
+
+// put stores value for key, recording in b the replacement of the previous value if any.
+// The caller must hold the lock.
+func (m *TypedMap[K, V]) put(b *removal.Batch[K, V], key K, value V) {
+	if old, ok := m.data[key]; ok {
+		b.Replace(key, old, value)
+	}
+	m.data[key] = value
+}
+
+// remove deletes the entry for key, recording in b its removal for the given reason.
+// The caller must hold the lock.
+func (m *TypedMap[K, V]) remove(b *removal.Batch[K, V], key K, reason removal.Reason) {
+	if value, ok := m.data[key]; ok {
+		delete(m.data, key)
+		b.Add(key, value, reason)
+	}
+}
@@ -1,13 +1,21 @@
 package mutex_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"testing"
 
 	"github.com/thetechpanda/typedmap"
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/equality"
 	"github.com/thetechpanda/typedmap/internal/mutex"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
 )
 
 func TestNew(t *testing.T) {
@@ -725,3 +733,269 @@ func TestValues(t *testing.T) {
 	testValues(t, stP, stN)
 
 }
+
+func TestIterators(t *testing.T) {
+	m := mutex.New[int, int](nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i*2)
+	}
+
+	clone := maps.Collect(m.All())
+	if len(clone) != 100 {
+		t.Errorf("All(): Expected 100 entries, got %d", len(clone))
+	}
+	for k, v := range clone {
+		if v != k*2 {
+			t.Errorf("All(): Expected value %d for key %d, got %d", k*2, k, v)
+		}
+	}
+
+	keys := slices.Sorted(m.KeysSeq())
+	if len(keys) != 100 || keys[0] != 0 || keys[99] != 99 {
+		t.Errorf("KeysSeq(): Expected sorted keys from 0 to 99, got %v", keys)
+	}
+
+	values := slices.Sorted(m.ValuesSeq())
+	if len(values) != 100 || values[0] != 0 || values[99] != 198 {
+		t.Errorf("ValuesSeq(): Expected sorted values from 0 to 198, got %v", values)
+	}
+
+	count := 0
+	for range m.All() {
+		count++
+		break
+	}
+	for range m.KeysSeq() {
+		count++
+		break
+	}
+	for range m.ValuesSeq() {
+		count++
+		break
+	}
+	if count != 3 {
+		t.Errorf("Expected iterators to stop after break, got %d iterations", count)
+	}
+
+	// the lock is released once the loop exits.
+	m.Store(100, 200)
+}
+
+func TestNewComparable(t *testing.T) {
+	type value struct{ N int }
+	a, b := &value{1}, &value{1}
+	src := map[string]*value{"k": a}
+	m := mutex.NewComparable(src)
+	delete(src, "k")
+	if v, ok := m.Load("k"); !ok || v != a {
+		t.Errorf("NewComparable(): Expected the map to be copied, got %v, %v", v, ok)
+	}
+
+	// pointers are compared by identity, as sync.Map does.
+	if m.CompareAndSwap("k", b, b) {
+		t.Error("CompareAndSwap(): Expected a different pointer to an equal value not to match")
+	}
+	if m.CompareAndDelete("k", b) {
+		t.Error("CompareAndDelete(): Expected a different pointer to an equal value not to match")
+	}
+	if !m.CompareAndSwap("k", a, b) {
+		t.Error("CompareAndSwap(): Expected the same pointer to match")
+	}
+	if !m.CompareAndDelete("k", b) || m.Has("k") {
+		t.Error("CompareAndDelete(): Expected the same pointer to match")
+	}
+
+	// New compares the values pointed to.
+	d := mutex.New(map[string]*value{"k": a})
+	if !d.CompareAndSwap("k", b, b) {
+		t.Error("CompareAndSwap(): Expected New to compare the values pointed to")
+	}
+
+	// as with ==, comparing interface values holding non comparable types panics.
+	i := mutex.NewComparable(map[string]any{"k": []int{1}})
+	defer func() {
+		if recover() == nil {
+			t.Error("CompareAndSwap(): Expected a panic comparing non comparable values")
+		}
+	}()
+	i.CompareAndSwap("k", []int{1}, nil)
+}
+
+// point is a non comparable value providing its own equality, the tags are ignored.
+type point struct {
+	x, y int
+	tags []string
+}
+
+func (p point) Equal(o point) bool {
+	return p.x == o.x && p.y == o.y
+}
+
+func TestNewEqual(t *testing.T) {
+	m := mutex.NewEqual(map[int][]byte{1: []byte("a")}, nil, bytes.Equal)
+	if swapped, err := m.TryCompareAndSwap(1, []byte("b"), []byte("c")); swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected different value not to be swapped, got %v, %v", swapped, err)
+	}
+	if swapped, err := m.TryCompareAndSwap(1, []byte("a"), []byte("b")); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := m.TryCompareAndDelete(1, []byte("b")); !deleted || err != nil || m.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+
+	// the values of a non comparable type implementing Equaler are compared using their Equal method.
+	p := mutex.New(map[string]point{"p": {x: 1, y: 2, tags: []string{"a"}}})
+	if !p.CompareAndSwap("p", point{x: 1, y: 2}, point{x: 3}) {
+		t.Error("CompareAndSwap(): Expected the values to be compared using Equal")
+	}
+	// the equality function takes precedence over the Equal method.
+	p = mutex.NewEqual(map[string]point{"p": {x: 1}}, nil, func(a, b point) bool { return false })
+	if p.CompareAndDelete("p", point{x: 1}) {
+		t.Error("CompareAndDelete(): Expected the equality function to be used")
+	}
+
+	// interface values are compared using reflect.DeepEqual.
+	i := mutex.New(map[string]any{"k": []int{1}})
+	if !i.CompareAndSwap("k", []int{1}, nil) {
+		t.Error("CompareAndSwap(): Expected interface values to be compared using reflect.DeepEqual")
+	}
+
+	n := mutex.New(map[int][]int{1: {1}})
+	if _, err := n.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := n.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := mutex.New(map[int]int{}).TryCompareAndDelete(1, 1); err != nil {
+		t.Errorf("TryCompareAndDelete(): Expected no error, got %v", err)
+	}
+}
+
+func TestTxRLock(t *testing.T) {
+	m := mutex.New[string, int](nil)
+	m.Store("a", 1)
+	m.Store("b", 2)
+	r := m.TxRLock()
+	// readers are not blocked by the read lock.
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Errorf("Load(): Expected 1, got %d", v)
+	}
+	if v, ok := r.Load("b"); !ok || v != 2 {
+		t.Errorf("TxRLock(): Load() expected 2, got %d", v)
+	}
+	if n := r.Len(); n != 2 {
+		t.Errorf("TxRLock(): Len() expected 2, got %d", n)
+	}
+	got := map[string]int{}
+	r.Range(func(key string, value int) bool {
+		got[key] = value
+		return true
+	})
+	if !maps.Equal(got, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("TxRLock(): Range() expected a=1 b=2, got %v", got)
+	}
+	n := 0
+	r.Range(func(string, int) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("TxRLock(): Range() expected to stop after 1 entry, got %d", n)
+	}
+	r.RUnlock()
+	m.Store("c", 3)
+}
+
+// removals records the entries notified by the removal hook.
+type removals struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *removals) hook(key string, value int, reason removal.Reason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf("%s=%d:%s", key, value, reason))
+}
+
+// take returns the recorded events, sorted, and resets them.
+func (r *removals) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	slices.Sort(events)
+	return events
+}
+
+func TestOnRemove(t *testing.T) {
+	r := &removals{}
+	m := mutex.NewEqual(nil, r.hook, nil)
+	check := func(op string, expect ...string) {
+		t.Helper()
+		if got := r.take(); !slices.Equal(got, expect) {
+			t.Errorf("%s: Expected removals %v, got %v", op, expect, got)
+		}
+	}
+	m.Store("a", 1)
+	m.Store("a", 1)
+	check("Store()")
+	m.Store("a", 2)
+	check("Store()", "a=1:Replaced")
+	m.Swap("a", 3)
+	check("Swap()", "a=2:Replaced")
+	m.CompareAndSwap("a", 3, 4)
+	check("CompareAndSwap()", "a=3:Replaced")
+	m.Update("a", func(v int, ok bool) int { return 5 })
+	check("Update()", "a=4:Replaced")
+	m.Compute("a", func(v int, ok bool) (int, compute.Op) { return 6, compute.Store })
+	check("Compute()", "a=5:Replaced")
+	m.Delete("a")
+	check("Delete()", "a=6:Deleted")
+	m.Store("b", 1)
+	m.CompareAndDelete("b", 1)
+	check("CompareAndDelete()", "b=1:Deleted")
+	m.Store("c", 1)
+	m.LoadAndDelete("c")
+	check("LoadAndDelete()", "c=1:Deleted")
+	m.Store("d", 1)
+	m.Compute("d", func(v int, ok bool) (int, compute.Op) { return 0, compute.Delete })
+	check("Compute()", "d=1:Deleted")
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+	m.UpdateRange(func(k string, v int) (int, bool) { return v * 10, true })
+	check("UpdateRange()", "a=1:Replaced", "b=2:Replaced", "c=3:Replaced")
+	m.UpdateRangeAtomic(func(k string, v int) (int, bool) { return v + 1, true })
+	check("UpdateRangeAtomic()", "a=10:Replaced", "b=20:Replaced", "c=30:Replaced")
+	m.DeleteFunc(func(k string, v int) bool { return k == "c" })
+	check("DeleteFunc()", "c=31:Deleted")
+	m.Exclusive(func(data map[string]int) {
+		delete(data, "a")
+		data["b"] = 200
+		data["e"] = 1
+	})
+	check("Exclusive()", "a=11:Deleted", "b=21:Replaced")
+	m.Transaction(func(tx txn.Tx[string, int]) error {
+		tx.Delete("b")
+		tx.Store("e", 2)
+		return nil
+	})
+	check("Transaction()", "b=200:Deleted", "e=1:Replaced")
+	m.Clear()
+	check("Clear()", "e=2:Cleared")
+
+	// the hook is called without holding the lock, so it may use the map.
+	var n *mutex.TypedMap[string, int]
+	var present bool
+	n = mutex.NewEqual(nil, func(key string, value int, reason removal.Reason) {
+		_, present = n.Load(key)
+	}, nil)
+	n.Store("key", 1)
+	n.Delete("key")
+	if present {
+		t.Error("Expected the entry to be removed before the hook is called")
+	}
+}
@@ -0,0 +1,28 @@
+package ordered
+
+import (
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// All returns an iterator over the key-value pairs in the map, it behaves as Range does, visiting the entries in insertion order.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V]) All() iter.Seq2[K, V] {
+	return derive.All(m.Range)
+}
+
+// KeysSeq returns an iterator over the keys in the map, it behaves as Range does, visiting the entries in insertion order.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V]) KeysSeq() iter.Seq[K] {
+	return derive.Keys(m.Range)
+}
+
+// ValuesSeq returns an iterator over the values in the map, it behaves as Range does, visiting the entries in insertion order.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V]) ValuesSeq() iter.Seq[V] {
+	return derive.Values(m.Range)
+}
@@ -0,0 +1,113 @@
+package ordered
+
+import (
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// node is an entry of the map, linked in the insertion order list.
+type node[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *node[K, V]
+}
+
+// TypedMap implements a thread-safe map preserving the insertion order of its keys:
+// Range, Keys, Values, Entries and the iterators visit the entries from the first inserted to the last inserted.
+type TypedMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	equality.Comparer[V]
+	txn.ID
+	data map[K]*node[K, V]
+	// moveToBack moves the entries to the back of the list each time their value is stored.
+	moveToBack bool
+	// root is the sentinel of the list, root.next is the first entry and root.prev the last one.
+	root node[K, V]
+}
+
+// New returns a new TypedMap, if moveToBack is true storing the value of a key already present moves it to the back
+// of the insertion order, as if it was deleted and stored again.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](moveToBack bool, equal equality.Func[V]) *TypedMap[K, V] {
+	m := &TypedMap[K, V]{
+		Comparer:   equality.NewComparer(equal, equality.Deep[V]),
+		ID:         txn.NewID(),
+		data:       make(map[K]*node[K, V]),
+		moveToBack: moveToBack,
+	}
+	m.root.next = &m.root
+	m.root.prev = &m.root
+	return m
+}
+
+// First returns the first entry of the map, the ok result reports whether the map is not empty.
+func (m *TypedMap[K, V]) First() (key K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.entry(m.root.next)
+}
+
+// Last returns the last entry of the map, the ok result reports whether the map is not empty.
+func (m *TypedMap[K, V]) Last() (key K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.entry(m.root.prev)
+}
+
+// PopFirst removes the first entry of the map and returns it, the ok result reports whether the map was not empty.
+func (m *TypedMap[K, V]) PopFirst() (key K, value V, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.root.next
+	if key, value, ok = m.entry(n); ok {
+		m.remove(n)
+	}
+	return key, value, ok
+}
+
+// entry returns the key and value of n, ok is false if n is the sentinel of the list.
+func (m *TypedMap[K, V]) entry(n *node[K, V]) (key K, value V, ok bool) {
+	if n == &m.root {
+		return key, value, false
+	}
+	return n.key, n.value, true
+}
+
+// unlink removes n from the list.
+func (m *TypedMap[K, V]) unlink(n *node[K, V]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.prev, n.next = nil, nil
+}
+
+// pushBack inserts n as the last entry.
+func (m *TypedMap[K, V]) pushBack(n *node[K, V]) {
+	n.next = &m.root
+	n.prev = m.root.prev
+	m.root.prev.next = n
+	m.root.prev = n
+}
+
+// set stores value for key, new keys are inserted as the last entry.
+// If moveToBack is set, the entry of a key already present is moved to the back. The caller must hold the lock.
+func (m *TypedMap[K, V]) set(key K, value V) {
+	if n, ok := m.data[key]; ok {
+		n.value = value
+		if m.moveToBack && m.root.prev != n {
+			m.unlink(n)
+			m.pushBack(n)
+		}
+		return
+	}
+	n := &node[K, V]{key: key, value: value}
+	m.data[key] = n
+	m.pushBack(n)
+}
+
+// remove deletes n from the map, the caller must hold the lock.
+func (m *TypedMap[K, V]) remove(n *node[K, V]) {
+	m.unlink(n)
+	delete(m.data, n.key)
+}
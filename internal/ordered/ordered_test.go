@@ -0,0 +1,323 @@
+package ordered_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/ordered"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+func TestNew(t *testing.T) {
+	m := ordered.New[string, int](false, nil)
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected a new map, got map with length %d", m.Len())
+	}
+	if _, _, ok := m.First(); ok {
+		t.Errorf("First(): Expected an empty map")
+	}
+	if _, _, ok := m.Last(); ok {
+		t.Errorf("Last(): Expected an empty map")
+	}
+	if _, _, ok := m.PopFirst(); ok {
+		t.Errorf("PopFirst(): Expected an empty map")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	for _, moveToBack := range []bool{false, true} {
+		m := ordered.New[int, int](moveToBack, nil)
+		for i := 0; i < 3; i++ {
+			m.Store(i, i)
+		}
+		// check expects the keys in insertion order, or in the order given by moved if the map moves the stored entries to the back.
+		check := func(op string, expect, moved []int) {
+			t.Helper()
+			if moveToBack {
+				expect = moved
+			}
+			if keys := m.Keys(); !slices.Equal(keys, expect) {
+				t.Errorf("%s: Expected keys %v, got %v", op, expect, keys)
+			}
+		}
+		check("Store()", []int{0, 1, 2}, []int{0, 1, 2})
+		m.Store(0, 10)
+		check("Store()", []int{0, 1, 2}, []int{1, 2, 0})
+		m.LoadOrStore(1, 10)
+		check("LoadOrStore()", []int{0, 1, 2}, []int{1, 2, 0})
+		m.Update(1, func(v int, ok bool) int { return v + 10 })
+		check("Update()", []int{0, 1, 2}, []int{2, 0, 1})
+		m.Swap(2, 20)
+		check("Swap()", []int{0, 1, 2}, []int{0, 1, 2})
+		m.CompareAndSwap(0, 10, 30)
+		check("CompareAndSwap()", []int{0, 1, 2}, []int{1, 2, 0})
+		m.CompareAndSwap(1, 100, 30)
+		check("CompareAndSwap()", []int{0, 1, 2}, []int{1, 2, 0})
+		m.Store(0, 30)
+		check("Store()", []int{0, 1, 2}, []int{1, 2, 0})
+		m.UpdateRange(func(k, v int) (int, bool) { return v, true })
+		check("UpdateRange()", []int{0, 1, 2}, []int{1, 2, 0})
+		m.Compute(1, func(v int, ok bool) (int, compute.Op) { return v, compute.Keep })
+		check("Compute()", []int{0, 1, 2}, []int{1, 2, 0})
+		m.LoadOrStore(3, 3)
+		check("LoadOrStore()", []int{0, 1, 2, 3}, []int{1, 2, 0, 3})
+		m.Delete(0)
+		m.Store(0, 0)
+		check("Delete()", []int{1, 2, 3, 0}, []int{1, 2, 3, 0})
+
+		if key, value, ok := m.First(); !ok || key != 1 || value != 11 {
+			t.Errorf("First(): Expected 1=11, got %d=%d, %v", key, value, ok)
+		}
+		if key, value, ok := m.Last(); !ok || key != 0 || value != 0 {
+			t.Errorf("Last(): Expected 0=0, got %d=%d, %v", key, value, ok)
+		}
+		if key, value, ok := m.PopFirst(); !ok || key != 1 || value != 11 || m.Has(1) {
+			t.Errorf("PopFirst(): Expected 1=11 to be removed, got %d=%d, %v", key, value, ok)
+		}
+		if keys, values := m.Entries(); !slices.Equal(keys, []int{2, 3, 0}) || !slices.Equal(values, []int{20, 3, 0}) {
+			t.Errorf("Entries(): Expected [2 3 0] [20 3 0], got %v %v", keys, values)
+		}
+		if values := m.Values(); !slices.Equal(values, []int{20, 3, 0}) {
+			t.Errorf("Values(): Expected values [20 3 0], got %v", values)
+		}
+	}
+}
+
+func TestMapOperations(t *testing.T) {
+	m := ordered.New[string, int](false, nil)
+	if _, ok := m.Load("key"); ok {
+		t.Errorf("Load(): Expected key to be missing")
+	}
+	if v, loaded := m.LoadOrStore("key", 42); loaded || v != 42 {
+		t.Errorf("LoadOrStore(): Expected key to be stored")
+	}
+	if v, loaded := m.LoadOrStore("key", 0); !loaded || v != 42 {
+		t.Errorf("LoadOrStore(): Expected value 42 to be loaded, got %d", v)
+	}
+	if v, loaded := m.Swap("key", 43); !loaded || v != 42 {
+		t.Errorf("Swap(): Expected previous value 42, got %d", v)
+	}
+	if _, loaded := m.Swap("other", 1); loaded {
+		t.Errorf("Swap(): Expected key not to be loaded")
+	}
+	if m.CompareAndDelete("key", 42) {
+		t.Errorf("CompareAndDelete(): Expected key not to be deleted")
+	}
+	if !m.CompareAndDelete("key", 43) {
+		t.Errorf("CompareAndDelete(): Expected key to be deleted")
+	}
+	if m.CompareAndSwap("key", 43, 44) {
+		t.Errorf("CompareAndSwap(): Expected missing key not to be swapped")
+	}
+	m.Store("key", 1)
+	if v, loaded := m.LoadAndDelete("key"); !loaded || v != 1 {
+		t.Errorf("LoadAndDelete(): Expected value 1, got %d", v)
+	}
+	if _, loaded := m.LoadAndDelete("key"); loaded {
+		t.Errorf("LoadAndDelete(): Expected key to be missing")
+	}
+	m.Store("key", 1)
+	m.Delete("key")
+	if m.Has("key") {
+		t.Errorf("Has(): Expected key to be deleted")
+	}
+	m.Clear()
+	if m.Len() != 0 || len(m.Keys()) != 0 {
+		t.Errorf("Clear(): Expected empty map, got length %d", m.Len())
+	}
+	m.Store("key", 1)
+	if key, _, _ := m.First(); key != "key" || !m.Has("key") {
+		t.Errorf("Has(): Expected key to be present after Clear")
+	}
+
+	n := ordered.New[int, []int](false, nil)
+	n.Store(1, []int{1})
+	if n.CompareAndSwap(1, []int{1}, []int{2}) || n.CompareAndDelete(1, []int{1}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := n.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := n.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := ordered.New[int, []int](false, slices.Equal[[]int])
+	e.Store(1, []int{1})
+	if swapped, err := e.TryCompareAndSwap(1, []int{1}, []int{2}); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := e.TryCompareAndDelete(1, []int{2}); !deleted || err != nil || e.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+}
+
+func TestRangeAndUpdateRange(t *testing.T) {
+	m := ordered.New[int, int](false, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, 1)
+	}
+	count := 0
+	m.UpdateRange(func(k, v int) (int, bool) {
+		count++
+		return 2, count < 50
+	})
+	// the first 49 entries are updated.
+	var keys []int
+	sum := 0
+	m.Range(func(k, v int) bool {
+		keys = append(keys, k)
+		sum += v
+		return true
+	})
+	if sum != 149 {
+		t.Errorf("UpdateRange(): Expected sum 149, got %d", sum)
+	}
+	if v, _ := m.Load(48); v != 2 || !slices.IsSorted(keys) {
+		t.Errorf("Range(): Expected the entries in insertion order, got %v", keys)
+	}
+	count = 0
+	m.Range(func(k, v int) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Range(): Expected 1 iteration, got %d", count)
+	}
+}
+
+func TestExclusive(t *testing.T) {
+	m := ordered.New[int, int](false, nil)
+	for i := 0; i < 4; i++ {
+		m.Store(i, i)
+	}
+	m.Exclusive(func(data map[int]int) {
+		delete(data, 1)
+		data[2] = 20
+		data[4] = 4
+	})
+	if keys := m.Keys(); !slices.Equal(keys, []int{0, 2, 3, 4}) {
+		t.Errorf("Exclusive(): Expected existing keys to keep their position, got %v", keys)
+	}
+	if v, _ := m.Load(2); v != 20 {
+		t.Errorf("Exclusive(): Expected value 20, got %d", v)
+	}
+
+	// several new keys are inserted after the existing entries, in any relative order.
+	m.Exclusive(func(data map[int]int) {
+		delete(data, 0)
+		for i := 5; i < 10; i++ {
+			data[i] = i
+		}
+	})
+	if keys := m.Keys(); !slices.Equal(keys[:3], []int{2, 3, 4}) || !slices.Equal(slices.Sorted(slices.Values(keys[3:])), []int{5, 6, 7, 8, 9}) {
+		t.Errorf("Exclusive(): Expected the new keys after [2 3 4], got %v", keys)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	m := ordered.New[int, int](false, nil)
+	for _, i := range []int{2, 0, 1} {
+		m.Store(i, i*2)
+	}
+	if keys := slices.Collect(m.KeysSeq()); !slices.Equal(keys, []int{2, 0, 1}) {
+		t.Errorf("KeysSeq(): Expected keys [2 0 1], got %v", keys)
+	}
+	if values := slices.Collect(m.ValuesSeq()); !slices.Equal(values, []int{4, 0, 2}) {
+		t.Errorf("ValuesSeq(): Expected values [4 0 2], got %v", values)
+	}
+	for k, v := range m.All() {
+		if v != k*2 {
+			t.Errorf("All(): Expected value %d, got %d", k*2, v)
+		}
+	}
+	for range m.KeysSeq() {
+		break
+	}
+	for range m.ValuesSeq() {
+		break
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	m := ordered.New[string, int](false, nil)
+	for i := 9; i >= 0; i-- {
+		m.Store(string(rune('a'+i)), i)
+	}
+	m.DeleteFunc(func(k string, v int) bool { return v%2 == 0 })
+	// the remaining keys are kept in insertion order.
+	if keys := m.Keys(); !slices.Equal(keys, []string{"j", "h", "f", "d", "b"}) {
+		t.Errorf("DeleteFunc(): Expected keys [j h f d b], got %v", keys)
+	}
+}
+
+func TestUpdateRangeAtomic(t *testing.T) {
+	m := ordered.New[int, int](false, nil)
+	for i := 0; i < 10; i++ {
+		m.Store(i, 1)
+	}
+	m.UpdateRangeAtomic(func(k, v int) (int, bool) {
+		return v + k, true
+	})
+	if values := m.Values(); !slices.IsSorted(values) {
+		t.Errorf("UpdateRangeAtomic(): Expected the entries to keep their position, got %v", values)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	m := ordered.New[string, int](true, nil)
+	m.Store("a", 10)
+	m.Store("b", 0)
+	m.Store("c", 0)
+	err := m.Transaction(func(tx txn.Tx[string, int]) error {
+		if _, ok := tx.Load("d"); ok {
+			t.Errorf("Load(): Expected key d to be missing")
+		}
+		a, _ := tx.Load("a")
+		tx.Store("d", a)
+		tx.Store("a", 0)
+		tx.Delete("b")
+		tx.Delete("missing")
+		return nil
+	})
+	if keys, values := m.Entries(); err != nil || !slices.Equal(keys, []string{"c", "d", "a"}) || !slices.Equal(values, []int{0, 10, 0}) {
+		t.Errorf("Transaction(): Expected [c d a] [0 10 0], got %v %v, %v", keys, values, err)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	m := ordered.New[int, int](true, nil)
+	numGoroutines := 100
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			// uses context done to have all goroutines start at the same time
+			<-ctx.Done()
+			for j := 0; j < numGoroutines; j++ {
+				if _, ok := m.Load(j); !ok {
+					m.LoadOrStore(j, i*i)
+				}
+				m.Update(j, func(v int, ok bool) int { return v + 1 })
+				m.First()
+				m.Last()
+				if j%10 == 0 {
+					m.PopFirst()
+				}
+			}
+		}(i)
+	}
+	cancel()
+	wg.Wait()
+	keys := m.Keys()
+	if len(keys) != m.Len() {
+		t.Errorf("Keys(): Expected %d keys, got %v", m.Len(), keys)
+	}
+}
@@ -0,0 +1,214 @@
+package ordered
+
+import "github.com/thetechpanda/typedmap/internal/txn"
+
+// Clear removes all items from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = make(map[K]*node[K, V])
+	m.root.next = &m.root
+	m.root.prev = &m.root
+}
+
+// Has returns true if the map contains the key.
+func (m *TypedMap[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+// A new key is inserted as the last entry.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var v V
+	n, ok := m.data[key]
+	if ok {
+		v = n.value
+	}
+	m.set(key, f(v, ok))
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+// The entries keep their position, even if the map moves the stored entries to the back.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for n := m.root.next; n != &m.root; n = n.next {
+		newValue, ok := f(n.key, n.value)
+		if !ok {
+			return
+		}
+		n.value = newValue
+	}
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated. The entries keep their position.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// values holds the new values in insertion order.
+	values := make([]V, 0, len(m.data))
+	for n := m.root.next; n != &m.root; n = n.next {
+		newValue, ok := f(n.key, n.value)
+		if !ok {
+			return false
+		}
+		values = append(values, newValue)
+	}
+	n := m.root.next
+	for _, value := range values {
+		n.value = value
+		n = n.next
+	}
+	return true
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+//
+// Once f returns the changes are applied to the map: entries that are still present keep their position,
+// missing entries are removed and the new entries are inserted after the last entry.
+// Since f receives a Go map only the relative order of the new entries is unspecified,
+// use Transaction to insert the new keys in the order of their last write.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := make(map[K]V, len(m.data))
+	for key, n := range m.data {
+		data[key] = n.value
+	}
+	f(data)
+	for key, n := range m.data {
+		if value, ok := data[key]; ok {
+			n.value = value
+			delete(data, key)
+			continue
+		}
+		m.remove(n)
+	}
+	for key, value := range data {
+		n := &node[K, V]{key: key, value: value}
+		m.data[key] = n
+		m.pushBack(n)
+	}
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics.
+//
+// Removals are applied first, then the new keys are inserted in the order of their last write,
+// a key deleted then stored again by the transaction is inserted as the last entry.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of the map, the returned txn.Locked gives access to its content until it is unlocked.
+func (m *TypedMap[K, V]) TxLock() txn.Locked[K, V] {
+	m.mu.Lock()
+	return txn.Locked[K, V]{
+		Load: func(key K) (v V, ok bool) {
+			n, ok := m.data[key]
+			if !ok {
+				return v, false
+			}
+			return n.value, true
+		},
+		Store: m.set,
+		Remove: func(key K) {
+			if n, ok := m.data[key]; ok {
+				m.remove(n)
+			}
+		},
+		Unlock: m.mu.Unlock,
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The map is locked for the duration of the iteration, so the entries are removed atomically.
+// The entries are visited in insertion order.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for e := m.root.next; e != &m.root; {
+		next := e.next
+		if f(e.key, e.value) {
+			m.remove(e)
+			n++
+		}
+		e = next
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map.
+func (m *TypedMap[K, V]) Len() (n int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.data)
+}
+
+// Keys returns a slice of all the keys present in the map, in insertion order.
+// An empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Keys() (keys []K) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, len(m.data))
+	for n := m.root.next; n != &m.root; n = n.next {
+		keys = append(keys, n.key)
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, in insertion order.
+// An empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Values() (values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values = make([]V, 0, len(m.data))
+	for n := m.root.next; n != &m.root; n = n.next {
+		values = append(values, n.value)
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map,
+// in insertion order.
+func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, len(m.data))
+	values = make([]V, 0, len(m.data))
+	for n := m.root.next; n != &m.root; n = n.next {
+		keys = append(keys, n.key)
+		values = append(values, n.value)
+	}
+	return keys, values
+}
@@ -0,0 +1,45 @@
+package ranked
+
+import (
+	"cmp"
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// All returns an iterator over the key-value pairs in the map, it behaves as Range does, in ascending order of score.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V, S]) All() iter.Seq2[K, V] {
+	return derive.All(m.Range)
+}
+
+// KeysSeq returns an iterator over the keys in the map, it behaves as Range does, in ascending order of score.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V, S]) KeysSeq() iter.Seq[K] {
+	return derive.Keys(m.Range)
+}
+
+// ValuesSeq returns an iterator over the values in the map, it behaves as Range does, in ascending order of score.
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V, S]) ValuesSeq() iter.Seq[V] {
+	return derive.Values(m.Range)
+}
+
+// ScoreRange returns an iterator over the key-value pairs whose score is greater than or equal to lo and less than hi,
+// in ascending order of score. Finding the first entry is O(log N).
+//
+// Avoid invoking any map functions within the loop body to prevent a deadlock.
+func (m *TypedMap[K, V, S]) ScoreRange(lo, hi S) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		for n := m.ceiling(lo); n != nil && cmp.Less(n.score, hi); n = n.next[0].to {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package ranked
+
+import "github.com/thetechpanda/typedmap/internal/derive"
+
+// Store sets the value for a key.
+func (m *TypedMap[K, V, S]) Store(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(key, value)
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.
+// The ok result indicates whether value was found in the map.
+func (m *TypedMap[K, V, S]) Load(key K) (v V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, ok := m.data[key]
+	if !ok {
+		return v, false
+	}
+	return n.value, true
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *TypedMap[K, V, S]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n, ok := m.data[key]; ok {
+		return n.value, true
+	}
+	m.set(key, value)
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V, S]) LoadAndDelete(key K) (value V, loaded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, loaded := m.data[key]
+	if !loaded {
+		return value, false
+	}
+	m.remove(n)
+	return n.value, true
+}
+
+// Delete removes the key from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V, S]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V, S]) Swap(key K, value V) (previous V, loaded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n, ok := m.data[key]; ok {
+		previous = n.value
+		m.replace(n, value)
+		return previous, true
+	}
+	m.set(key, value)
+	return previous, false
+}
+
+// CompareAndSwap swaps the old and new values for key
+// if the value stored in the map is equal to old.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+//
+// Returns true if the swap was performed.
+func (m *TypedMap[K, V, S]) CompareAndSwap(key K, old, new V) bool {
+	if !m.ValuesComparable() {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.data[key]
+	if !ok || !m.ValuesEqual(n.value, old) {
+		return false
+	}
+	m.replace(n, new)
+	return true
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
+//
+// If there is no current value for key in the map, CompareAndDelete
+// returns false (even if the old value is the nil interface value).
+func (m *TypedMap[K, V, S]) CompareAndDelete(key K, old V) (deleted bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.data[key]
+	if !ok || !m.ValuesEqual(n.value, old) {
+		return false
+	}
+	m.remove(n)
+	return true
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V, S]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V, S]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// Range calls f sequentially for each key and value present in the map, in ascending order of score.
+// If f returns false, Range stops the iteration.
+// Avoid invoking any map functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) Range(f func(K, V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for n := m.head.next[0].to; n != nil; n = n.next[0].to {
+		if !f(n.key, n.value) {
+			break
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package ranked
+
+import (
+	"cmp"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// maxLevel is the maximum number of levels of the skip list, enough for 4^32 entries.
+const maxLevel = 32
+
+// link points to the following node at a level of the skip list, span is the number of nodes it skips at the lowest level, plus one.
+type link[K comparable, V any, S cmp.Ordered] struct {
+	to   *node[K, V, S]
+	span int
+}
+
+// node is an entry of the map, linked in the skip list in ascending order of score.
+// Entries with the same score are ordered by descending seq, so that the entry stored first comes last.
+// prev is the preceding node at the lowest level, the head of the list for the first node.
+type node[K comparable, V any, S cmp.Ordered] struct {
+	key   K
+	value V
+	score S
+	seq   uint64
+	prev  *node[K, V, S]
+	next  []link[K, V, S]
+}
+
+// TypedMap implements a thread-safe map ordered by the score of its values: the entries are held in an indexable skip list,
+// so that insertions, removals and rank queries are O(log N), and in a map from the keys to the nodes, so that lookups are O(1).
+type TypedMap[K comparable, V any, S cmp.Ordered] struct {
+	mu sync.RWMutex
+	equality.Comparer[V]
+	txn.ID
+	// score returns the score of a value, it is computed once when the value is stored.
+	score func(V) S
+	data  map[K]*node[K, V, S]
+	// head is the sentinel of the skip list, its next has maxLevel levels.
+	head node[K, V, S]
+	// tail is the last node, the head if the map is empty.
+	tail *node[K, V, S]
+	// level is the number of levels in use.
+	level int
+	n     int
+	// seq is the sequence number of the last stored score.
+	seq uint64
+}
+
+// New returns a new TypedMap whose entries are ordered using score, entries with the same score are ordered by the time their score was stored.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any, S cmp.Ordered](score func(V) S, equal equality.Func[V]) *TypedMap[K, V, S] {
+	m := &TypedMap[K, V, S]{
+		Comparer: equality.NewComparer(equal, equality.Deep[V]),
+		ID:       txn.NewID(),
+		score:    score,
+	}
+	m.reset()
+	return m
+}
+
+// reset empties the map, the caller must hold the lock.
+func (m *TypedMap[K, V, S]) reset() {
+	m.data = make(map[K]*node[K, V, S])
+	m.head.next = make([]link[K, V, S], maxLevel)
+	m.tail = &m.head
+	m.level = 1
+	m.n = 0
+}
+
+// randomLevel returns the number of levels of a new node, each level being kept with probability 1/4.
+func randomLevel() int {
+	return min(1+bits.TrailingZeros64(rand.Uint64())/2, maxLevel)
+}
+
+// before reports whether n precedes the position of score and seq in the skip list.
+func before[K comparable, V any, S cmp.Ordered](n *node[K, V, S], score S, seq uint64) bool {
+	c := cmp.Compare(n.score, score)
+	return c < 0 || c == 0 && n.seq > seq
+}
+
+// search returns, for each level in use, the last node preceding the position of score and seq,
+// and the position of that node at the lowest level, 0 for the head.
+func (m *TypedMap[K, V, S]) search(score S, seq uint64) (update [maxLevel]*node[K, V, S], rank [maxLevel]int) {
+	x := &m.head
+	r := 0
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i].to != nil && before(x.next[i].to, score, seq) {
+			r += x.next[i].span
+			x = x.next[i].to
+		}
+		update[i], rank[i] = x, r
+	}
+	return update, rank
+}
+
+// link inserts n at the position of its score and seq, n.next must hold its levels. The caller must hold the lock.
+func (m *TypedMap[K, V, S]) link(n *node[K, V, S]) {
+	update, rank := m.search(n.score, n.seq)
+	for ; m.level < len(n.next); m.level++ {
+		update[m.level] = &m.head
+		m.head.next[m.level].span = m.n
+	}
+	for i := range n.next {
+		n.next[i].to = update[i].next[i].to
+		update[i].next[i].to = n
+		n.next[i].span = update[i].next[i].span - (rank[0] - rank[i])
+		update[i].next[i].span = rank[0] - rank[i] + 1
+	}
+	for i := len(n.next); i < m.level; i++ {
+		update[i].next[i].span++
+	}
+	n.prev = update[0]
+	if next := n.next[0].to; next != nil {
+		next.prev = n
+	} else {
+		m.tail = n
+	}
+	m.n++
+}
+
+// unlink removes n from the skip list, the caller must hold the lock.
+func (m *TypedMap[K, V, S]) unlink(n *node[K, V, S]) {
+	update, _ := m.search(n.score, n.seq)
+	for i := 0; i < m.level; i++ {
+		if l := &update[i].next[i]; l.to == n {
+			l.to = n.next[i].to
+			l.span += n.next[i].span - 1
+		} else {
+			l.span--
+		}
+	}
+	if next := n.next[0].to; next != nil {
+		next.prev = n.prev
+	} else {
+		m.tail = n.prev
+	}
+	for m.level > 1 && m.head.next[m.level-1].to == nil {
+		m.level--
+	}
+	m.n--
+}
+
+// set stores value for key, the caller must hold the lock.
+// An entry whose score changes is moved to the position of its new score, as if it was stored for the first time.
+func (m *TypedMap[K, V, S]) set(key K, value V) {
+	if n, ok := m.data[key]; ok {
+		m.replace(n, value)
+		return
+	}
+	m.seq++
+	n := &node[K, V, S]{key: key, value: value, score: m.score(value), seq: m.seq, next: make([]link[K, V, S], randomLevel())}
+	m.link(n)
+	m.data[key] = n
+}
+
+// replace stores value in n, moving n if its score changes. The caller must hold the lock.
+func (m *TypedMap[K, V, S]) replace(n *node[K, V, S], value V) {
+	n.value = value
+	score := m.score(value)
+	if cmp.Compare(score, n.score) == 0 {
+		return
+	}
+	m.unlink(n)
+	m.seq++
+	n.score, n.seq = score, m.seq
+	m.link(n)
+}
+
+// remove deletes n from the map, the caller must hold the lock.
+func (m *TypedMap[K, V, S]) remove(n *node[K, V, S]) {
+	m.unlink(n)
+	delete(m.data, n.key)
+}
+
+// ceiling returns the first node whose score is greater than or equal to score, nil if none.
+func (m *TypedMap[K, V, S]) ceiling(score S) *node[K, V, S] {
+	x := &m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i].to != nil && cmp.Less(x.next[i].to.score, score) {
+			x = x.next[i].to
+		}
+	}
+	return x.next[0].to
+}
+
+// TopN returns the n entries with the highest scores, in descending order of score, the entries with the same score in the order they were stored.
+// It returns fewer entries if the map holds less than n entries, and is O(n).
+func (m *TypedMap[K, V, S]) TopN(n int) (keys []K, values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n = max(min(n, m.n), 0)
+	keys, values = make([]K, 0, n), make([]V, 0, n)
+	for x := m.tail; len(keys) < n; x = x.prev {
+		keys = append(keys, x.key)
+		values = append(values, x.value)
+	}
+	return keys, values
+}
+
+// BottomN returns the n entries with the lowest scores, in ascending order of score, the reverse of the order of TopN.
+// It returns fewer entries if the map holds less than n entries, and is O(n).
+func (m *TypedMap[K, V, S]) BottomN(n int) (keys []K, values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n = max(min(n, m.n), 0)
+	keys, values = make([]K, 0, n), make([]V, 0, n)
+	for x := m.head.next[0].to; len(keys) < n; x = x.next[0].to {
+		keys = append(keys, x.key)
+		values = append(values, x.value)
+	}
+	return keys, values
+}
+
+// Rank returns the position of key in the order of TopN, 0 for the entry with the highest score,
+// the ok result reports whether key is present. It is O(log N).
+func (m *TypedMap[K, V, S]) Rank(key K) (rank int, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, ok := m.data[key]
+	if !ok {
+		return 0, false
+	}
+	// the position of the node preceding n, plus one, is the position of n in ascending order, from 1.
+	_, r := m.search(n.score, n.seq)
+	return m.n - r[0] - 1, true
+}
+
+// Score returns the score of the value stored for key, the ok result reports whether key is present.
+func (m *TypedMap[K, V, S]) Score(key K) (score S, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, ok := m.data[key]
+	if !ok {
+		return score, false
+	}
+	return n.score, true
+}
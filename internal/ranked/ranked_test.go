@@ -0,0 +1,388 @@
+package ranked_test
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/ranked"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// identity scores the values by themselves.
+func identity(v int) int {
+	return v
+}
+
+func TestNew(t *testing.T) {
+	m := ranked.New[string](identity, nil)
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected a new map, got map with length %d", m.Len())
+	}
+	if keys, values := m.TopN(3); len(keys) != 0 || len(values) != 0 {
+		t.Errorf("TopN(): Expected an empty map, got %v %v", keys, values)
+	}
+	if keys, values := m.BottomN(3); len(keys) != 0 || len(values) != 0 {
+		t.Errorf("BottomN(): Expected an empty map, got %v %v", keys, values)
+	}
+	if _, ok := m.Rank("a"); ok {
+		t.Errorf("Rank(): Expected a missing key")
+	}
+	if _, ok := m.Score("a"); ok {
+		t.Errorf("Score(): Expected a missing key")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	m := ranked.New[string](identity, nil)
+	m.Store("a", 3)
+	m.Store("b", 1)
+	m.Store("c", 2)
+	m.Store("d", 2)
+	// check expects the keys in ascending order of score, the keys with the same score stored last first.
+	check := func(op string, expect []string) {
+		t.Helper()
+		if keys := m.Keys(); !slices.Equal(keys, expect) {
+			t.Errorf("%s: Expected keys %v, got %v", op, expect, keys)
+		}
+		top, _ := m.TopN(len(expect))
+		if slices.Reverse(top); !slices.Equal(top, expect) {
+			t.Errorf("%s: Expected TopN to return the keys in reverse order, got %v", op, top)
+		}
+		for i, key := range expect {
+			if rank, ok := m.Rank(key); !ok || rank != len(expect)-1-i {
+				t.Errorf("%s: Expected rank %d for %s, got %d", op, len(expect)-1-i, key, rank)
+			}
+		}
+	}
+	check("Store()", []string{"b", "d", "c", "a"})
+	m.Store("c", 2)
+	check("Store() with the same score", []string{"b", "d", "c", "a"})
+	m.Store("b", 2)
+	check("Store() with a new score", []string{"b", "d", "c", "a"})
+	m.Store("c", 0)
+	check("Store() with a new score", []string{"c", "b", "d", "a"})
+	m.Swap("a", -1)
+	check("Swap()", []string{"a", "c", "b", "d"})
+	m.Update("e", func(v int, ok bool) int { return 2 })
+	check("Update()", []string{"a", "c", "e", "b", "d"})
+	m.Delete("b")
+	check("Delete()", []string{"a", "c", "e", "d"})
+
+	if keys, values := m.TopN(2); !slices.Equal(keys, []string{"d", "e"}) || !slices.Equal(values, []int{2, 2}) {
+		t.Errorf("TopN(): Expected [d e] [2 2], got %v %v", keys, values)
+	}
+	if keys, values := m.BottomN(3); !slices.Equal(keys, []string{"a", "c", "e"}) || !slices.Equal(values, []int{-1, 0, 2}) {
+		t.Errorf("BottomN(): Expected [a c e] [-1 0 2], got %v %v", keys, values)
+	}
+	if keys, _ := m.TopN(-1); len(keys) != 0 {
+		t.Errorf("TopN(): Expected no entries, got %v", keys)
+	}
+	if score, ok := m.Score("a"); !ok || score != -1 {
+		t.Errorf("Score(): Expected -1, got %d, %v", score, ok)
+	}
+}
+
+func TestRank(t *testing.T) {
+	m := ranked.New[int](identity, nil)
+	expect := make(map[int]int)
+	// random writes grow and shrink the skip list, the ranks must still match the order of the entries.
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(500)
+		switch r.Intn(4) {
+		case 0:
+			m.Delete(key)
+			delete(expect, key)
+		default:
+			value := r.Intn(50)
+			m.Store(key, value)
+			expect[key] = value
+		}
+	}
+	keys, values := m.Entries()
+	if len(keys) != len(expect) || m.Len() != len(expect) {
+		t.Fatalf("Entries(): Expected %d entries, got %d", len(expect), len(keys))
+	}
+	if !slices.IsSorted(values) {
+		t.Errorf("Entries(): Expected the values to be sorted, got %v", values)
+	}
+	for i, key := range keys {
+		if values[i] != expect[key] {
+			t.Errorf("Entries(): Expected %d for %d, got %d", expect[key], key, values[i])
+		}
+		if rank, ok := m.Rank(key); !ok || rank != len(keys)-1-i {
+			t.Errorf("Rank(): Expected %d for %d, got %d", len(keys)-1-i, key, rank)
+		}
+	}
+	m.Clear()
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	for i := 0; i < 100; i++ {
+		if rank, _ := m.Rank(i); rank != 99-i {
+			t.Errorf("Rank(): Expected %d for %d after Clear, got %d", 99-i, i, rank)
+		}
+	}
+}
+
+func TestScoreRange(t *testing.T) {
+	type player struct {
+		name  string
+		score float64
+	}
+	m := ranked.New[string](func(p player) float64 { return p.score }, nil)
+	for i := 0; i < 10; i++ {
+		name := strconv.Itoa(i)
+		m.Store(name, player{name, float64(i) / 2})
+	}
+	var keys []string
+	for key, p := range m.ScoreRange(1, 3) {
+		if p.name != key {
+			t.Errorf("ScoreRange(): Expected player %s, got %s", key, p.name)
+		}
+		keys = append(keys, key)
+	}
+	if !slices.Equal(keys, []string{"2", "3", "4", "5"}) {
+		t.Errorf("ScoreRange(): Expected [2 3 4 5], got %v", keys)
+	}
+	for range m.ScoreRange(0, 10) {
+		break
+	}
+	for range m.ScoreRange(10, 20) {
+		t.Errorf("ScoreRange(): Expected no entries")
+	}
+}
+
+func TestMapOperations(t *testing.T) {
+	m := ranked.New[string](identity, nil)
+	if _, ok := m.Load("key"); ok {
+		t.Errorf("Load(): Expected key to be missing")
+	}
+	if v, loaded := m.LoadOrStore("key", 42); loaded || v != 42 {
+		t.Errorf("LoadOrStore(): Expected key to be stored")
+	}
+	if v, loaded := m.LoadOrStore("key", 0); !loaded || v != 42 {
+		t.Errorf("LoadOrStore(): Expected value 42 to be loaded, got %d", v)
+	}
+	if v, loaded := m.Swap("key", 43); !loaded || v != 42 {
+		t.Errorf("Swap(): Expected previous value 42, got %d", v)
+	}
+	if _, loaded := m.Swap("other", 1); loaded {
+		t.Errorf("Swap(): Expected key not to be loaded")
+	}
+	if m.CompareAndDelete("key", 42) {
+		t.Errorf("CompareAndDelete(): Expected key not to be deleted")
+	}
+	if !m.CompareAndDelete("key", 43) {
+		t.Errorf("CompareAndDelete(): Expected key to be deleted")
+	}
+	if m.CompareAndSwap("key", 43, 44) {
+		t.Errorf("CompareAndSwap(): Expected missing key not to be swapped")
+	}
+	if !m.CompareAndSwap("other", 1, 2) {
+		t.Errorf("CompareAndSwap(): Expected key to be swapped")
+	}
+	m.Store("key", 1)
+	if v, loaded := m.LoadAndDelete("key"); !loaded || v != 1 {
+		t.Errorf("LoadAndDelete(): Expected value 1, got %d", v)
+	}
+	if _, loaded := m.LoadAndDelete("key"); loaded {
+		t.Errorf("LoadAndDelete(): Expected key to be missing")
+	}
+	m.Store("key", 1)
+	m.Delete("key")
+	if m.Has("key") {
+		t.Errorf("Has(): Expected key to be deleted")
+	}
+	m.Clear()
+	if m.Len() != 0 || len(m.Keys()) != 0 {
+		t.Errorf("Clear(): Expected empty map, got length %d", m.Len())
+	}
+	m.Store("key", 1)
+	if !m.Has("key") {
+		t.Errorf("Has(): Expected key to be present after Clear")
+	}
+
+	n := ranked.New[int](func(v []int) int { return len(v) }, nil)
+	n.Store(1, []int{1})
+	if n.CompareAndSwap(1, []int{1}, []int{2}) || n.CompareAndDelete(1, []int{1}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := n.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := n.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := ranked.New[int](func(v []int) int { return len(v) }, slices.Equal[[]int])
+	e.Store(1, []int{1})
+	if swapped, err := e.TryCompareAndSwap(1, []int{1}, []int{2}); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := e.TryCompareAndDelete(1, []int{2}); !deleted || err != nil || e.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+}
+
+func TestRangeAndUpdateRange(t *testing.T) {
+	m := ranked.New[int](identity, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	count := 0
+	m.UpdateRange(func(k, v int) (int, bool) {
+		count++
+		return v + 100, count < 50
+	})
+	// the 49 lowest values are updated and moved to the top.
+	if top, _ := m.TopN(1); top[0] != 48 {
+		t.Errorf("UpdateRange(): Expected 48 to be moved to the top, got %v", top)
+	}
+	if bottom, _ := m.BottomN(1); bottom[0] != 49 {
+		t.Errorf("UpdateRange(): Expected 49 to be at the bottom, got %v", bottom)
+	}
+	count = 0
+	m.Range(func(k, v int) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Range(): Expected 1 iteration, got %d", count)
+	}
+	m.Update(0, func(v int, ok bool) int { return v + 1 })
+	m.Update(100, func(v int, ok bool) int { return v + 1 })
+	if a, _ := m.Load(0); a != 101 || m.Len() != 101 {
+		t.Errorf("Update(): Expected value 101 and length 101, got %d and %d", a, m.Len())
+	}
+	if keys := m.Keys(); !slices.IsSortedFunc(keys, func(a, b int) int {
+		va, _ := m.Load(a)
+		vb, _ := m.Load(b)
+		return cmp.Compare(va, vb)
+	}) {
+		t.Errorf("Keys(): Expected the keys in ascending order of value, got %v", keys)
+	}
+}
+
+func TestExclusive(t *testing.T) {
+	m := ranked.New[int](identity, nil)
+	for i := 0; i < 4; i++ {
+		m.Store(i, i)
+	}
+	m.Exclusive(func(data map[int]int) {
+		delete(data, 1)
+		data[2] = 20
+		data[-1] = -1
+	})
+	if keys, values := m.Entries(); !slices.Equal(keys, []int{-1, 0, 3, 2}) || !slices.Equal(values, []int{-1, 0, 3, 20}) {
+		t.Errorf("Exclusive(): Expected [-1 0 3 2] [-1 0 3 20], got %v %v", keys, values)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	m := ranked.New[int](identity, nil)
+	for _, i := range []int{2, 0, 1} {
+		m.Store(i, -i)
+	}
+	if keys := slices.Collect(m.KeysSeq()); !slices.Equal(keys, []int{2, 1, 0}) {
+		t.Errorf("KeysSeq(): Expected keys [2 1 0], got %v", keys)
+	}
+	if values := slices.Collect(m.ValuesSeq()); !slices.Equal(values, []int{-2, -1, 0}) {
+		t.Errorf("ValuesSeq(): Expected values [-2 -1 0], got %v", values)
+	}
+	for k, v := range m.All() {
+		if v != -k {
+			t.Errorf("All(): Expected value %d, got %d", -k, v)
+		}
+	}
+	for range m.KeysSeq() {
+		break
+	}
+	for range m.ValuesSeq() {
+		break
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	m := ranked.New[string](identity, nil)
+	for i := 9; i >= 0; i-- {
+		m.Store(string(rune('a'+i)), i)
+	}
+	m.DeleteFunc(func(k string, v int) bool { return v%2 == 0 })
+	// the remaining keys are still ranked.
+	if keys := m.Keys(); !slices.Equal(keys, []string{"b", "d", "f", "h", "j"}) {
+		t.Errorf("DeleteFunc(): Expected keys [b d f h j], got %v", keys)
+	}
+}
+
+func TestUpdateRangeAtomic(t *testing.T) {
+	m := ranked.New[int](identity, nil)
+	for i := 0; i < 10; i++ {
+		m.Store(i, 1)
+	}
+	m.UpdateRangeAtomic(func(k, v int) (int, bool) {
+		return v + k, true
+	})
+	if top, _ := m.TopN(3); !slices.Equal(top, []int{9, 8, 7}) {
+		t.Errorf("UpdateRangeAtomic(): Expected the entries to be moved, got top %v", top)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	m := ranked.New[string](identity, nil)
+	m.Store("a", 10)
+	m.Store("b", 0)
+	err := m.Transaction(func(tx txn.Tx[string, int]) error {
+		if _, ok := tx.Load("c"); ok {
+			t.Errorf("Load(): Expected key c to be missing")
+		}
+		a, _ := tx.Load("a")
+		tx.Store("c", a)
+		tx.Delete("a")
+		tx.Delete("missing")
+		return nil
+	})
+	if keys, values := m.Entries(); err != nil || !slices.Equal(keys, []string{"b", "c"}) || !slices.Equal(values, []int{0, 10}) {
+		t.Errorf("Transaction(): Expected [b c] [0 10], got %v %v, %v", keys, values, err)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	m := ranked.New[int](identity, nil)
+	numGoroutines := 100
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			// uses context done to have all goroutines start at the same time
+			<-ctx.Done()
+			for j := 0; j < numGoroutines; j++ {
+				if _, ok := m.Load(j); !ok {
+					m.LoadOrStore(j, i*i)
+				}
+				m.Update(j, func(v int, ok bool) int { return v + 1 })
+				m.Rank(j)
+				m.TopN(10)
+				for range m.ScoreRange(j, j+10) {
+				}
+				if j%10 == 0 {
+					m.Delete(j)
+				}
+			}
+		}(i)
+	}
+	cancel()
+	wg.Wait()
+	if values := m.Values(); len(values) != m.Len() || !slices.IsSorted(values) {
+		t.Errorf("Values(): Expected %d sorted values, got %v", m.Len(), values)
+	}
+}
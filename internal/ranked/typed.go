@@ -0,0 +1,216 @@
+package ranked
+
+import (
+	"cmp"
+
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Clear removes all items from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V, S]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reset()
+}
+
+// Has returns true if the map contains the key.
+func (m *TypedMap[K, V, S]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) Update(key K, f func(V, bool) V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n, ok := m.data[key]; ok {
+		m.replace(n, f(n.value, true))
+		return
+	}
+	var v V
+	m.set(key, f(v, false))
+}
+
+// pending is a value returned by the function of UpdateRange, applied once the iteration is over.
+type pending[K comparable, V any, S cmp.Ordered] struct {
+	n     *node[K, V, S]
+	value V
+}
+
+// updateRange calls f for each entry in ascending order of score, until f returns false,
+// and returns the new values along with whether f returned true for every entry. The caller must hold the lock.
+// The values are not applied during the iteration, as a change of score would move the entry.
+func (m *TypedMap[K, V, S]) updateRange(f func(K, V) (V, bool)) (values []pending[K, V, S], all bool) {
+	values = make([]pending[K, V, S], 0, m.n)
+	for n := m.head.next[0].to; n != nil; n = n.next[0].to {
+		newValue, ok := f(n.key, n.value)
+		if !ok {
+			return values, false
+		}
+		values = append(values, pending[K, V, S]{n, newValue})
+	}
+	return values, true
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+// The entries are visited in ascending order of score, the new values are applied, and the entries moved, once the iteration is over.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) UpdateRange(f func(K, V) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values, _ := m.updateRange(f)
+	for _, p := range values {
+		m.replace(p.n, p.value)
+	}
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values, all := m.updateRange(f)
+	if !all {
+		return false
+	}
+	for _, p := range values {
+		m.replace(p.n, p.value)
+	}
+	return true
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+//
+// f receives a copy of the map. Once f returns the changes are applied to the map.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) Exclusive(f func(m map[K]V)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := make(map[K]V, m.n)
+	for key, n := range m.data {
+		data[key] = n.value
+	}
+	f(data)
+	for key, n := range m.data {
+		if value, ok := data[key]; ok {
+			m.replace(n, value)
+			delete(data, key)
+			continue
+		}
+		m.remove(n)
+	}
+	for key, value := range data {
+		m.set(key, value)
+	}
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of the map, the returned txn.Locked gives access to its content until it is unlocked.
+func (m *TypedMap[K, V, S]) TxLock() txn.Locked[K, V] {
+	m.mu.Lock()
+	return txn.Locked[K, V]{
+		Load: func(key K) (v V, ok bool) {
+			n, ok := m.data[key]
+			if !ok {
+				return v, false
+			}
+			return n.value, true
+		},
+		Store: m.set,
+		Remove: func(key K) {
+			if n, ok := m.data[key]; ok {
+				m.remove(n)
+			}
+		},
+		Unlock: m.mu.Unlock,
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The map is locked for the duration of the iteration, so the entries are removed atomically.
+// The entries are visited in ascending order of score.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) DeleteFunc(f func(K, V) bool) (n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for e := m.head.next[0].to; e != nil; e = e.next[0].to {
+		if f(e.key, e.value) {
+			m.remove(e)
+			n++
+		}
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V, S]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map.
+func (m *TypedMap[K, V, S]) Len() (n int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.n
+}
+
+// Keys returns a slice of all the keys present in the map, in ascending order of score.
+// An empty slice is returned if the map is empty.
+func (m *TypedMap[K, V, S]) Keys() (keys []K) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, m.n)
+	for n := m.head.next[0].to; n != nil; n = n.next[0].to {
+		keys = append(keys, n.key)
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, in ascending order of score.
+// An empty slice is returned if the map is empty.
+func (m *TypedMap[K, V, S]) Values() (values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values = make([]V, 0, m.n)
+	for n := m.head.next[0].to; n != nil; n = n.next[0].to {
+		values = append(values, n.value)
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map,
+// in ascending order of score.
+func (m *TypedMap[K, V, S]) Entries() (keys []K, values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, m.n)
+	values = make([]V, 0, m.n)
+	for n := m.head.next[0].to; n != nil; n = n.next[0].to {
+		keys = append(keys, n.key)
+		values = append(values, n.value)
+	}
+	return keys, values
+}
@@ -0,0 +1,97 @@
+// Package removal provides the types used to notify the removal of entries from a map.
+package removal
+
+import "reflect"
+
+// Reason describes why an entry has been removed from a map.
+type Reason int
+
+const (
+	// Deleted means the entry has been removed by the caller, eg. using Delete, LoadAndDelete, CompareAndDelete or Exclusive.
+	Deleted Reason = iota
+	// Replaced means the value of the entry has been replaced by a different value.
+	Replaced
+	// Expired means the entry has been removed because its time to live has elapsed.
+	Expired
+	// Capacity means the entry has been evicted to make room for another entry.
+	Capacity
+	// Cleared means the entry has been removed by Clear.
+	Cleared
+)
+
+// String returns the name of the reason.
+func (r Reason) String() string {
+	switch r {
+	case Deleted:
+		return "Deleted"
+	case Replaced:
+		return "Replaced"
+	case Expired:
+		return "Expired"
+	case Capacity:
+		return "Capacity"
+	case Cleared:
+		return "Cleared"
+	}
+	return "unknown"
+}
+
+// Hook is called for each entry removed from a map.
+type Hook[K, V any] func(key K, value V, reason Reason)
+
+// entry is a removed entry waiting to be notified.
+type entry[K, V any] struct {
+	key    K
+	value  V
+	reason Reason
+}
+
+// Batch collects the entries removed while the map lock is held, so that the hook can be called once the lock is released.
+// A Batch with a nil hook does nothing.
+//
+// The usual pattern is to defer Notify before acquiring the lock, so that it runs after the lock has been released:
+//
+//	b := removal.NewBatch(m.onRemove)
+//	defer b.Notify()
+//	m.mu.Lock()
+//	defer m.mu.Unlock()
+type Batch[K, V any] struct {
+	hook    Hook[K, V]
+	entries []entry[K, V]
+}
+
+// NewBatch returns a new Batch notifying hook.
+func NewBatch[K, V any](hook Hook[K, V]) Batch[K, V] {
+	return Batch[K, V]{hook: hook}
+}
+
+// Add records the removal of an entry.
+func (b *Batch[K, V]) Add(key K, value V, reason Reason) {
+	if b.hook == nil {
+		return
+	}
+	b.entries = append(b.entries, entry[K, V]{key: key, value: value, reason: reason})
+}
+
+// Replace records the replacement of old with new, unless new is the same value as old.
+func (b *Batch[K, V]) Replace(key K, old, new V) {
+	if b.hook == nil || Same(old, new) {
+		return
+	}
+	b.entries = append(b.entries, entry[K, V]{key: key, value: old, reason: Replaced})
+}
+
+// Notify calls the hook for each recorded entry, in the order they were recorded.
+func (b *Batch[K, V]) Notify() {
+	for _, e := range b.entries {
+		b.hook(e.key, e.value, e.reason)
+	}
+	b.entries = nil
+}
+
+// Same reports whether a and b are the same value: values of comparable types are compared using ==,
+// pointers by identity. Values of non comparable types are never the same.
+func Same[V any](a, b V) bool {
+	va, vb := reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem()
+	return va.Comparable() && vb.Comparable() && va.Equal(vb)
+}
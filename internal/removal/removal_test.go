@@ -0,0 +1,68 @@
+package removal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/removal"
+)
+
+func TestSame(t *testing.T) {
+	a, b := 1, 1
+	var nilAny any
+	cases := []struct {
+		name string
+		same bool
+		got  bool
+	}{
+		{"int", true, removal.Same(1, 1)},
+		{"int", false, removal.Same(1, 2)},
+		{"pointer", true, removal.Same(&a, &a)},
+		{"pointer", false, removal.Same(&a, &b)},
+		{"slice", false, removal.Same([]int{1}, []int{1})},
+		{"any", true, removal.Same[any](1, 1)},
+		{"any", false, removal.Same[any](1, "1")},
+		{"any", false, removal.Same[any](1, nilAny)},
+		{"any", true, removal.Same[any](nilAny, nilAny)},
+		{"any", false, removal.Same[any]([]int{1}, []int{1})},
+		{"struct", false, removal.Same(struct{ A any }{[]int{1}}, struct{ A any }{[]int{1}})},
+	}
+	for _, c := range cases {
+		if c.got != c.same {
+			t.Errorf("Same(): %s expected %v, got %v", c.name, c.same, c.got)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var got []string
+	b := removal.NewBatch(func(key string, value int, reason removal.Reason) {
+		got = append(got, key+":"+reason.String())
+	})
+	b.Add("a", 1, removal.Deleted)
+	b.Replace("b", 1, 1)
+	b.Replace("c", 1, 2)
+	b.Add("d", 1, removal.Expired)
+	b.Add("e", 1, removal.Capacity)
+	b.Add("f", 1, removal.Cleared)
+	if len(got) != 0 {
+		t.Errorf("Expected hook to be called by Notify only, got %v", got)
+	}
+	b.Notify()
+	expect := []string{"a:Deleted", "c:Replaced", "d:Expired", "e:Capacity", "f:Cleared"}
+	if !slices.Equal(got, expect) {
+		t.Errorf("Notify(): Expected %v, got %v", expect, got)
+	}
+	b.Notify()
+	if len(got) != len(expect) {
+		t.Errorf("Notify(): Expected entries to be notified once, got %v", got)
+	}
+	if removal.Reason(-1).String() != "unknown" {
+		t.Errorf("String(): Expected unknown reason")
+	}
+
+	nop := removal.NewBatch[string, int](nil)
+	nop.Add("a", 1, removal.Deleted)
+	nop.Replace("a", 1, 2)
+	nop.Notify()
+}
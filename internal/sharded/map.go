@@ -0,0 +1,143 @@
+package sharded
+
+import "github.com/thetechpanda/typedmap/internal/derive"
+
+// Store sets the value for a key.
+func (m *TypedMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.
+// The ok result indicates whether value was found in the map.
+func (m *TypedMap[K, V]) Load(key K) (v V, ok bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok = s.data[key]
+	return v, ok
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *TypedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	actual, loaded = s.data[key]
+	if loaded {
+		return actual, true
+	}
+	s.data[key] = value
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, loaded = s.data[key]
+	if loaded {
+		delete(s.data, key)
+	}
+	return value, loaded
+}
+
+// Delete removes the key from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *TypedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, loaded = s.data[key]
+	s.data[key] = value
+	return previous, loaded
+}
+
+// CompareAndSwap swaps the old and new values for key
+// if the value stored in the map is equal to old.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+//
+// Returns true if the swap was performed.
+func (m *TypedMap[K, V]) CompareAndSwap(key K, old, new V) bool {
+	if !m.ValuesComparable() {
+		return false
+	}
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	if !ok || !m.ValuesEqual(v, old) {
+		return false
+	}
+	s.data[key] = new
+	return true
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
+//
+// If there is no current value for key in the map, CompareAndDelete
+// returns false (even if the old value is the nil interface value).
+func (m *TypedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	if !ok || !m.ValuesEqual(v, old) {
+		return false
+	}
+	delete(s.data, key)
+	return true
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+//
+// Shards are visited one at a time holding only the read lock of the shard being visited,
+// Range does not correspond to a consistent snapshot of the whole map.
+// Avoid invoking any map functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Range(f func(K, V) bool) {
+	for _, s := range m.shards {
+		if !s.rangeLocked(f) {
+			return
+		}
+	}
+}
+
+// rangeLocked calls f for each key and value in the shard while holding its read lock.
+// It returns false if f stopped the iteration.
+func (s *shard[K, V]) rangeLocked(f func(K, V) bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, value := range s.data {
+		if !f(key, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package sharded
+
+import (
+	"hash/maphash"
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/hashing"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// DefaultShards is the number of shards used when New is called with a non positive value.
+const DefaultShards = 32
+
+// MaxShards is the maximum number of shards, larger values passed to New are capped.
+const MaxShards = 1 << 16
+
+// shard is a portion of the map guarded by its own RWMutex.
+type shard[K comparable, V any] struct {
+	mu   sync.RWMutex
+	data map[K]V
+}
+
+// TypedMap implements a thread-safe map that spreads its keys over a number of
+// independently locked shards, reducing the contention between writers working on different keys.
+type TypedMap[K comparable, V any] struct {
+	seed maphash.Seed
+	mask uint64
+	equality.Comparer[V]
+	txn.ID
+	shards []*shard[K, V]
+}
+
+// New returns a new TypedMap, split in the given number of shards.
+// The number of shards is rounded up to the next power of two, up to MaxShards, if n is not positive DefaultShards is used.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](n int, equal equality.Func[V]) *TypedMap[K, V] {
+	if n <= 0 {
+		n = DefaultShards
+	}
+	n = min(n, MaxShards)
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	shards := make([]*shard[K, V], size)
+	for i := range shards {
+		shards[i] = &shard[K, V]{data: make(map[K]V)}
+	}
+	return &TypedMap[K, V]{
+		seed:     maphash.MakeSeed(),
+		mask:     uint64(size - 1),
+		Comparer: equality.NewComparer(equal, equality.Deep[V]),
+		ID:       txn.NewID(),
+		shards:   shards,
+	}
+}
+
+// Shards returns the number of shards used by the map.
+func (m *TypedMap[K, V]) Shards() int {
+	return len(m.shards)
+}
+
+// shard returns the shard responsible for key.
+func (m *TypedMap[K, V]) shard(key K) *shard[K, V] {
+	return m.shards[hashing.Comparable(m.seed, key)&m.mask]
+}
+
+// lockAll acquires the write lock of every shard, always in the same order to prevent deadlocks.
+func (m *TypedMap[K, V]) lockAll() {
+	for _, s := range m.shards {
+		s.mu.Lock()
+	}
+}
+
+// unlockAll releases the write lock of every shard.
+func (m *TypedMap[K, V]) unlockAll() {
+	for _, s := range m.shards {
+		s.mu.Unlock()
+	}
+}
+
+// rlockAll acquires the read lock of every shard, always in the same order to prevent deadlocks.
+func (m *TypedMap[K, V]) rlockAll() {
+	for _, s := range m.shards {
+		s.mu.RLock()
+	}
+}
+
+// runlockAll releases the read lock of every shard.
+func (m *TypedMap[K, V]) runlockAll() {
+	for _, s := range m.shards {
+		s.mu.RUnlock()
+	}
+}
+
+// len returns the number of keys in the map, the caller must hold the lock of every shard.
+func (m *TypedMap[K, V]) len() (n int) {
+	for _, s := range m.shards {
+		n += len(s.data)
+	}
+	return n
+}
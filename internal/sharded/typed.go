@@ -0,0 +1,236 @@
+package sharded
+
+import "github.com/thetechpanda/typedmap/internal/txn"
+
+// Clear removes all items from the map.
+// All shards are locked for the duration of the operation.
+func (m *TypedMap[K, V]) Clear() {
+	m.lockAll()
+	defer m.unlockAll()
+	for _, s := range m.shards {
+		s.data = make(map[K]V)
+	}
+}
+
+// Has returns true if the map contains the key.
+func (m *TypedMap[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+// Only the shard holding the key is locked.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	s.data[key] = f(v, ok)
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+//
+// All shards are locked before the iteration starts, so UpdateRange observes and modifies a consistent view of the whole map.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	m.lockAll()
+	defer m.unlockAll()
+	for _, s := range m.shards {
+		for key, value := range s.data {
+			newValue, ok := f(key, value)
+			if !ok {
+				return
+			}
+			s.data[key] = newValue
+		}
+	}
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated.
+//
+// All shards are locked for the duration of the iteration, so the values are applied to the whole map at once.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	m.lockAll()
+	defer m.unlockAll()
+	values := make([]map[K]V, len(m.shards))
+	for i, s := range m.shards {
+		values[i] = make(map[K]V, len(s.data))
+		for key, value := range s.data {
+			newValue, ok := f(key, value)
+			if !ok {
+				return false
+			}
+			values[i][key] = newValue
+		}
+	}
+	for i, s := range m.shards {
+		for key, value := range values[i] {
+			s.data[key] = value
+		}
+	}
+	return true
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+//
+// All shards are locked and their content is merged in a single map passed to f,
+// once f returns the content of the map is distributed back to the shards.
+// This makes Exclusive an O(N) operation with the number of elements in the map.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	m.lockAll()
+	defer m.unlockAll()
+	data := make(map[K]V, m.len())
+	for _, s := range m.shards {
+		for key, value := range s.data {
+			data[key] = value
+		}
+		s.data = make(map[K]V)
+	}
+	// redistribute even if f panics, so that the map is left in a consistent state.
+	defer func() {
+		for key, value := range data {
+			m.shard(key).data[key] = value
+		}
+	}()
+	f(data)
+}
+
+// Transaction calls f with a transaction staging the writes to the map, every shard is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of every shard, the returned txn.Locked gives access to the content of the map until it is unlocked.
+func (m *TypedMap[K, V]) TxLock() txn.Locked[K, V] {
+	m.lockAll()
+	return txn.Locked[K, V]{
+		Load: func(key K) (V, bool) {
+			v, ok := m.shard(key).data[key]
+			return v, ok
+		},
+		Store: func(key K, value V) {
+			m.shard(key).data[key] = value
+		},
+		Remove: func(key K) {
+			delete(m.shard(key).data, key)
+		},
+		Unlock: m.unlockAll,
+	}
+}
+
+// TxRLock acquires the read lock of every shard, the returned txn.RLocked gives access to the content of the map until it is unlocked.
+func (m *TypedMap[K, V]) TxRLock() txn.RLocked[K, V] {
+	m.rlockAll()
+	return txn.RLocked[K, V]{
+		Load: func(key K) (V, bool) {
+			v, ok := m.shard(key).data[key]
+			return v, ok
+		},
+		Range: func(f func(K, V) bool) {
+			for _, s := range m.shards {
+				for key, value := range s.data {
+					if !f(key, value) {
+						return
+					}
+				}
+			}
+		},
+		Len:     m.len,
+		RUnlock: m.runlockAll,
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// All shards are locked for the duration of the iteration, so the entries are removed atomically.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	m.lockAll()
+	defer m.unlockAll()
+	for _, s := range m.shards {
+		for key, value := range s.data {
+			if f(key, value) {
+				delete(s.data, key)
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map.
+// All shards are read locked, so Len returns the number of items at a single point in time.
+func (m *TypedMap[K, V]) Len() (n int) {
+	m.rlockAll()
+	defer m.runlockAll()
+	return m.len()
+}
+
+// Keys returns a slice of all the keys present in the map, an empty slice is returned if the map is empty.
+// All shards are read locked, so the result is a consistent snapshot of the map.
+func (m *TypedMap[K, V]) Keys() (keys []K) {
+	m.rlockAll()
+	defer m.runlockAll()
+	keys = make([]K, 0, m.len())
+	for _, s := range m.shards {
+		for key := range s.data {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, an empty slice is returned if the map is empty.
+// All shards are read locked, so the result is a consistent snapshot of the map.
+func (m *TypedMap[K, V]) Values() (values []V) {
+	m.rlockAll()
+	defer m.runlockAll()
+	values = make([]V, 0, m.len())
+	for _, s := range m.shards {
+		for _, value := range s.data {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map.
+// All shards are read locked, so the result is a consistent snapshot of the map.
+func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
+	m.rlockAll()
+	defer m.runlockAll()
+	max := m.len()
+	keys = make([]K, 0, max)
+	values = make([]V, 0, max)
+	for _, s := range m.shards {
+		for key, value := range s.data {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+	}
+	return keys, values
+}
@@ -0,0 +1,206 @@
+package sorted
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// maxLevel is the maximum number of levels of the skip list, enough for 4^32 entries.
+const maxLevel = 32
+
+// node is an entry of the map, linked in the skip list. next holds the following node at each level of the node,
+// prev the preceding node at the lowest level, the head of the list for the first node.
+type node[K comparable, V any] struct {
+	key   K
+	value V
+	prev  *node[K, V]
+	next  []*node[K, V]
+}
+
+// TypedMap implements a thread-safe map ordered by key: the entries are held in a skip list,
+// so that lookups, insertions and removals are O(log N) and the entries can be visited in either order.
+type TypedMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	equality.Comparer[V]
+	txn.ID
+	// compare orders the keys, keys comparing equal are the same key.
+	compare func(a, b K) int
+	// head is the sentinel of the skip list, its next has maxLevel levels.
+	head node[K, V]
+	// tail is the last node, the head if the map is empty.
+	tail *node[K, V]
+	// level is the number of levels in use.
+	level int
+	n     int
+}
+
+// New returns a new TypedMap whose keys are ordered using compare, which returns a negative number if a < b,
+// a positive number if a > b and zero if a and b are the same key.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](compare func(a, b K) int, equal equality.Func[V]) *TypedMap[K, V] {
+	m := &TypedMap[K, V]{
+		Comparer: equality.NewComparer(equal, equality.Deep[V]),
+		ID:       txn.NewID(),
+		compare:  compare,
+	}
+	m.reset()
+	return m
+}
+
+// reset empties the skip list, the caller must hold the lock.
+func (m *TypedMap[K, V]) reset() {
+	m.head.next = make([]*node[K, V], maxLevel)
+	m.tail = &m.head
+	m.level = 1
+	m.n = 0
+}
+
+// randomLevel returns the number of levels of a new node, each level being kept with probability 1/4.
+func randomLevel() int {
+	return min(1+bits.TrailingZeros64(rand.Uint64())/2, maxLevel)
+}
+
+// search returns, for each level in use, the last node whose key is less than key, and the node for key, if any.
+func (m *TypedMap[K, V]) search(key K) (update [maxLevel]*node[K, V], found *node[K, V]) {
+	x := &m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+	if n := x.next[0]; n != nil && m.compare(n.key, key) == 0 {
+		found = n
+	}
+	return update, found
+}
+
+// ceiling returns the first node whose key is greater than or equal to key, nil if none.
+func (m *TypedMap[K, V]) ceiling(key K) *node[K, V] {
+	x := &m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+	}
+	return x.next[0]
+}
+
+// lookup returns the node for key, nil if key is missing.
+func (m *TypedMap[K, V]) lookup(key K) *node[K, V] {
+	if n := m.ceiling(key); n != nil && m.compare(n.key, key) == 0 {
+		return n
+	}
+	return nil
+}
+
+// set stores value for key, the caller must hold the lock.
+func (m *TypedMap[K, V]) set(key K, value V) {
+	update, found := m.search(key)
+	if found != nil {
+		found.value = value
+		return
+	}
+	level := randomLevel()
+	for ; m.level < level; m.level++ {
+		update[m.level] = &m.head
+	}
+	n := &node[K, V]{key: key, value: value, prev: update[0], next: make([]*node[K, V], level)}
+	for i := range n.next {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		m.tail = n
+	}
+	m.n++
+}
+
+// unlink removes n, update holds the last node preceding n at each level, as returned by search.
+// The caller must hold the lock.
+func (m *TypedMap[K, V]) unlink(update *[maxLevel]*node[K, V], n *node[K, V]) {
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		m.tail = n.prev
+	}
+	for m.level > 1 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+	m.n--
+}
+
+// remove deletes the node for key and returns it, nil if key is missing. The caller must hold the lock.
+func (m *TypedMap[K, V]) remove(key K) *node[K, V] {
+	update, found := m.search(key)
+	if found != nil {
+		m.unlink(&update, found)
+	}
+	return found
+}
+
+// entry returns the key and value of n, ok is false if n is nil or the head of the list.
+func (m *TypedMap[K, V]) entry(n *node[K, V]) (key K, value V, ok bool) {
+	if n == nil || n == &m.head {
+		return key, value, false
+	}
+	return n.key, n.value, true
+}
+
+// Min returns the entry with the smallest key, the ok result reports whether the map is not empty.
+func (m *TypedMap[K, V]) Min() (key K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.entry(m.head.next[0])
+}
+
+// Max returns the entry with the largest key, the ok result reports whether the map is not empty.
+func (m *TypedMap[K, V]) Max() (key K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.entry(m.tail)
+}
+
+// Floor returns the entry with the largest key less than or equal to key, the ok result reports whether there is one.
+func (m *TypedMap[K, V]) Floor(key K) (k K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n := m.ceiling(key)
+	switch {
+	case n == nil:
+		n = m.tail
+	case m.compare(n.key, key) != 0:
+		n = n.prev
+	}
+	return m.entry(n)
+}
+
+// Ceiling returns the entry with the smallest key greater than or equal to key, the ok result reports whether there is one.
+func (m *TypedMap[K, V]) Ceiling(key K) (k K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.entry(m.ceiling(key))
+}
+
+// DeleteRange removes the entries whose keys are greater than or equal to lo and less than hi,
+// and returns how many entries were removed. It is O(log N) plus the number of entries removed.
+func (m *TypedMap[K, V]) DeleteRange(lo, hi K) (n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	update, _ := m.search(lo)
+	// the nodes preceding lo precede every removed node, update stays valid.
+	for x := update[0].next[0]; x != nil && m.compare(x.key, hi) < 0; x = update[0].next[0] {
+		m.unlink(&update, x)
+		n++
+	}
+	return n
+}
@@ -0,0 +1,384 @@
+package sorted_test
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/sorted"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+func TestNew(t *testing.T) {
+	m := sorted.New[int, int](cmp.Compare[int], nil)
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected a new map, got map with length %d", m.Len())
+	}
+	if _, _, ok := m.Min(); ok {
+		t.Errorf("Min(): Expected an empty map")
+	}
+	if _, _, ok := m.Max(); ok {
+		t.Errorf("Max(): Expected an empty map")
+	}
+	if _, _, ok := m.Floor(0); ok {
+		t.Errorf("Floor(): Expected an empty map")
+	}
+	if _, _, ok := m.Ceiling(0); ok {
+		t.Errorf("Ceiling(): Expected an empty map")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	m := sorted.New[int, int](cmp.Compare[int], nil)
+	want := map[int]int{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(1000)
+		if r.Intn(3) == 0 {
+			m.Delete(key)
+			delete(want, key)
+		} else {
+			m.Store(key, i)
+			want[key] = i
+		}
+	}
+	keys := slices.Sorted(maps.Keys(want))
+	if got := m.Keys(); !slices.Equal(got, keys) {
+		t.Errorf("Keys(): Expected the keys in ascending order")
+	}
+	if m.Len() != len(want) {
+		t.Errorf("Len(): Expected length %d, got %d", len(want), m.Len())
+	}
+	gotKeys, values := m.Entries()
+	for i, key := range gotKeys {
+		if values[i] != want[key] {
+			t.Errorf("Entries(): Expected value %d for key %d, got %d", want[key], key, values[i])
+		}
+	}
+	if got := m.Values(); !slices.Equal(got, values) {
+		t.Errorf("Values(): Expected the values in ascending order of the keys")
+	}
+	var descending []int
+	for key := range m.Descend() {
+		descending = append(descending, key)
+	}
+	slices.Reverse(descending)
+	if !slices.Equal(descending, keys) {
+		t.Errorf("Descend(): Expected the keys in descending order")
+	}
+	if key, _, _ := m.Min(); key != keys[0] {
+		t.Errorf("Min(): Expected key %d, got %d", keys[0], key)
+	}
+	if key, _, _ := m.Max(); key != keys[len(keys)-1] {
+		t.Errorf("Max(): Expected key %d, got %d", keys[len(keys)-1], key)
+	}
+}
+
+func TestFloorCeiling(t *testing.T) {
+	m := sorted.New[int, string](cmp.Compare[int], nil)
+	for _, key := range []int{10, 20, 30} {
+		m.Store(key, "v")
+	}
+	tests := []struct {
+		key            int
+		floor, ceiling int
+		hasFloor       bool
+		hasCeiling     bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{15, 10, 20, true, true},
+		{30, 30, 30, true, true},
+		{35, 30, 0, true, false},
+	}
+	for _, test := range tests {
+		if key, _, ok := m.Floor(test.key); key != test.floor || ok != test.hasFloor {
+			t.Errorf("Floor(%d): Expected %d, %v, got %d, %v", test.key, test.floor, test.hasFloor, key, ok)
+		}
+		if key, _, ok := m.Ceiling(test.key); key != test.ceiling || ok != test.hasCeiling {
+			t.Errorf("Ceiling(%d): Expected %d, %v, got %d, %v", test.key, test.ceiling, test.hasCeiling, key, ok)
+		}
+	}
+}
+
+func TestRangeBetween(t *testing.T) {
+	m := sorted.New[int, int](cmp.Compare[int], nil)
+	for i := 0; i < 100; i += 2 {
+		m.Store(i, i)
+	}
+	var keys []int
+	for key := range m.RangeBetween(9, 17) {
+		keys = append(keys, key)
+	}
+	if !slices.Equal(keys, []int{10, 12, 14, 16}) {
+		t.Errorf("RangeBetween(): Expected keys [10 12 14 16], got %v", keys)
+	}
+	for range m.RangeBetween(0, 100) {
+		break
+	}
+	for range m.Descend() {
+		break
+	}
+	if n := len(maps.Collect(m.RangeBetween(50, 10))); n != 0 {
+		t.Errorf("RangeBetween(): Expected no keys when lo > hi, got %d", n)
+	}
+
+	if n := m.DeleteRange(9, 17); n != 4 {
+		t.Errorf("DeleteRange(): Expected 4 entries to be removed, got %d", n)
+	}
+	if n := m.DeleteRange(9, 17); n != 0 {
+		t.Errorf("DeleteRange(): Expected no entries to be removed, got %d", n)
+	}
+	if keys := m.Keys(); len(keys) != 46 || keys[4] != 8 || keys[5] != 18 {
+		t.Errorf("DeleteRange(): Unexpected keys %v", keys)
+	}
+	if n := m.DeleteRange(90, 1000); n != 5 || m.Len() != 41 {
+		t.Errorf("DeleteRange(): Expected the last 5 entries to be removed, got %d and length %d", n, m.Len())
+	}
+	if key, _, _ := m.Max(); key != 88 {
+		t.Errorf("Max(): Expected key 88, got %d", key)
+	}
+	if n := m.DeleteRange(-1, 1000); n != 41 || m.Len() != 0 {
+		t.Errorf("DeleteRange(): Expected every entry to be removed, got %d", n)
+	}
+	m.Store(1, 1)
+	if key, _, ok := m.Max(); !ok || key != 1 {
+		t.Errorf("Max(): Expected key 1, got %d, %v", key, ok)
+	}
+}
+
+func TestComparator(t *testing.T) {
+	// keys are compared ignoring the case, the keys comparing equal are the same key.
+	m := sorted.New[string, int](func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}, nil)
+	m.Store("b", 1)
+	m.Store("A", 2)
+	m.Store("a", 3)
+	m.Store("C", 4)
+	if keys := m.Keys(); !slices.Equal(keys, []string{"A", "b", "C"}) {
+		t.Errorf("Keys(): Expected keys [A b C], got %v", keys)
+	}
+	if v, ok := m.Load("B"); !ok || v != 1 {
+		t.Errorf("Load(): Expected value 1, got %d, %v", v, ok)
+	}
+	if v, _ := m.Load("a"); v != 3 {
+		t.Errorf("Store(): Expected value 3, got %d", v)
+	}
+}
+
+func TestMapOperations(t *testing.T) {
+	m := sorted.New[string, int](strings.Compare, nil)
+	if _, ok := m.Load("key"); ok {
+		t.Errorf("Load(): Expected key to be missing")
+	}
+	if v, loaded := m.LoadOrStore("key", 42); loaded || v != 42 {
+		t.Errorf("LoadOrStore(): Expected key to be stored")
+	}
+	if v, loaded := m.LoadOrStore("key", 0); !loaded || v != 42 {
+		t.Errorf("LoadOrStore(): Expected value 42 to be loaded, got %d", v)
+	}
+	if v, loaded := m.Swap("key", 43); !loaded || v != 42 {
+		t.Errorf("Swap(): Expected previous value 42, got %d", v)
+	}
+	if _, loaded := m.Swap("other", 1); loaded {
+		t.Errorf("Swap(): Expected key not to be loaded")
+	}
+	if m.CompareAndDelete("key", 42) {
+		t.Errorf("CompareAndDelete(): Expected key not to be deleted")
+	}
+	if !m.CompareAndDelete("key", 43) {
+		t.Errorf("CompareAndDelete(): Expected key to be deleted")
+	}
+	if m.CompareAndSwap("key", 43, 44) {
+		t.Errorf("CompareAndSwap(): Expected missing key not to be swapped")
+	}
+	if !m.CompareAndSwap("other", 1, 2) {
+		t.Errorf("CompareAndSwap(): Expected key to be swapped")
+	}
+	m.Store("key", 1)
+	if v, loaded := m.LoadAndDelete("key"); !loaded || v != 1 {
+		t.Errorf("LoadAndDelete(): Expected value 1, got %d", v)
+	}
+	if _, loaded := m.LoadAndDelete("key"); loaded {
+		t.Errorf("LoadAndDelete(): Expected key to be missing")
+	}
+	m.Store("key", 1)
+	m.Delete("key")
+	if m.Has("key") {
+		t.Errorf("Has(): Expected key to be deleted")
+	}
+	m.Clear()
+	if m.Len() != 0 || len(m.Keys()) != 0 {
+		t.Errorf("Clear(): Expected empty map, got length %d", m.Len())
+	}
+	m.Store("key", 1)
+	if !m.Has("key") {
+		t.Errorf("Has(): Expected key to be present after Clear")
+	}
+
+	n := sorted.New[int, []int](cmp.Compare[int], nil)
+	n.Store(1, []int{1})
+	if n.CompareAndSwap(1, []int{1}, []int{2}) || n.CompareAndDelete(1, []int{1}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := n.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := n.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := sorted.New[int, []int](cmp.Compare[int], slices.Equal[[]int])
+	e.Store(1, []int{1})
+	if swapped, err := e.TryCompareAndSwap(1, []int{1}, []int{2}); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := e.TryCompareAndDelete(1, []int{2}); !deleted || err != nil || e.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+}
+
+func TestRangeAndUpdateRange(t *testing.T) {
+	m := sorted.New[int, int](cmp.Compare[int], nil)
+	for i := 99; i >= 0; i-- {
+		m.Store(i, 1)
+	}
+	count := 0
+	m.UpdateRange(func(k, v int) (int, bool) {
+		count++
+		return 2, count < 50
+	})
+	// the 49 smallest keys are updated.
+	sum := 0
+	m.Range(func(k, v int) bool {
+		sum += v
+		return true
+	})
+	if v, _ := m.Load(48); sum != 149 || v != 2 {
+		t.Errorf("UpdateRange(): Expected sum 149, got %d", sum)
+	}
+	count = 0
+	m.Range(func(k, v int) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Range(): Expected 1 iteration, got %d", count)
+	}
+	m.Update(0, func(v int, ok bool) int { return v + 1 })
+	m.Update(100, func(v int, ok bool) int { return v + 1 })
+	if a, _ := m.Load(0); a != 3 || m.Len() != 101 {
+		t.Errorf("Update(): Expected value 3 and length 101, got %d and %d", a, m.Len())
+	}
+}
+
+func TestExclusive(t *testing.T) {
+	m := sorted.New[int, int](cmp.Compare[int], nil)
+	for i := 0; i < 4; i++ {
+		m.Store(i, i)
+	}
+	m.Exclusive(func(data map[int]int) {
+		delete(data, 1)
+		data[2] = 20
+		data[-1] = -1
+	})
+	if keys, values := m.Entries(); !slices.Equal(keys, []int{-1, 0, 2, 3}) || !slices.Equal(values, []int{-1, 0, 20, 3}) {
+		t.Errorf("Exclusive(): Expected [-1 0 2 3] [-1 0 20 3], got %v %v", keys, values)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	m := sorted.New[int, int](cmp.Compare[int], nil)
+	for _, i := range []int{2, 0, 1} {
+		m.Store(i, i*2)
+	}
+	if keys := slices.Collect(m.KeysSeq()); !slices.Equal(keys, []int{0, 1, 2}) {
+		t.Errorf("KeysSeq(): Expected keys [0 1 2], got %v", keys)
+	}
+	if values := slices.Collect(m.ValuesSeq()); !slices.Equal(values, []int{0, 2, 4}) {
+		t.Errorf("ValuesSeq(): Expected values [0 2 4], got %v", values)
+	}
+	for k, v := range m.All() {
+		if v != k*2 {
+			t.Errorf("All(): Expected value %d, got %d", k*2, v)
+		}
+	}
+	for range m.KeysSeq() {
+		break
+	}
+	for range m.ValuesSeq() {
+		break
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	m := sorted.New[string, int](strings.Compare, nil)
+	for i := 9; i >= 0; i-- {
+		m.Store(string(rune('a'+i)), i)
+	}
+	m.DeleteFunc(func(k string, v int) bool { return v%2 == 0 })
+	// the remaining keys are still sorted.
+	if keys := m.Keys(); !slices.Equal(keys, []string{"b", "d", "f", "h", "j"}) {
+		t.Errorf("DeleteFunc(): Expected keys [b d f h j], got %v", keys)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	m := sorted.New[string, int](strings.Compare, nil)
+	m.Store("a", 10)
+	m.Store("b", 0)
+	err := m.Transaction(func(tx txn.Tx[string, int]) error {
+		if _, ok := tx.Load("c"); ok {
+			t.Errorf("Load(): Expected key c to be missing")
+		}
+		a, _ := tx.Load("a")
+		tx.Store("c", a)
+		tx.Delete("a")
+		tx.Delete("missing")
+		return nil
+	})
+	if keys, values := m.Entries(); err != nil || !slices.Equal(keys, []string{"b", "c"}) || !slices.Equal(values, []int{0, 10}) {
+		t.Errorf("Transaction(): Expected [b c] [0 10], got %v %v, %v", keys, values, err)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	m := sorted.New[int, int](cmp.Compare[int], nil)
+	numGoroutines := 100
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			// uses context done to have all goroutines start at the same time
+			<-ctx.Done()
+			for j := 0; j < numGoroutines; j++ {
+				if _, ok := m.Load(j); !ok {
+					m.LoadOrStore(j, i*i)
+				}
+				m.Update(j, func(v int, ok bool) int { return v + 1 })
+				m.Floor(j)
+				m.Ceiling(j)
+				for range m.RangeBetween(j, j+10) {
+				}
+				if j%10 == 0 {
+					m.DeleteRange(j, j+5)
+				}
+			}
+		}(i)
+	}
+	cancel()
+	wg.Wait()
+	if keys := m.Keys(); len(keys) != m.Len() || !slices.IsSorted(keys) {
+		t.Errorf("Keys(): Expected %d sorted keys, got %v", m.Len(), keys)
+	}
+}
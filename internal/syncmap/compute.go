@@ -0,0 +1,50 @@
+package syncmap
+
+import (
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/derive"
+)
+
+// Compute calls f with the current value for key, if any, and stores or deletes the key according to the returned Op.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+//
+// Compute is implemented using a compare and swap loop: if the value for key changes while f is running, f is called again with the new value.
+func (m *SyncMap[K, V]) Compute(key K, f func(old V, loaded bool) (V, compute.Op)) (value V, ok bool) {
+	for {
+		current, loaded := m.sm.Load(key)
+		old := m.unbox(current)
+		value, op := f(old, loaded)
+		switch op {
+		case compute.Store:
+			if !loaded {
+				if _, loaded := m.sm.LoadOrStore(key, m.wrap(value)); !loaded {
+					m.n.Add(1)
+					return value, true
+				}
+			} else if m.sm.CompareAndSwap(key, current, m.wrap(value)) {
+				m.replaced(key, old, value)
+				return value, true
+			}
+		case compute.Delete:
+			if !loaded || m.compareAndDelete(key, current) {
+				return m.zeroValue(), false
+			}
+		default:
+			return old, loaded
+		}
+	}
+}
+
+// ComputeIfAbsent calls f only if key is not present, and stores the returned value if the Op is Store.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+func (m *SyncMap[K, V]) ComputeIfAbsent(key K, f func() (V, compute.Op)) (value V, ok bool) {
+	return derive.ComputeIfAbsent(m.Load, m.Compute, key, f)
+}
+
+// ComputeIfPresent calls f only if key is present, and stores or deletes the key according to the returned Op.
+// It returns the value for key once the operation is applied, the ok result reports whether the key is present.
+//
+// f may be called more than once if the value for key changes concurrently.
+func (m *SyncMap[K, V]) ComputeIfPresent(key K, f func(old V) (V, compute.Op)) (value V, ok bool) {
+	return derive.ComputeIfPresent(m.Compute, key, f)
+}
@@ -1,20 +1,51 @@
 package syncmap
 
 import (
+	"reflect"
 	"sync"
+	"sync/atomic"
+
+	"github.com/thetechpanda/typedmap/internal/derive"
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
 )
 
-type SyncMap[K, V any] struct {
+// box holds a value stored in the underlying sync.Map when values are not compared using ==.
+// The compare and swap operations of sync.Map compare the boxes rather than the values they hold:
+// values of any type, comparable or not, can be swapped once compared using the equality function of the map.
+type box[V any] struct {
+	value V
+}
+
+// SyncMap wraps a sync.Map, the number of entries is tracked by an atomic counter updated by every operation adding or removing a key.
+type SyncMap[K comparable, V any] struct {
 	sm sync.Map
+	n  atomic.Int64
+	equality.Comparer[V]
+	txn.ID
+	// boxed reports whether values are stored boxed, otherwise they are stored as is and compared using ==, as sync.Map does.
+	boxed    bool
+	onRemove removal.Hook[K, V]
 }
 
-func New[K, V any]() *SyncMap[K, V] {
-	return &SyncMap[K, V]{}
+// New returns a new SyncMap.
+// If onRemove is not nil it is called for every entry removed from the map, once the entry has been removed.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or ==.
+// Values are stored as is if V is a comparable type compared using ==, otherwise they are stored boxed.
+// Interface values are stored boxed, as they might hold values of non comparable types.
+func New[K comparable, V any](onRemove removal.Hook[K, V], equal equality.Func[V]) *SyncMap[K, V] {
+	return &SyncMap[K, V]{
+		Comparer: equality.NewComparer(equal, equality.Identical[V]),
+		boxed:    equal != nil || !equality.Default[V]() || reflect.TypeFor[V]().Kind() == reflect.Interface,
+		ID:       txn.NewID(),
+		onRemove: onRemove,
+	}
 }
 
 // Store sets the value for a key.
 func (m *SyncMap[K, V]) Store(key K, value V) {
-	m.sm.Store(key, value)
+	m.Swap(key, value)
 }
 
 // Load returns the value stored in the map for a key, or nil if no
@@ -22,22 +53,18 @@ func (m *SyncMap[K, V]) Store(key K, value V) {
 // The ok result indicates whether value was found in the map.
 func (m *SyncMap[K, V]) Load(key K) (v V, ok bool) {
 	value, ok := m.sm.Load(key)
-	if value == nil || !ok {
-		return m.zeroValue(), ok
-	}
-	return value.(V), ok
-
+	return m.unbox(value), ok
 }
 
 // LoadOrStore returns the existing value for the key if present.
 // Otherwise, it stores and returns the given value.
 // The loaded result is true if the value was loaded, false if stored.
 func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
-	v, loaded := m.sm.LoadOrStore(key, value)
-	if v == nil {
-		return m.zeroValue(), loaded
+	v, loaded := m.sm.LoadOrStore(key, m.wrap(value))
+	if !loaded {
+		m.n.Add(1)
 	}
-	return v.(V), loaded
+	return m.unbox(v), loaded
 }
 
 // zeroValue returns the zero value for the value type V.
@@ -46,14 +73,50 @@ func (m *SyncMap[K, V]) zeroValue() V {
 	return zero
 }
 
+// wrap returns the value to store in the underlying sync.Map for value, boxing it if the map stores values boxed.
+func (m *SyncMap[K, V]) wrap(value V) any {
+	if m.boxed {
+		return &box[V]{value}
+	}
+	return value
+}
+
+// unbox returns the value stored in the underlying sync.Map, unboxing it if needed, or the zero value if v is nil.
+func (m *SyncMap[K, V]) unbox(v any) V {
+	if v == nil {
+		return m.zeroValue()
+	}
+	if m.boxed {
+		return v.(*box[V]).value
+	}
+	return v.(V)
+}
+
+// removed calls the removal hook, if any, for an entry removed from the map.
+func (m *SyncMap[K, V]) removed(key K, value V, reason removal.Reason) {
+	if m.onRemove != nil {
+		m.onRemove(key, value, reason)
+	}
+}
+
+// replaced calls the removal hook, if any, for a value replaced by new, unless new is the same value.
+func (m *SyncMap[K, V]) replaced(key K, old, new V) {
+	if m.onRemove != nil && !removal.Same(old, new) {
+		m.onRemove(key, old, removal.Replaced)
+	}
+}
+
 // LoadAndDelete deletes the value for a key, returning the previous value if any.
 // The loaded result reports whether the key was present.
 func (m *SyncMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 	v, loaded := m.sm.LoadAndDelete(key)
-	if v == nil {
-		return m.zeroValue(), loaded
+	if !loaded {
+		return m.zeroValue(), false
 	}
-	return v.(V), loaded
+	m.n.Add(-1)
+	value = m.unbox(v)
+	m.removed(key, value, removal.Deleted)
+	return value, true
 }
 
 // Delete removes the key from the map.
@@ -65,29 +128,89 @@ func (m *SyncMap[K, V]) Delete(key K) {
 // Swap swaps the value for a key and returns the previous value if any.
 // The loaded result reports whether the key was present.
 func (m *SyncMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
-	previousAny, loaded := m.sm.Swap(key, value)
-	if previousAny == nil {
-		return m.zeroValue(), loaded
+	v, loaded := m.sm.Swap(key, m.wrap(value))
+	if !loaded {
+		m.n.Add(1)
+		return m.zeroValue(), false
 	}
-	return previousAny.(V), loaded
+	previous = m.unbox(v)
+	m.replaced(key, previous, value)
+	return previous, true
 }
 
 // CompareAndSwap swaps the old and new values for key
 // if the value stored in the map is equal to old.
-// The old value must be of a comparable type.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+// As sync.Map does, it panics if V is an interface type holding values of a non comparable type and no equality function is provided.
 //
 // Returns true if the swap was performed.
 func (m *SyncMap[K, V]) CompareAndSwap(key K, old, new V) bool {
-	return m.sm.CompareAndSwap(key, old, new)
+	if !m.ValuesComparable() {
+		return false
+	}
+	if !m.boxed {
+		if !m.sm.CompareAndSwap(key, old, new) {
+			return false
+		}
+		m.replaced(key, old, new)
+		return true
+	}
+	for {
+		current, ok := m.sm.Load(key)
+		if !ok || !m.ValuesEqual(m.unbox(current), old) {
+			return false
+		}
+		if m.sm.CompareAndSwap(key, current, &box[V]{new}) {
+			m.replaced(key, m.unbox(current), new)
+			return true
+		}
+	}
 }
 
 // CompareAndDelete deletes the entry for key if its value is equal to old.
-// The old value must be of a comparable type.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
 //
 // If there is no current value for key in the map, CompareAndDelete
 // returns false (even if the old value is the nil interface value).
 func (m *SyncMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
-	return m.sm.CompareAndDelete(key, old)
+	if !m.ValuesComparable() {
+		return false
+	}
+	if !m.boxed {
+		return m.compareAndDelete(key, old)
+	}
+	for {
+		current, ok := m.sm.Load(key)
+		if !ok || !m.ValuesEqual(m.unbox(current), old) {
+			return false
+		}
+		if m.compareAndDelete(key, current) {
+			return true
+		}
+	}
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *SyncMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *SyncMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// compareAndDelete deletes the entry for key if the value, or the box, stored in the underlying sync.Map is old.
+// The removal is reported to the removal hook, if any, as deleted.
+func (m *SyncMap[K, V]) compareAndDelete(key K, old any) (deleted bool) {
+	if m.sm.CompareAndDelete(key, old) {
+		m.n.Add(-1)
+		m.removed(key, m.unbox(old), removal.Deleted)
+		return true
+	}
+	return false
 }
 
 // Range calls f sequentially for each key and value present in the map.
@@ -95,14 +218,14 @@ func (m *SyncMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
 // Avoid invoking any map functions within 'f' to prevent a deadlock.
 func (m *SyncMap[K, V]) Range(f func(K, V) bool) {
 	m.sm.Range(func(key, value any) bool {
-		var k K
-		var v V
-		if key != nil {
-			k = key.(K)
-		}
-		if value != nil {
-			v = value.(V)
-		}
-		return f(k, v)
+		return f(m.typed(key, value))
 	})
 }
+
+// typed converts a key and a value stored in the underlying sync.Map to K and V.
+func (m *SyncMap[K, V]) typed(key, value any) (k K, v V) {
+	if key != nil {
+		k = key.(K)
+	}
+	return k, m.unbox(value)
+}
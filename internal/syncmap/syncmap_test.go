@@ -1,16 +1,26 @@
 package syncmap_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
 	"github.com/thetechpanda/typedmap/internal/syncmap"
+	"github.com/thetechpanda/typedmap/internal/txn"
 )
 
 func TestSyncMapLoad(t *testing.T) {
-	m := syncmap.New[string, int]()
+	m := syncmap.New[string, int](nil, nil)
 	key := "key"
 	value := 42
 	m.Store(key, value)
@@ -25,7 +35,7 @@ func TestSyncMapLoad(t *testing.T) {
 }
 
 func TestSyncMapLoadOrStore(t *testing.T) {
-	m := syncmap.New[string, int]()
+	m := syncmap.New[string, int](nil, nil)
 	key := "key"
 	value := 42
 	actual, loaded := m.LoadOrStore(key, value)
@@ -44,7 +54,7 @@ func TestSyncMapLoadOrStore(t *testing.T) {
 		t.Errorf("Expected value %d for key %q, got value %d", value, key, actual)
 	}
 
-	mp := syncmap.New[string, *int]()
+	mp := syncmap.New[string, *int](nil, nil)
 	mp.Store(key, nil)
 	var valueP *int = new(int)
 	if actual, _ := mp.LoadOrStore(key, valueP); actual != nil {
@@ -54,7 +64,7 @@ func TestSyncMapLoadOrStore(t *testing.T) {
 }
 
 func TestSyncMapLoadAndDelete(t *testing.T) {
-	m := syncmap.New[string, int]()
+	m := syncmap.New[string, int](nil, nil)
 	key := "key"
 	m.Store(key, 42)
 	v, deleted := m.LoadAndDelete(key)
@@ -69,7 +79,7 @@ func TestSyncMapLoadAndDelete(t *testing.T) {
 }
 
 func TestSyncMapDelete(t *testing.T) {
-	m := syncmap.New[string, int]()
+	m := syncmap.New[string, int](nil, nil)
 	key := "key"
 	value := 42
 	m.Store(key, value)
@@ -80,7 +90,7 @@ func TestSyncMapDelete(t *testing.T) {
 }
 
 func TestSyncMapSwap(t *testing.T) {
-	m := syncmap.New[string, int]()
+	m := syncmap.New[string, int](nil, nil)
 	key := "key"
 	value := 42
 	previous, loaded := m.Swap(key, value)
@@ -101,7 +111,7 @@ func TestSyncMapSwap(t *testing.T) {
 }
 
 func TestSyncMapCompareAndSwap(t *testing.T) {
-	m := syncmap.New[string, int]()
+	m := syncmap.New[string, int](nil, nil)
 	key := "key"
 	current, swap := 42, 43
 	m.Store(key, current)
@@ -116,7 +126,7 @@ func TestSyncMapCompareAndSwap(t *testing.T) {
 }
 
 func TestSyncMapCompareAndDelete(t *testing.T) {
-	m := syncmap.New[string, int]()
+	m := syncmap.New[string, int](nil, nil)
 	key := "key"
 	value := 42
 	m.Store(key, value)
@@ -138,7 +148,7 @@ func TestSyncMapCompareAndDelete(t *testing.T) {
 }
 
 func TestSyncMapConcurrentAccessStore(t *testing.T) {
-	m := syncmap.New[int, int]()
+	m := syncmap.New[int, int](nil, nil)
 
 	// Number of goroutines to spawn
 	numGoroutines := 100
@@ -166,40 +176,87 @@ func TestSyncMapConcurrentAccessStore(t *testing.T) {
 	wg.Wait()
 }
 
-func TestSyncMapNotComparableTypeCompareAndDelete(t *testing.T) {
-	pass := true
-	defer func() {
-		recover()
-		pass = false
-	}()
-	m := syncmap.New[int, []int]()
+func TestSyncMapNotComparableType(t *testing.T) {
+	m := syncmap.New[int, []int](nil, nil)
 	m.Store(1, []int{1, 2, 3})
 	if m.CompareAndDelete(1, []int{1, 2, 3}) {
 		t.Errorf("Expected not comparable type")
 	}
-	if !pass {
-		t.Errorf("Expected panic")
+	if m.CompareAndSwap(1, []int{1, 2, 3}, []int{1, 2, 3, 4}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := m.TryCompareAndSwap(1, []int{1, 2, 3}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := m.TryCompareAndDelete(1, []int{1, 2, 3}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+	// values are boxed, the functions using compare and swap internally work with non comparable types.
+	m.Update(1, func(v []int, _ bool) []int {
+		return append(v, 4)
+	})
+	m.UpdateRange(func(_ int, v []int) ([]int, bool) {
+		return append(v, 5), true
+	})
+	if v, _ := m.Load(1); !slices.Equal(v, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("Update(): Expected [1 2 3 4 5], got %v", v)
+	}
+	if n := m.DeleteFunc(func(int, []int) bool { return true }); n != 1 || m.Len() != 0 {
+		t.Errorf("DeleteFunc(): Expected 1 entry removed, got %d", n)
 	}
 }
 
-func TestSyncMapNotComparableTypeCompareAndSwap(t *testing.T) {
-	pass := true
-	defer func() {
-		recover()
-		pass = false
-	}()
-	m := syncmap.New[int, []int]()
-	m.Store(1, []int{1, 2, 3})
-	if m.CompareAndSwap(1, []int{1, 2, 3}, []int{1, 2, 3, 4}) {
-		t.Errorf("Expected not comparable type")
+func TestSyncMapEqual(t *testing.T) {
+	m := syncmap.New[int, []byte](nil, bytes.Equal)
+	m.Store(1, []byte("a"))
+	if swapped, err := m.TryCompareAndSwap(1, []byte("b"), []byte("c")); swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected different value not to be swapped, got %v, %v", swapped, err)
+	}
+	if swapped, err := m.TryCompareAndSwap(1, []byte("a"), []byte("b")); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
 	}
-	if pass {
-		t.Errorf("Expected panic")
+	if deleted, err := m.TryCompareAndDelete(1, []byte("b")); !deleted || err != nil || m.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+	if m.CompareAndSwap(1, nil, nil) || m.CompareAndDelete(1, nil) {
+		t.Errorf("Expected missing key not to be swapped or deleted")
+	}
+
+	// values implementing Equaler are compared using their Equal method.
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tm := syncmap.New[string, time.Time](nil, nil)
+	tm.Store("t", at)
+	if !tm.CompareAndSwap("t", at.In(time.FixedZone("X", 3600)), at.Add(time.Hour)) {
+		t.Errorf("CompareAndSwap(): Expected time.Time values to be compared using Equal")
+	}
+}
+
+func TestSyncMapCompareAndSwapConcurrent(t *testing.T) {
+	m := syncmap.New[int, []int](nil, slices.Equal[[]int])
+	m.Store(0, []int{0})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				for {
+					v, _ := m.Load(0)
+					if m.CompareAndSwap(0, v, []int{v[0] + 1}) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := m.Load(0); v[0] != 8000 {
+		t.Errorf("CompareAndSwap(): Expected 8000 increments, got %d", v[0])
 	}
 }
 
 func TestSyncMapComparableType(t *testing.T) {
-	m := syncmap.New[int, int]()
+	m := syncmap.New[int, int](nil, nil)
 	m.Store(1, 1)
 	if !m.CompareAndDelete(1, 1) {
 		t.Errorf("Expected to return true")
@@ -209,10 +266,16 @@ func TestSyncMapComparableType(t *testing.T) {
 		t.Errorf("Expected to return true")
 	}
 
+	// values compared using == are stored as is, only the values compared using an equality function are boxed.
+	boxed := syncmap.New[int, int](nil, func(a, b int) bool { return a == b })
+	boxed.Store(1, 1)
+	if n, b := testing.AllocsPerRun(100, func() { m.Store(1, 1) }), testing.AllocsPerRun(100, func() { boxed.Store(1, 1) }); n >= b {
+		t.Errorf("Store(): Expected values compared using == not to be boxed, got %v allocations, %v boxed", n, b)
+	}
 }
 
 func TestSyncMapRange(t *testing.T) {
-	m := syncmap.New[int, int]()
+	m := syncmap.New[int, int](nil, nil)
 	numKeys := 100
 	for i := 0; i < numKeys; i++ {
 		m.Store(i, i)
@@ -235,8 +298,8 @@ func TestSyncMapRange(t *testing.T) {
 	}
 }
 
-func testValues[K any, V any](t *testing.T, key K, value V) {
-	m := syncmap.New[K, V]()
+func testValues[K comparable, V any](t *testing.T, key K, value V) {
+	m := syncmap.New[K, V](nil, nil)
 	m.Store(key, value)
 	v, ok := m.Load(key)
 	if !ok {
@@ -258,12 +321,12 @@ func testValues[K any, V any](t *testing.T, key K, value V) {
 func TestValues(t *testing.T) {
 	// test pointer to any type with nil value
 	var anyV interface{}
-	ma := syncmap.New[*interface{}, interface{}]()
+	ma := syncmap.New[*interface{}, interface{}](nil, nil)
 	ma.Store(&anyV, anyV)
 	ma.LoadOrStore(&anyV, anyV)
 	// test pointer to struct with nil value
 	var anyS struct{}
-	ms := syncmap.New[*struct{}, struct{}]()
+	ms := syncmap.New[*struct{}, struct{}](nil, nil)
 	ms.Store(&anyS, anyS)
 	ms.LoadOrStore(&anyS, anyS)
 
@@ -358,3 +421,279 @@ func TestValues(t *testing.T) {
 	testValues(t, stP, stN)
 
 }
+
+func TestSyncMapIterators(t *testing.T) {
+	m := syncmap.New[int, int](nil, nil)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i*2)
+	}
+
+	clone := maps.Collect(m.All())
+	if len(clone) != 100 {
+		t.Errorf("All(): Expected 100 entries, got %d", len(clone))
+	}
+	for k, v := range clone {
+		if v != k*2 {
+			t.Errorf("All(): Expected value %d for key %d, got %d", k*2, k, v)
+		}
+	}
+
+	keys := slices.Sorted(m.KeysSeq())
+	if len(keys) != 100 || keys[0] != 0 || keys[99] != 99 {
+		t.Errorf("KeysSeq(): Expected sorted keys from 0 to 99, got %v", keys)
+	}
+
+	values := slices.Sorted(m.ValuesSeq())
+	if len(values) != 100 || values[0] != 0 || values[99] != 198 {
+		t.Errorf("ValuesSeq(): Expected sorted values from 0 to 198, got %v", values)
+	}
+
+	count := 0
+	for range m.All() {
+		count++
+		break
+	}
+	for range m.KeysSeq() {
+		count++
+		break
+	}
+	for range m.ValuesSeq() {
+		count++
+		break
+	}
+	if count != 3 {
+		t.Errorf("Expected iterators to stop after break, got %d iterations", count)
+	}
+}
+
+func TestSyncMapDeleteFuncBestEffort(t *testing.T) {
+	m := syncmap.New[string, any](nil, nil)
+	m.Store("nil", nil)
+	m.Store("slice", []int{1})
+	m.Store("changed", 1)
+	n := m.DeleteFunc(func(k string, v any) bool {
+		if k == "changed" {
+			// values changed after being passed to f are not removed.
+			m.Store(k, 2)
+		}
+		return true
+	})
+	if n != 2 {
+		t.Errorf("DeleteFunc(): Expected 2 entries to be removed, got %d", n)
+	}
+	if keys := slices.Collect(m.KeysSeq()); !slices.Equal(keys, []string{"changed"}) {
+		t.Errorf("DeleteFunc(): Expected keys [changed], got %v", keys)
+	}
+}
+
+func TestSyncMapLen(t *testing.T) {
+	m := syncmap.New[string, int](nil, nil)
+	checkLen := func(op string, expect int) {
+		t.Helper()
+		if m.Len() != expect {
+			t.Errorf("%s: Expected length %d, got %d", op, expect, m.Len())
+		}
+	}
+	m.Store("a", 1)
+	m.Store("a", 2)
+	checkLen("Store()", 1)
+	m.LoadOrStore("a", 3)
+	m.LoadOrStore("b", 3)
+	checkLen("LoadOrStore()", 2)
+	m.Swap("b", 4)
+	m.Swap("c", 5)
+	checkLen("Swap()", 3)
+	m.CompareAndSwap("c", 5, 6)
+	m.CompareAndSwap("d", 5, 6)
+	checkLen("CompareAndSwap()", 3)
+	m.CompareAndDelete("c", 5)
+	m.CompareAndDelete("c", 6)
+	checkLen("CompareAndDelete()", 2)
+	m.LoadAndDelete("b")
+	m.Delete("b")
+	checkLen("Delete()", 1)
+	m.Update("a", func(v int, ok bool) int { return v + 1 })
+	m.Update("b", func(v int, ok bool) int { return v + 1 })
+	checkLen("Update()", 2)
+	m.Clear()
+	checkLen("Clear()", 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := fmt.Sprint(j % 100)
+				switch j % 4 {
+				case 0:
+					m.Store(key, j)
+				case 1:
+					m.LoadOrStore(key, j)
+				case 2:
+					m.Delete(key)
+				case 3:
+					m.Swap(key, j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(m.Keys()); m.Len() != n {
+		t.Errorf("Len(): Expected length %d after concurrent operations, got %d", n, m.Len())
+	}
+}
+
+func TestSyncMapTypedMap(t *testing.T) {
+	m := syncmap.New[string, int](nil, nil)
+	for i := 0; i < 10; i++ {
+		m.Store(fmt.Sprint(i), i)
+	}
+	if !m.Has("1") || m.Has("10") {
+		t.Errorf("Has(): Expected 1 to be present and 10 to be missing")
+	}
+	keys, values := m.Entries()
+	for i, key := range keys {
+		if key != fmt.Sprint(values[i]) {
+			t.Errorf("Entries(): Expected key %d for value %d, got %s", values[i], values[i], key)
+		}
+	}
+	if keys := slices.Sorted(slices.Values(m.Keys())); len(keys) != 10 || keys[0] != "0" {
+		t.Errorf("Keys(): Expected 10 keys, got %v", keys)
+	}
+	if values := slices.Sorted(slices.Values(m.Values())); len(values) != 10 || values[9] != 9 {
+		t.Errorf("Values(): Expected 10 values, got %v", values)
+	}
+
+	m.UpdateRange(func(k string, v int) (int, bool) {
+		if k == "5" {
+			// values changed after being passed to f are not replaced.
+			m.Store(k, -5)
+		}
+		return v * 10, true
+	})
+	if v, _ := m.Load("5"); v != -5 {
+		t.Errorf("UpdateRange(): Expected concurrently changed value -5, got %d", v)
+	}
+	if v, _ := m.Load("9"); v != 90 {
+		t.Errorf("UpdateRange(): Expected updated value 90, got %d", v)
+	}
+	visited := 0
+	m.UpdateRange(func(k string, v int) (int, bool) {
+		visited++
+		return -1, false
+	})
+	if visited != 1 || slices.Contains(m.Values(), -1) {
+		t.Errorf("UpdateRange(): Expected the iteration to stop without updates, visited %d", visited)
+	}
+
+	m.Exclusive(func(data map[string]int) {
+		if len(data) != 10 {
+			t.Errorf("Exclusive(): Expected a copy of the 10 entries, got %d", len(data))
+		}
+		delete(data, "0")
+		data["1"] = 1
+		data["10"] = 100
+	})
+	if m.Has("0") || m.Len() != 10 {
+		t.Errorf("Exclusive(): Expected 0 to be deleted and 10 entries, got %d", m.Len())
+	}
+	if v, _ := m.Load("1"); v != 1 {
+		t.Errorf("Exclusive(): Expected changed value 1, got %d", v)
+	}
+	if v, _ := m.Load("10"); v != 100 {
+		t.Errorf("Exclusive(): Expected new value 100, got %d", v)
+	}
+	m.Clear()
+	if m.Len() != 0 || len(m.Keys()) != 0 {
+		t.Errorf("Clear(): Expected an empty map, got %d entries", m.Len())
+	}
+}
+
+// removals records the entries notified by the removal hook.
+type removals struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *removals) hook(key string, value int, reason removal.Reason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf("%s=%d:%s", key, value, reason))
+}
+
+// take returns the recorded events, sorted, and resets them.
+func (r *removals) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	slices.Sort(events)
+	return events
+}
+
+func TestOnRemove(t *testing.T) {
+	r := &removals{}
+	m := syncmap.New(r.hook, nil)
+	check := func(op string, expect ...string) {
+		t.Helper()
+		if got := r.take(); !slices.Equal(got, expect) {
+			t.Errorf("%s: Expected removals %v, got %v", op, expect, got)
+		}
+	}
+	m.Store("a", 1)
+	m.Store("a", 1)
+	check("Store()")
+	m.Store("a", 2)
+	check("Store()", "a=1:Replaced")
+	m.Swap("a", 3)
+	check("Swap()", "a=2:Replaced")
+	m.CompareAndSwap("a", 3, 4)
+	check("CompareAndSwap()", "a=3:Replaced")
+	m.Update("a", func(v int, ok bool) int { return 5 })
+	check("Update()", "a=4:Replaced")
+	m.Compute("a", func(v int, ok bool) (int, compute.Op) { return 6, compute.Store })
+	check("Compute()", "a=5:Replaced")
+	m.Delete("a")
+	check("Delete()", "a=6:Deleted")
+	m.Store("b", 1)
+	m.CompareAndDelete("b", 1)
+	check("CompareAndDelete()", "b=1:Deleted")
+	m.Store("c", 1)
+	m.LoadAndDelete("c")
+	check("LoadAndDelete()", "c=1:Deleted")
+	m.Store("d", 1)
+	m.Compute("d", func(v int, ok bool) (int, compute.Op) { return 0, compute.Delete })
+	check("Compute()", "d=1:Deleted")
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+	m.UpdateRange(func(k string, v int) (int, bool) { return v * 10, true })
+	check("UpdateRange()", "a=1:Replaced", "b=2:Replaced", "c=3:Replaced")
+	m.UpdateRangeAtomic(func(k string, v int) (int, bool) { return v + 1, true })
+	check("UpdateRangeAtomic()", "a=10:Replaced", "b=20:Replaced", "c=30:Replaced")
+	m.DeleteFunc(func(k string, v int) bool { return k == "c" })
+	check("DeleteFunc()", "c=31:Deleted")
+	m.Exclusive(func(data map[string]int) {
+		delete(data, "a")
+		data["b"] = 200
+		data["e"] = 1
+	})
+	check("Exclusive()", "a=11:Deleted", "b=21:Replaced")
+	m.Transaction(func(tx txn.Tx[string, int]) error {
+		tx.Delete("b")
+		tx.Store("e", 2)
+		return nil
+	})
+	check("Transaction()", "b=200:Deleted", "e=1:Replaced")
+	m.Clear()
+	check("Clear()", "e=2:Cleared")
+
+	// values stored boxed are reported unboxed.
+	b := syncmap.New(r.hook, func(a, b int) bool { return a == b })
+	b.Store("a", 1)
+	b.CompareAndSwap("a", 1, 2)
+	b.CompareAndDelete("a", 2)
+	check("CompareAndDelete()", "a=1:Replaced", "a=2:Deleted")
+}
@@ -0,0 +1,210 @@
+package syncmap
+
+import (
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Clear removes all items from the map using sync.Map.Clear and resets the counter used by Len.
+// Keys stored or deleted concurrently with Clear may be counted wrongly by Len, until the map is cleared again.
+//
+// If the map has a removal hook, the keys are deleted one at a time to report the removed entries:
+// as Range, Clear does not correspond to any consistent snapshot of the map and keys stored concurrently may be kept.
+func (m *SyncMap[K, V]) Clear() {
+	if m.onRemove == nil {
+		m.sm.Clear()
+		m.n.Store(0)
+		return
+	}
+	m.sm.Range(func(key, _ any) bool {
+		if v, loaded := m.sm.LoadAndDelete(key); loaded {
+			m.n.Add(-1)
+			k, value := m.typed(key, v)
+			m.removed(k, value, removal.Cleared)
+		}
+		return true
+	})
+}
+
+// Has returns true if the map contains the key.
+func (m *SyncMap[K, V]) Has(key K) bool {
+	_, ok := m.sm.Load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+//
+// Update is implemented using a compare and swap loop: if the value for key changes while f is running, f is called again with the new value.
+func (m *SyncMap[K, V]) Update(key K, f func(V, bool) V) {
+	m.Compute(key, func(old V, loaded bool) (V, compute.Op) {
+		return f(old, loaded), compute.Store
+	})
+}
+
+// UpdateRange calls f sequentially for each key and value present in the map and replaces the value with the one returned by f.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+//
+// UpdateRange is best-effort: as Range it does not correspond to any consistent snapshot of the map,
+// a value is replaced only if it has not been changed since it was passed to f.
+func (m *SyncMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	m.sm.Range(func(key, value any) bool {
+		k, v := m.typed(key, value)
+		newValue, ok := f(k, v)
+		if ok && m.sm.CompareAndSwap(key, value, m.wrap(newValue)) {
+			m.replaced(k, v, newValue)
+		}
+		return ok
+	})
+}
+
+// UpdateRangeAtomic calls f sequentially for each key and value present in the map, as UpdateRange does,
+// but the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been applied.
+//
+// Unlike TypedMap, sync.Map cannot be locked: the values are applied one at a time, concurrent operations may observe part of them,
+// and a value is replaced only if it has not been changed since it was passed to f.
+func (m *SyncMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	var olds, keys []any
+	var values []V
+	completed := true
+	m.sm.Range(func(key, value any) bool {
+		newValue, ok := f(m.typed(key, value))
+		if !ok {
+			completed = false
+			return false
+		}
+		keys = append(keys, key)
+		olds = append(olds, value)
+		values = append(values, newValue)
+		return true
+	})
+	if !completed {
+		return false
+	}
+	for i, key := range keys {
+		if m.sm.CompareAndSwap(key, olds[i], m.wrap(values[i])) {
+			k, old := m.typed(key, olds[i])
+			m.replaced(k, old, values[i])
+		}
+	}
+	return true
+}
+
+// Exclusive calls f with a copy of the content of the map, once f returns the changes made by f are applied to the map:
+// missing entries are deleted, new and changed entries are stored.
+//
+// Unlike TypedMap, sync.Map cannot be locked: Exclusive does not prevent other operations from running concurrently with f,
+// changes applied to the map while f is running may be lost.
+// Values of non comparable types are always stored back.
+func (m *SyncMap[K, V]) Exclusive(f func(m map[K]V)) {
+	snapshot := make(map[K]V)
+	m.Range(func(key K, value V) bool {
+		snapshot[key] = value
+		return true
+	})
+	data := make(map[K]V, len(snapshot))
+	for key, value := range snapshot {
+		data[key] = value
+	}
+	f(data)
+	for key := range snapshot {
+		if _, ok := data[key]; !ok {
+			m.Delete(key)
+		}
+	}
+	for key, value := range data {
+		if old, ok := snapshot[key]; !ok || !removal.Same(old, value) {
+			m.Store(key, value)
+		}
+	}
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the staged writes are applied once f returns nil,
+// they are discarded if f returns an error or panics.
+//
+// Unlike TypedMap, sync.Map cannot be locked: the writes are applied one at a time, concurrent operations may observe
+// part of them and values loaded by the transaction may be changed concurrently before the writes are applied.
+func (m *SyncMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock returns a txn.Locked using the methods of the map, no lock is acquired: see Transaction.
+func (m *SyncMap[K, V]) TxLock() txn.Locked[K, V] {
+	return txn.Locked[K, V]{
+		Load:   m.Load,
+		Store:  m.Store,
+		Remove: m.Delete,
+		Unlock: func() {},
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+//
+// DeleteFunc is best-effort: as Range it does not correspond to any consistent snapshot of the map,
+// an entry is removed only if its value has not been changed since it was passed to f.
+func (m *SyncMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	m.sm.Range(func(key, value any) bool {
+		k, v := m.typed(key, value)
+		if !f(k, v) {
+			return true
+		}
+		if m.compareAndDelete(k, value) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+func (m *SyncMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of unique keys in the map.
+// It is an O(1) operation, the count reflects the operations completed when Len is called.
+// It is approximate if Clear runs concurrently with operations adding or removing keys.
+func (m *SyncMap[K, V]) Len() (n int) {
+	// a key might be deleted before the counter is incremented by the operation storing it.
+	return max(int(m.n.Load()), 0)
+}
+
+// Keys returns a slice of all the keys present in the map, an empty slice is returned if the map is empty.
+// As Range, it does not correspond to any consistent snapshot of the map.
+func (m *SyncMap[K, V]) Keys() (keys []K) {
+	keys = make([]K, 0, m.Len())
+	m.Range(func(key K, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, an empty slice is returned if the map is empty.
+// As Range, it does not correspond to any consistent snapshot of the map.
+func (m *SyncMap[K, V]) Values() (values []V) {
+	values = make([]V, 0, m.Len())
+	m.Range(func(_ K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map.
+// The slices are in the same order, as Range, they do not correspond to any consistent snapshot of the map.
+func (m *SyncMap[K, V]) Entries() (keys []K, values []V) {
+	n := m.Len()
+	keys, values = make([]K, 0, n), make([]V, 0, n)
+	m.Range(func(key K, value V) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	return keys, values
+}
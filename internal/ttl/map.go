@@ -0,0 +1,142 @@
+package ttl
+
+import (
+	"github.com/thetechpanda/typedmap/internal/derive"
+	"github.com/thetechpanda/typedmap/internal/removal"
+)
+
+// Store sets the value for a key, the entry expires after the default ttl.
+func (m *TypedMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.
+// The ok result indicates whether value was found in the map and has not expired.
+func (m *TypedMap[K, V]) Load(key K) (v V, ok bool) {
+	e, ok := m.load(key)
+	return e.value, ok
+}
+
+// LoadOrStore returns the existing value for the key if present and not expired.
+// Otherwise, it stores and returns the given value, the entry expires after the default ttl.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *TypedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if e, ok := m.get(key, now); ok {
+		return e.value, true
+	}
+	m.put(&b, key, m.newEntry(value), now)
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present and not expired.
+func (m *TypedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	e, loaded := m.get(key, now)
+	m.remove(&b, key, now, removal.Deleted)
+	return e.value, loaded
+}
+
+// Delete removes the key from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present and not expired.
+// The new entry expires after the default ttl.
+func (m *TypedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	e, loaded := m.get(key, now)
+	m.put(&b, key, m.newEntry(value), now)
+	return e.value, loaded
+}
+
+// CompareAndSwap swaps the old and new values for key
+// if the value stored in the map is equal to old and has not expired.
+// The new entry expires after the default ttl.
+//
+// Values are compared using the equality function of the map, if V is not comparable
+// and no equality function is available this function will return false, see TryCompareAndSwap.
+//
+// Returns true if the swap was performed.
+func (m *TypedMap[K, V]) CompareAndSwap(key K, old, new V) bool {
+	if !m.ValuesComparable() {
+		return false
+	}
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	e, ok := m.get(key, now)
+	if !ok || !m.ValuesEqual(e.value, old) {
+		return false
+	}
+	m.put(&b, key, m.newEntry(new), now)
+	return true
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old and has not expired.
+// Values are compared as CompareAndSwap does, if they cannot be compared this function will return false, see TryCompareAndDelete.
+//
+// If there is no current value for key in the map, CompareAndDelete
+// returns false (even if the old value is the nil interface value).
+func (m *TypedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	if !m.ValuesComparable() {
+		return false
+	}
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	e, ok := m.get(key, now)
+	if !ok || !m.ValuesEqual(e.value, old) {
+		return false
+	}
+	m.remove(&b, key, now, removal.Deleted)
+	return true
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	return derive.TryCompareAndSwap(m.ValuesComparable(), m.CompareAndSwap, key, old, new)
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns equality.ErrNotComparable if the values cannot be compared.
+func (m *TypedMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	return derive.TryCompareAndDelete(m.ValuesComparable(), m.CompareAndDelete, key, old)
+}
+
+// Range calls f sequentially for each key and value present in the map that has not expired.
+// If f returns false, Range stops the iteration.
+// Avoid invoking any map functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Range(f func(K, V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := m.now()
+	for key, e := range m.data {
+		if e.expired(now) {
+			continue
+		}
+		if !f(key, e.value) {
+			break
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package ttl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// entry is a value stored in the map along with its expiration deadline.
+// A zero deadline means the entry never expires.
+type entry[V any] struct {
+	value    V
+	deadline time.Time
+}
+
+// expired returns true if the entry deadline has passed at the given time.
+func (e entry[V]) expired(now time.Time) bool {
+	return !e.deadline.IsZero() && !now.Before(e.deadline)
+}
+
+// TypedMap implements a thread-safe map where each entry expires after a given duration.
+// Expired entries are never returned, they are removed lazily when accessed, by Sweep or by the background sweeper.
+type TypedMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	equality.Comparer[V]
+	txn.ID
+	data       map[K]entry[V]
+	defaultTTL time.Duration
+	now        func() time.Time
+	onRemove   removal.Hook[K, V]
+	stop       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New returns a new TypedMap where entries expire after defaultTTL, if defaultTTL is not positive entries never expire by default.
+// now is used as the clock of the map, if nil time.Now is used.
+// If sweepInterval is positive a background goroutine removes expired entries at every interval until Close is called.
+// If onRemove is not nil it is called, without holding the map lock, for every entry removed from the map.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](defaultTTL time.Duration, now func() time.Time, sweepInterval time.Duration, onRemove removal.Hook[K, V], equal equality.Func[V]) *TypedMap[K, V] {
+	if now == nil {
+		now = time.Now
+	}
+	m := &TypedMap[K, V]{
+		Comparer:   equality.NewComparer(equal, equality.Deep[V]),
+		ID:         txn.NewID(),
+		data:       make(map[K]entry[V]),
+		defaultTTL: defaultTTL,
+		now:        now,
+		onRemove:   onRemove,
+		stop:       make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go m.sweeper(sweepInterval)
+	}
+	return m
+}
+
+// sweeper removes expired entries at every interval until the map is closed.
+func (m *TypedMap[K, V]) sweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.Sweep()
+		}
+	}
+}
+
+// Close stops the background sweeper, if any. The map can still be used after Close.
+// Close is idempotent.
+func (m *TypedMap[K, V]) Close() {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+// Sweep removes all the expired entries from the map and returns how many were removed.
+func (m *TypedMap[K, V]) Sweep() (n int) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, e := range m.data {
+		if e.expired(now) {
+			delete(m.data, key)
+			b.Add(key, e.value, removal.Expired)
+			n++
+		}
+	}
+	return n
+}
+
+// StoreWithTTL sets the value for a key, the entry expires after ttl.
+// If ttl is not positive the entry never expires.
+func (m *TypedMap[K, V]) StoreWithTTL(key K, value V, ttl time.Duration) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.put(&b, key, entry[V]{value: value, deadline: m.deadline(ttl)}, m.now())
+}
+
+// Deadline returns the time at which the entry for key expires.
+// The ok result is false if the key is not present or has expired,
+// a zero deadline with ok set to true means the entry never expires.
+func (m *TypedMap[K, V]) Deadline(key K) (deadline time.Time, ok bool) {
+	e, ok := m.load(key)
+	return e.deadline, ok
+}
+
+// deadline returns the deadline of an entry stored now that expires after ttl.
+func (m *TypedMap[K, V]) deadline(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return m.now().Add(ttl)
+}
+
+// newEntry returns an entry for value that expires after the default ttl.
+func (m *TypedMap[K, V]) newEntry(value V) entry[V] {
+	return entry[V]{value: value, deadline: m.deadline(m.defaultTTL)}
+}
+
+// load returns the entry for key if present and not expired.
+// Expired entries are removed from the map.
+func (m *TypedMap[K, V]) load(key K) (e entry[V], ok bool) {
+	m.mu.RLock()
+	e, ok = m.data[key]
+	m.mu.RUnlock()
+	if !ok || !e.expired(m.now()) {
+		return e, ok
+	}
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// the entry might have been replaced while the lock was released.
+	now := m.now()
+	if e, ok = m.data[key]; ok && e.expired(now) {
+		delete(m.data, key)
+		b.Add(key, e.value, removal.Expired)
+	}
+	return m.get(key, now)
+}
+
+// get returns the entry for key if present and not expired, the caller must hold the lock.
+func (m *TypedMap[K, V]) get(key K, now time.Time) (e entry[V], ok bool) {
+	e, ok = m.data[key]
+	if !ok || e.expired(now) {
+		return entry[V]{}, false
+	}
+	return e, true
+}
+
+// put stores e for key, recording in b the removal of the previous entry if any.
+// The caller must hold the lock.
+func (m *TypedMap[K, V]) put(b *removal.Batch[K, V], key K, e entry[V], now time.Time) {
+	if old, ok := m.data[key]; ok {
+		if old.expired(now) {
+			b.Add(key, old.value, removal.Expired)
+		} else {
+			b.Replace(key, old.value, e.value)
+		}
+	}
+	m.data[key] = e
+}
+
+// remove deletes the entry for key, recording in b its removal for the given reason, or as expired if it has expired.
+// The caller must hold the lock.
+func (m *TypedMap[K, V]) remove(b *removal.Batch[K, V], key K, now time.Time, reason removal.Reason) {
+	e, ok := m.data[key]
+	if !ok {
+		return
+	}
+	delete(m.data, key)
+	if e.expired(now) {
+		reason = removal.Expired
+	}
+	b.Add(key, e.value, reason)
+}
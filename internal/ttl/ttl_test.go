@@ -0,0 +1,479 @@
+package ttl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/ttl"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestNew(t *testing.T) {
+	m := ttl.New[string, int](time.Minute, nil, 0, nil, nil)
+	defer m.Close()
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected a new map, got map with length %d", m.Len())
+	}
+	m.Store("key", 42)
+	if v, ok := m.Load("key"); !ok || v != 42 {
+		t.Errorf("Load(): Expected value 42, got %d", v)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	c := newClock()
+	m := ttl.New[string, int](time.Minute, c.Now, 0, nil, nil)
+	m.Store("key", 42)
+	m.StoreWithTTL("short", 1, time.Second)
+	m.StoreWithTTL("forever", 2, 0)
+
+	if d, ok := m.Deadline("key"); !ok || !d.Equal(c.Now().Add(time.Minute)) {
+		t.Errorf("Deadline(): Expected deadline in one minute, got %v", d)
+	}
+	if d, ok := m.Deadline("forever"); !ok || !d.IsZero() {
+		t.Errorf("Deadline(): Expected zero deadline, got %v", d)
+	}
+
+	c.Advance(time.Second)
+	if m.Has("short") {
+		t.Errorf("Has(): Expected key %q to be expired", "short")
+	}
+	if _, ok := m.Deadline("short"); ok {
+		t.Errorf("Deadline(): Expected key %q to be expired", "short")
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len(): Expected length 2, got %d", m.Len())
+	}
+
+	c.Advance(time.Minute)
+	if _, ok := m.Load("key"); ok {
+		t.Errorf("Load(): Expected key %q to be expired", "key")
+	}
+	if v, ok := m.Load("forever"); !ok || v != 2 {
+		t.Errorf("Load(): Expected key %q to never expire", "forever")
+	}
+	if keys := m.Keys(); len(keys) != 1 || keys[0] != "forever" {
+		t.Errorf("Keys(): Expected only key %q, got %v", "forever", keys)
+	}
+	if values := m.Values(); len(values) != 1 || values[0] != 2 {
+		t.Errorf("Values(): Expected only value 2, got %v", values)
+	}
+	if keys, values := m.Entries(); len(keys) != 1 || len(values) != 1 {
+		t.Errorf("Entries(): Expected a single entry, got %v %v", keys, values)
+	}
+	count := 0
+	m.Range(func(k string, v int) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Errorf("Range(): Expected a single entry, got %d", count)
+	}
+}
+
+func TestExpiredEntriesAreMissing(t *testing.T) {
+	c := newClock()
+	m := ttl.New[string, int](time.Second, c.Now, 0, nil, nil)
+	reset := func() {
+		m.Store("key", 42)
+		c.Advance(time.Second)
+	}
+
+	reset()
+	if v, loaded := m.LoadOrStore("key", 43); loaded || v != 43 {
+		t.Errorf("LoadOrStore(): Expected expired key to be stored, got %d", v)
+	}
+	reset()
+	if _, loaded := m.LoadAndDelete("key"); loaded {
+		t.Errorf("LoadAndDelete(): Expected expired key not to be loaded")
+	}
+	reset()
+	if _, loaded := m.Swap("key", 43); loaded {
+		t.Errorf("Swap(): Expected expired key not to be loaded")
+	}
+	reset()
+	if m.CompareAndSwap("key", 42, 43) {
+		t.Errorf("CompareAndSwap(): Expected expired key not to be swapped")
+	}
+	if m.CompareAndDelete("key", 42) {
+		t.Errorf("CompareAndDelete(): Expected expired key not to be deleted")
+	}
+	m.Update("key", func(v int, ok bool) int {
+		if ok {
+			t.Errorf("Update(): Expected expired key to be missing")
+		}
+		return 1
+	})
+	if v, ok := m.Load("key"); !ok || v != 1 {
+		t.Errorf("Load(): Expected updated value 1, got %d", v)
+	}
+}
+
+func TestMapOperations(t *testing.T) {
+	m := ttl.New[string, int](time.Minute, nil, 0, nil, nil)
+	if _, loaded := m.LoadOrStore("key", 42); loaded {
+		t.Errorf("LoadOrStore(): Expected key to be stored")
+	}
+	if v, loaded := m.LoadOrStore("key", 43); !loaded || v != 42 {
+		t.Errorf("LoadOrStore(): Expected value 42 to be loaded, got %d", v)
+	}
+	if v, loaded := m.Swap("key", 43); !loaded || v != 42 {
+		t.Errorf("Swap(): Expected previous value 42, got %d", v)
+	}
+	if m.CompareAndSwap("key", 42, 44) {
+		t.Errorf("CompareAndSwap(): Expected key not to be swapped")
+	}
+	if !m.CompareAndSwap("key", 43, 44) {
+		t.Errorf("CompareAndSwap(): Expected key to be swapped")
+	}
+	if m.CompareAndDelete("key", 43) {
+		t.Errorf("CompareAndDelete(): Expected key not to be deleted")
+	}
+	if !m.CompareAndDelete("key", 44) {
+		t.Errorf("CompareAndDelete(): Expected key to be deleted")
+	}
+	m.Store("key", 1)
+	if v, loaded := m.LoadAndDelete("key"); !loaded || v != 1 {
+		t.Errorf("LoadAndDelete(): Expected value 1, got %d", v)
+	}
+	m.Store("key", 1)
+	m.Delete("key")
+	if m.Has("key") {
+		t.Errorf("Has(): Expected key to be deleted")
+	}
+
+	n := ttl.New[int, []int](time.Minute, nil, 0, nil, nil)
+	n.Store(1, []int{1})
+	if n.CompareAndSwap(1, []int{1}, []int{2}) || n.CompareAndDelete(1, []int{1}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := n.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := n.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := ttl.New[int, []int](time.Minute, nil, 0, nil, slices.Equal[[]int])
+	e.Store(1, []int{1})
+	if swapped, err := e.TryCompareAndSwap(1, []int{1}, []int{2}); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+	if deleted, err := e.TryCompareAndDelete(1, []int{2}); !deleted || err != nil || e.Has(1) {
+		t.Errorf("TryCompareAndDelete(): Expected equal value to be deleted, got %v, %v", deleted, err)
+	}
+}
+
+func TestUpdateRangeAndExclusive(t *testing.T) {
+	c := newClock()
+	m := ttl.New[int, int](time.Minute, c.Now, 0, nil, nil)
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	m.StoreWithTTL(100, 100, time.Second)
+	c.Advance(time.Second)
+
+	m.UpdateRange(func(k, v int) (int, bool) {
+		if k == 100 {
+			t.Errorf("UpdateRange(): Expected expired key to be skipped")
+		}
+		return v + 1, true
+	})
+	m.UpdateRange(func(k, v int) (int, bool) {
+		return 0, false
+	})
+	if v, _ := m.Load(5); v != 6 {
+		t.Errorf("UpdateRange(): Expected value 6, got %d", v)
+	}
+
+	m.Exclusive(func(data map[int]int) {
+		if _, ok := data[100]; ok {
+			t.Errorf("Exclusive(): Expected expired key to be missing")
+		}
+		delete(data, 0)
+		data[5] = 50
+		data[200] = 200
+	})
+	if m.Has(0) {
+		t.Errorf("Exclusive(): Expected key 0 to be deleted")
+	}
+	if d, _ := m.Deadline(5); !d.Equal(c.Now().Add(time.Minute - time.Second)) {
+		t.Errorf("Exclusive(): Expected key 5 to keep its deadline, got %v", d)
+	}
+	if d, _ := m.Deadline(200); !d.Equal(c.Now().Add(time.Minute)) {
+		t.Errorf("Exclusive(): Expected key 200 to expire after the default ttl, got %v", d)
+	}
+	if m.Len() != 10 {
+		t.Errorf("Len(): Expected length 10, got %d", m.Len())
+	}
+
+	m.Clear()
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected length 0, got %d", m.Len())
+	}
+}
+
+func TestSweep(t *testing.T) {
+	c := newClock()
+	m := ttl.New[int, int](time.Second, c.Now, 0, nil, nil)
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	m.StoreWithTTL(10, 10, time.Hour)
+	c.Advance(time.Second)
+	if n := m.Sweep(); n != 10 {
+		t.Errorf("Sweep(): Expected 10 entries to be removed, got %d", n)
+	}
+	if n := m.Sweep(); n != 0 {
+		t.Errorf("Sweep(): Expected no entries to be removed, got %d", n)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len(): Expected length 1, got %d", m.Len())
+	}
+}
+
+func TestBackgroundSweeper(t *testing.T) {
+	c := newClock()
+	var calls atomic.Int64
+	now := func() time.Time {
+		calls.Add(1)
+		return c.Now()
+	}
+	m := ttl.New[int, int](time.Second, now, time.Millisecond, nil, nil)
+	defer m.Close()
+	m.Store(1, 1)
+	c.Advance(time.Second)
+	// the sweeper reads the clock while holding the lock, once the clock has been read again
+	// the next Sweep can only run after the sweeper has removed the expired entry.
+	start := calls.Load()
+	for calls.Load() == start {
+		time.Sleep(time.Millisecond)
+	}
+	if n := m.Sweep(); n != 0 {
+		t.Errorf("Sweep(): Expected the sweeper to remove the expired entry, got %d removed", n)
+	}
+	m.Close()
+	m.Close()
+}
+
+func TestIterators(t *testing.T) {
+	c := newClock()
+	m := ttl.New[int, int](time.Minute, c.Now, 0, nil, nil)
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	m.StoreWithTTL(10, 10, time.Second)
+	c.Advance(time.Second)
+	count := 0
+	for k, v := range m.All() {
+		if k != v || k == 10 {
+			t.Errorf("All(): Unexpected entry %d %d", k, v)
+		}
+		count++
+	}
+	for range m.KeysSeq() {
+		count++
+	}
+	for range m.ValuesSeq() {
+		count++
+		break
+	}
+	if count != 21 {
+		t.Errorf("Expected 21 iterations, got %d", count)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	m := ttl.New[int, int](time.Millisecond, nil, time.Millisecond, nil, nil)
+	defer m.Close()
+	numGoroutines := 100
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < numGoroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			// uses context done to have all goroutines start at the same time
+			<-ctx.Done()
+			for j := 0; j < numGoroutines; j++ {
+				if _, ok := m.Load(j); !ok {
+					m.Store(j, i*i)
+				}
+				m.Update(j, func(v int, ok bool) int { return v + 1 })
+				m.Len()
+				m.Delete(j)
+			}
+		}(i)
+	}
+	cancel()
+	wg.Wait()
+	if m.Len() != 0 {
+		t.Errorf("Len(): Expected length 0, got %d", m.Len())
+	}
+}
+
+// removals records the entries notified by the removal hook.
+type removals struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *removals) hook(key string, value int, reason removal.Reason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf("%s=%d:%s", key, value, reason))
+}
+
+// take returns the recorded events, sorted, and resets them.
+func (r *removals) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	slices.Sort(events)
+	return events
+}
+
+func TestOnRemove(t *testing.T) {
+	c := newClock()
+	r := &removals{}
+	m := ttl.New(time.Minute, c.Now, 0, r.hook, nil)
+	check := func(op string, expect ...string) {
+		t.Helper()
+		if got := r.take(); !slices.Equal(got, expect) {
+			t.Errorf("%s: Expected removals %v, got %v", op, expect, got)
+		}
+	}
+	m.Store("a", 1)
+	m.Store("a", 1)
+	check("Store()")
+	m.Store("a", 2)
+	check("Store()", "a=1:Replaced")
+	m.Swap("a", 3)
+	check("Swap()", "a=2:Replaced")
+	m.CompareAndSwap("a", 3, 4)
+	check("CompareAndSwap()", "a=3:Replaced")
+	m.Update("a", func(v int, ok bool) int { return 5 })
+	check("Update()", "a=4:Replaced")
+	m.StoreWithTTL("a", 6, time.Second)
+	check("StoreWithTTL()", "a=5:Replaced")
+	m.Delete("a")
+	check("Delete()", "a=6:Deleted")
+	m.Store("b", 1)
+	m.CompareAndDelete("b", 1)
+	check("CompareAndDelete()", "b=1:Deleted")
+
+	// expired entries are reported once, by whichever operation finds them.
+	m.StoreWithTTL("load", 1, time.Second)
+	m.StoreWithTTL("sweep", 2, time.Second)
+	m.StoreWithTTL("store", 3, time.Second)
+	m.StoreWithTTL("delete", 4, time.Second)
+	m.StoreWithTTL("clear", 5, time.Second)
+	m.Store("keep", 6)
+	c.Advance(2 * time.Second)
+	m.Load("load")
+	check("Load()", "load=1:Expired")
+	m.Store("store", 30)
+	check("Store()", "store=3:Expired")
+	m.Delete("delete")
+	check("Delete()", "delete=4:Expired")
+	m.Sweep()
+	check("Sweep()", "clear=5:Expired", "sweep=2:Expired")
+	m.StoreWithTTL("clear", 5, time.Second)
+	c.Advance(2 * time.Second)
+	m.Clear()
+	check("Clear()", "clear=5:Expired", "keep=6:Cleared", "store=30:Cleared")
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.UpdateRange(func(k string, v int) (int, bool) { return v * 10, true })
+	check("UpdateRange()", "a=1:Replaced", "b=2:Replaced")
+	m.StoreWithTTL("c", 3, time.Second)
+	c.Advance(2 * time.Second)
+	m.Exclusive(func(data map[string]int) {
+		delete(data, "a")
+		data["b"] = 200
+	})
+	check("Exclusive()", "a=10:Deleted", "b=20:Replaced", "c=3:Expired")
+
+	// the hook is called without holding the lock, so it may use the map.
+	var n *ttl.TypedMap[string, int]
+	var present bool
+	n = ttl.New(time.Minute, c.Now, 0, func(key string, value int, reason removal.Reason) {
+		_, present = n.Load(key)
+	}, nil)
+	n.StoreWithTTL("key", 1, time.Second)
+	c.Advance(2 * time.Second)
+	n.Sweep()
+	if present {
+		t.Error("Expected the expired entry to be removed before the hook is called")
+	}
+}
+
+func TestDeleteFuncExpired(t *testing.T) {
+	c := newClock()
+	r := &removals{}
+	m := ttl.New(time.Minute, c.Now, 0, r.hook, nil)
+	m.StoreWithTTL("expired", 1, time.Second)
+	m.Store("a", 2)
+	c.Advance(time.Second)
+	if n := m.DeleteFunc(func(k string, v int) bool {
+		if k == "expired" {
+			t.Error("DeleteFunc(): Expected expired entries to be skipped")
+		}
+		return true
+	}); n != 1 {
+		t.Errorf("DeleteFunc(): Expected 1 entry to be removed, got %d", n)
+	}
+	if events := r.take(); !slices.Equal(events, []string{"a=2:Deleted"}) {
+		t.Errorf("DeleteFunc(): Expected removals [a=2:Deleted], got %v", events)
+	}
+}
+
+func TestUpdateRangeAtomic(t *testing.T) {
+	c := newClock()
+	m := ttl.New[int, int](time.Minute, c.Now, 0, nil, nil)
+	m.Store(1, 1)
+	// expired entries are skipped.
+	m.StoreWithTTL(2, 1, time.Second)
+	c.Advance(time.Second)
+	calls := 0
+	if !m.UpdateRangeAtomic(func(k, v int) (int, bool) {
+		calls++
+		return v + 1, true
+	}) || calls != 1 {
+		t.Errorf("UpdateRangeAtomic(): Expected only the live entry to be visited, got %d calls", calls)
+	}
+	if v, ok := m.Load(1); !ok || v != 2 {
+		t.Errorf("UpdateRangeAtomic(): Expected 2, got %d, %v", v, ok)
+	}
+}
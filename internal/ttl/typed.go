@@ -0,0 +1,258 @@
+package ttl
+
+import (
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Clear removes all items from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Clear() {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key := range m.data {
+		m.remove(&b, key, now, removal.Cleared)
+	}
+	m.data = make(map[K]entry[V])
+}
+
+// Has returns true if the map contains the key and it has not expired.
+func (m *TypedMap[K, V]) Has(key K) bool {
+	_, ok := m.load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+// Expired entries are passed to f as missing, the updated entry expires after the default ttl.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	e, ok := m.get(key, now)
+	m.put(&b, key, m.newEntry(f(e.value, ok)), now)
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+// Expired entries are skipped, updated entries keep their deadline.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, e := range m.data {
+		if e.expired(now) {
+			continue
+		}
+		newValue, ok := f(key, e.value)
+		if !ok {
+			return
+		}
+		b.Replace(key, e.value, newValue)
+		e.value = newValue
+		m.data[key] = e
+	}
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated.
+// Expired entries are skipped, updated entries keep their deadline.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	values := make(map[K]V, len(m.data))
+	for key, e := range m.data {
+		if e.expired(now) {
+			continue
+		}
+		newValue, ok := f(key, e.value)
+		if !ok {
+			return false
+		}
+		values[key] = newValue
+	}
+	for key, value := range values {
+		e := m.data[key]
+		b.Replace(key, e.value, value)
+		e.value = value
+		m.data[key] = e
+	}
+	return true
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+//
+// f receives a map containing the entries that have not expired, once f returns the changes are applied to the map:
+// entries that are still present keep their deadline, new entries expire after the default ttl and missing entries are removed.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	data := make(map[K]V, len(m.data))
+	for key, e := range m.data {
+		if !e.expired(now) {
+			data[key] = e.value
+		}
+	}
+	f(data)
+	next := make(map[K]entry[V], len(data))
+	for key, value := range data {
+		e, ok := m.get(key, now)
+		if !ok {
+			// records the removal of the expired entry, if any.
+			m.remove(&b, key, now, removal.Deleted)
+			next[key] = m.newEntry(value)
+			continue
+		}
+		b.Replace(key, e.value, value)
+		e.value = value
+		next[key] = e
+	}
+	for key := range m.data {
+		if _, ok := next[key]; !ok {
+			m.remove(&b, key, now, removal.Deleted)
+		}
+	}
+	m.data = next
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics.
+// Expired entries are loaded as missing, stored entries expire after the default ttl.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of the map, the returned txn.Locked gives access to its content until it is unlocked.
+// Expired entries are loaded as missing, stored entries expire after the default ttl.
+// The removal hook is notified by Notify, once the locks of every map taking part in the transaction are released.
+func (m *TypedMap[K, V]) TxLock() txn.Locked[K, V] {
+	b := removal.NewBatch(m.onRemove)
+	m.mu.Lock()
+	now := m.now()
+	return txn.Locked[K, V]{
+		Load: func(key K) (V, bool) {
+			e, ok := m.get(key, now)
+			return e.value, ok
+		},
+		Store: func(key K, value V) {
+			m.put(&b, key, m.newEntry(value), now)
+		},
+		Remove: func(key K) {
+			m.remove(&b, key, now, removal.Deleted)
+		},
+		Unlock: m.mu.Unlock,
+		Notify: b.Notify,
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The map is locked for the duration of the iteration, so the entries are removed atomically.
+// Expired entries are skipped.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	b := removal.NewBatch(m.onRemove)
+	defer b.Notify()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, e := range m.data {
+		if !e.expired(now) && f(key, e.value) {
+			m.remove(&b, key, now, removal.Deleted)
+			n++
+		}
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map that have not expired.
+// This is an O(N) operation with the number of items stored in the map.
+func (m *TypedMap[K, V]) Len() (n int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := m.now()
+	for _, e := range m.data {
+		if !e.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Keys returns a slice of all the keys present in the map that have not expired, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Keys() (keys []K) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := m.now()
+	keys = make([]K, 0, len(m.data))
+	for key, e := range m.data {
+		if !e.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map that have not expired, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Values() (values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := m.now()
+	values = make([]V, 0, len(m.data))
+	for _, e := range m.data {
+		if !e.expired(now) {
+			values = append(values, e.value)
+		}
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map that have not expired.
+func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := m.now()
+	keys = make([]K, 0, len(m.data))
+	values = make([]V, 0, len(m.data))
+	for key, e := range m.data {
+		if !e.expired(now) {
+			keys = append(keys, key)
+			values = append(values, e.value)
+		}
+	}
+	return keys, values
+}
@@ -0,0 +1,286 @@
+// Package txn provides the transactions used to apply several writes to one or more maps atomically.
+package txn
+
+import (
+	"cmp"
+	"slices"
+	"sync/atomic"
+)
+
+// Tx is a transaction on a map: writes are staged, reads see the staged writes,
+// and the writes are applied to the map only once the transaction function returns without error.
+type Tx[K, V any] interface {
+	// Load returns the value for a key, as staged by the transaction or as stored in the map.
+	// The ok result indicates whether value was found.
+	Load(key K) (value V, ok bool)
+	// Store stages the value for a key.
+	Store(key K, value V)
+	// Delete stages the removal of a key.
+	Delete(key K)
+}
+
+// Locked gives access to the content of a map while its lock is held.
+type Locked[K, V any] struct {
+	// Load returns the value stored in the map for a key.
+	Load func(K) (V, bool)
+	// Store sets the value for a key, as the Store function of the map does.
+	Store func(K, V)
+	// Remove deletes a key, if present.
+	Remove func(K)
+	// Unlock releases the lock of the map, once the lock is released the functions above must not be used.
+	Unlock func()
+	// Notify, if not nil, calls the removal hook of the map for the entries removed while the lock was held.
+	// It is called once the locks of every map taking part in the transaction have been released,
+	// so that the hook can use any of them.
+	Notify func()
+}
+
+// Map is implemented by the maps that can take part in a transaction.
+type Map[K, V any] interface {
+	// TxID returns the id of the map, the maps taking part in a Group are locked in increasing order of id.
+	TxID() uint64
+	// TxLock acquires the lock of the map.
+	TxLock() Locked[K, V]
+}
+
+// RLocked gives access to the content of a map while its read lock is held.
+type RLocked[K, V any] struct {
+	// Load returns the value stored in the map for a key.
+	Load func(K) (V, bool)
+	// Range calls f for each key and value present in the map, until f returns false.
+	Range func(f func(K, V) bool)
+	// Len returns the number of keys in the map.
+	Len func() int
+	// RUnlock releases the read lock of the map, once the lock is released the functions above must not be used.
+	RUnlock func()
+}
+
+// Reader is implemented by the maps whose content can be read while holding their read lock,
+// the read locks of several maps must be taken in increasing order of TxID, as the locks of a Group are.
+type Reader[K, V any] interface {
+	// TxID returns the id of the map, see Map.
+	TxID() uint64
+	// TxRLock acquires the read lock of the map.
+	TxRLock() RLocked[K, V]
+}
+
+// ids is the last id returned by NewID.
+var ids atomic.Uint64
+
+// ID is the id of a Map, it is embedded by the maps to implement TxID.
+type ID uint64
+
+// NewID returns a new ID, ids are unique for the lifetime of the process.
+func NewID() ID {
+	return ID(ids.Add(1))
+}
+
+// TxID returns the id of the map, the maps taking part in a Group are locked in increasing order of id.
+func (id ID) TxID() uint64 {
+	return uint64(id)
+}
+
+// write is a staged write, deleted is true for a removal.
+type write[V any] struct {
+	value   V
+	deleted bool
+	// removed is true if the key is stored after being deleted by the transaction: it is removed before being stored,
+	// so that the value is inserted as a new entry.
+	removed bool
+	// seq is the position of the last write of the key in the log.
+	seq int
+}
+
+// staged implements Tx recording the writes until they are committed.
+type staged[K comparable, V any] struct {
+	// locked gives access to the map, it is nil when the transaction function is not running.
+	locked *Locked[K, V]
+	writes map[K]write[V]
+	// log holds the keys in the order they have been written.
+	log []K
+}
+
+// Load returns the value staged for key, if any, otherwise the value stored in the map.
+func (tx *staged[K, V]) Load(key K) (value V, ok bool) {
+	tx.check()
+	if w, ok := tx.writes[key]; ok {
+		return w.value, !w.deleted
+	}
+	return tx.locked.Load(key)
+}
+
+// Store stages the value for key.
+func (tx *staged[K, V]) Store(key K, value V) {
+	tx.stage(key, write[V]{value: value})
+}
+
+// Delete stages the removal of key.
+func (tx *staged[K, V]) Delete(key K) {
+	tx.stage(key, write[V]{deleted: true})
+}
+
+// stage records w as the last write of key.
+func (tx *staged[K, V]) stage(key K, w write[V]) {
+	tx.check()
+	if tx.writes == nil {
+		tx.writes = make(map[K]write[V])
+	}
+	if prev, ok := tx.writes[key]; ok && !w.deleted {
+		w.removed = prev.deleted || prev.removed
+	}
+	w.seq = len(tx.log)
+	tx.writes[key] = w
+	tx.log = append(tx.log, key)
+}
+
+// check panics if the transaction is used while its function is not running.
+func (tx *staged[K, V]) check() {
+	if tx.locked == nil {
+		panic("typedmap: transaction used outside its function")
+	}
+}
+
+// begin discards the staged writes and gives the transaction access to the map through l.
+func (tx *staged[K, V]) begin(l *Locked[K, V]) {
+	tx.locked = l
+	tx.writes = nil
+	tx.log = nil
+}
+
+// commit applies the staged writes: first the removals, then the values, each in the order of the last write of the key,
+// so that bounded maps do not evict entries to make room for keys being removed.
+// A key deleted then stored again is removed along with the others, then stored as a new entry.
+func (tx *staged[K, V]) commit() {
+	for _, deleted := range []bool{true, false} {
+		for i, key := range tx.log {
+			w := tx.writes[key]
+			// skips the keys overwritten by a later write.
+			if w.seq != i {
+				continue
+			}
+			if deleted && (w.deleted || w.removed) {
+				tx.locked.Remove(key)
+			}
+			if !deleted && !w.deleted {
+				tx.locked.Store(key, w.value)
+			}
+		}
+	}
+}
+
+// end releases the lock of the map and returns its Notify function, the transaction can no longer be used.
+func (tx *staged[K, V]) end() (notify func()) {
+	l := tx.locked
+	tx.locked = nil
+	l.Unlock()
+	return l.Notify
+}
+
+// Run locks m and calls f with a new transaction on m. If f returns nil the staged writes are applied, see Group.Run,
+// otherwise they are discarded and the error is returned. If f panics the writes are discarded and the panic is propagated.
+func Run[K comparable, V any](m Map[K, V], f func(tx Tx[K, V]) error) error {
+	g := &Group{}
+	tx := Enlist(g, m)
+	return g.Run(func() error {
+		return f(tx)
+	})
+}
+
+// member is a map enlisted in a Group.
+type member interface {
+	id() uint64
+	lock()
+	commit()
+	unlock() (notify func())
+}
+
+// enlisted is a map enlisted in a Group along with its transaction.
+type enlisted[K comparable, V any] struct {
+	m  Map[K, V]
+	tx staged[K, V]
+}
+
+func (e *enlisted[K, V]) id() uint64 {
+	return e.m.TxID()
+}
+
+func (e *enlisted[K, V]) lock() {
+	l := e.m.TxLock()
+	e.tx.begin(&l)
+}
+
+func (e *enlisted[K, V]) commit() {
+	e.tx.commit()
+}
+
+func (e *enlisted[K, V]) unlock() (notify func()) {
+	return e.tx.end()
+}
+
+// Group is a transaction spanning several maps, the zero value is an empty Group ready to use.
+type Group struct {
+	members []member
+	running bool
+}
+
+// Enlist adds m to the maps of g and returns the transaction on m, enlisting the same map again returns the same transaction.
+// The transaction can only be used within the function passed to Run.
+//
+// Enlist panics if called while Run is running, if m is already enlisted with different types it panics as well.
+func Enlist[K comparable, V any](g *Group, m Map[K, V]) Tx[K, V] {
+	if g.running {
+		panic("typedmap: map enlisted while the transaction is running")
+	}
+	id := m.TxID()
+	for _, e := range g.members {
+		if e.id() == id {
+			return &e.(*enlisted[K, V]).tx
+		}
+	}
+	e := &enlisted[K, V]{m: m}
+	g.members = append(g.members, e)
+	return &e.tx
+}
+
+// Run locks the enlisted maps, in increasing order of id so that groups sharing maps cannot deadlock, and calls f.
+// If f returns nil the writes staged on each map are applied, before releasing any lock: first the removals, then the values,
+// each in the order of the last write of the key, a key deleted then stored again is removed, then stored. Otherwise the writes are discarded and the error is returned.
+// The Notify functions of the maps are called once every lock has been released.
+// If f panics the writes are discarded and the panic is propagated.
+//
+// Run can be called again, for example to retry the transaction, each call starts with no staged writes.
+func (g *Group) Run(f func() error) error {
+	if g.running {
+		panic("typedmap: transaction run while already running")
+	}
+	slices.SortFunc(g.members, func(a, b member) int {
+		return cmp.Compare(a.id(), b.id())
+	})
+	g.running = true
+	locked := 0
+	defer func() {
+		// releases the locks in reverse order, also when f panics, then notifies the removals:
+		// the removal hooks run once no map of the group is locked.
+		notify := make([]func(), 0, locked)
+		for i := locked - 1; i >= 0; i-- {
+			if n := g.members[i].unlock(); n != nil {
+				notify = append(notify, n)
+			}
+		}
+		g.running = false
+		for _, n := range notify {
+			n()
+		}
+	}()
+	for _, e := range g.members {
+		e.lock()
+		locked++
+	}
+	if err := f(); err != nil {
+		return err
+	}
+	for _, e := range g.members {
+		e.commit()
+	}
+	return nil
+}
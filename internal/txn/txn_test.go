@@ -0,0 +1,197 @@
+package txn_test
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// testMap is a Map over a plain map, recording the operations in ops.
+type testMap struct {
+	txn.ID
+	data map[string]int
+	ops  *[]string
+}
+
+func newTestMap(data map[string]int, ops *[]string) *testMap {
+	return &testMap{ID: txn.NewID(), data: data, ops: ops}
+}
+
+func (m *testMap) TxLock() txn.Locked[string, int] {
+	name := strconv.FormatUint(m.TxID(), 10)
+	*m.ops = append(*m.ops, "lock "+name)
+	return txn.Locked[string, int]{
+		Load: func(key string) (int, bool) {
+			v, ok := m.data[key]
+			return v, ok
+		},
+		Store: func(key string, value int) {
+			*m.ops = append(*m.ops, "store "+key)
+			m.data[key] = value
+		},
+		Remove: func(key string) {
+			*m.ops = append(*m.ops, "delete "+key)
+			delete(m.data, key)
+		},
+		Unlock: func() {
+			*m.ops = append(*m.ops, "unlock "+name)
+		},
+		Notify: func() {
+			*m.ops = append(*m.ops, "notify "+name)
+		},
+	}
+}
+
+// mapTx runs f as a transaction on data, returning the error and the applied writes.
+func mapTx(data map[string]int, f func(tx txn.Tx[string, int]) error) ([]string, error) {
+	var ops []string
+	err := txn.Run(newTestMap(data, &ops), f)
+	var writes []string
+	for _, op := range ops {
+		if !strings.HasPrefix(op, "lock ") && !strings.HasPrefix(op, "unlock ") && !strings.HasPrefix(op, "notify ") {
+			writes = append(writes, op)
+		}
+	}
+	return writes, err
+}
+
+func TestRun(t *testing.T) {
+	data := map[string]int{"a": 10, "b": 0, "c": 1, "e": 0}
+	ops, err := mapTx(data, func(tx txn.Tx[string, int]) error {
+		a, _ := tx.Load("a")
+		b, _ := tx.Load("b")
+		tx.Store("a", a-5)
+		tx.Store("b", b+5)
+		if v, ok := tx.Load("a"); !ok || v != 5 {
+			t.Errorf("Load(): Expected staged value 5, got %d, %v", v, ok)
+		}
+		tx.Delete("c")
+		if _, ok := tx.Load("c"); ok {
+			t.Errorf("Load(): Expected staged deletion")
+		}
+		tx.Store("d", 1)
+		tx.Delete("d")
+		tx.Store("a", a-4)
+		tx.Delete("e")
+		tx.Store("e", 1)
+		tx.Store("e", 2)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Run(): Expected no error, got %v", err)
+	}
+	if !maps.Equal(data, map[string]int{"a": 6, "b": 5, "e": 2}) {
+		t.Errorf("Run(): Expected a=6 b=5 e=2, got %v", data)
+	}
+	if !slices.Equal(ops, []string{"delete c", "delete d", "delete e", "store b", "store a", "store e"}) {
+		t.Errorf("Run(): Expected the removals then the stores, in the order of the last write of each key, got %v", ops)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	errAbort := errors.New("abort")
+	data := map[string]int{"a": 1}
+	ops, err := mapTx(data, func(tx txn.Tx[string, int]) error {
+		tx.Store("a", 2)
+		tx.Delete("a")
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) || len(ops) != 0 || data["a"] != 1 {
+		t.Errorf("Run(): Expected the writes to be discarded, got %v, %v, %v", err, ops, data)
+	}
+
+	var leaked txn.Tx[string, int]
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Run(): Expected the panic to be propagated, got %v", r)
+			}
+		}()
+		mapTx(data, func(tx txn.Tx[string, int]) error {
+			leaked = tx
+			tx.Store("a", 2)
+			panic("boom")
+		})
+	}()
+	if data["a"] != 1 {
+		t.Errorf("Run(): Expected the writes to be discarded, got %v", data)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Store(): Expected a panic using a transaction after its function returned")
+		}
+	}()
+	leaked.Store("a", 3)
+}
+
+func TestGroup(t *testing.T) {
+	var ops []string
+	a := newTestMap(map[string]int{"x": 10}, &ops)
+	b := newTestMap(map[string]int{}, &ops)
+	g := &txn.Group{}
+	// enlisted out of order, locked in increasing order of id.
+	txb := txn.Enlist[string, int](g, b)
+	txa := txn.Enlist[string, int](g, a)
+	if txn.Enlist[string, int](g, a) != txa {
+		t.Errorf("Enlist(): Expected the same transaction enlisting a map twice")
+	}
+	err := g.Run(func() error {
+		x, _ := txa.Load("x")
+		txa.Delete("x")
+		txb.Store("x", x)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Run(): Expected no error, got %v", err)
+	}
+	if len(a.data) != 0 || b.data["x"] != 10 {
+		t.Errorf("Run(): Expected x to be moved, got %v %v", a.data, b.data)
+	}
+	ia, ib := strconv.FormatUint(a.TxID(), 10), strconv.FormatUint(b.TxID(), 10)
+	// the maps are notified once both are unlocked.
+	expected := []string{"lock " + ia, "lock " + ib, "delete x", "store x", "unlock " + ib, "unlock " + ia, "notify " + ib, "notify " + ia}
+	if !slices.Equal(ops, expected) {
+		t.Errorf("Run(): Expected %v, got %v", expected, ops)
+	}
+
+	// a failed run leaves every map untouched, and the group can be run again.
+	ops = nil
+	errAbort := errors.New("abort")
+	err = g.Run(func() error {
+		txa.Store("x", 1)
+		txb.Delete("x")
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) || len(a.data) != 0 || b.data["x"] != 10 {
+		t.Errorf("Run(): Expected the writes to be discarded, got %v, %v %v", err, a.data, b.data)
+	}
+	expected = []string{"lock " + ia, "lock " + ib, "unlock " + ib, "unlock " + ia, "notify " + ib, "notify " + ia}
+	if !slices.Equal(ops, expected) {
+		t.Errorf("Run(): Expected %v, got %v", expected, ops)
+	}
+
+	panics := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: Expected a panic", name)
+			}
+		}()
+		f()
+	}
+	g.Run(func() error {
+		panics("Enlist()", func() {
+			txn.Enlist[string, int](g, newTestMap(nil, &ops))
+		})
+		panics("Run()", func() {
+			g.Run(func() error { return nil })
+		})
+		return nil
+	})
+}
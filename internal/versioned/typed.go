@@ -0,0 +1,194 @@
+package versioned
+
+import (
+	"github.com/thetechpanda/typedmap/internal/removal"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Clear removes all items from the map.
+// This is a locking operation.
+func (m *TypedMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = make(map[K]entry[V])
+}
+
+// Has returns true if the map contains the key.
+func (m *TypedMap[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+// The entry gets a new version.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Update(key K, f func(V, bool) V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.data[key]
+	m.put(key, f(e.value, ok))
+}
+
+// UpdateRange is a thread-safe version of Range that locks the map for the duration of the iteration and allows for the modification of the values.
+// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+// Every updated entry gets a new version.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, e := range m.data {
+		newValue, ok := f(key, e.value)
+		if !ok {
+			return
+		}
+		m.put(key, newValue)
+	}
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+// the values returned by f are applied only once f has been called for every entry.
+// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+// The result reports whether the values have been updated.
+// Every entry gets a new version once the values are applied.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make(map[K]V, len(m.data))
+	for key, e := range m.data {
+		newValue, ok := f(key, e.value)
+		if !ok {
+			return false
+		}
+		values[key] = newValue
+	}
+	for key, value := range values {
+		m.put(key, value)
+	}
+	return true
+}
+
+// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
+//
+// f receives a copy of the content of the map, once f returns the changes are applied to the map:
+// entries holding the same value keep their version, new and changed entries get a new version and missing entries are removed.
+// Values of non comparable types are always considered changed.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Exclusive(f func(m map[K]V)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := make(map[K]V, len(m.data))
+	for key, e := range m.data {
+		data[key] = e.value
+	}
+	f(data)
+	previous := m.data
+	m.data = make(map[K]entry[V], len(data))
+	for key, value := range data {
+		if e, ok := previous[key]; ok && removal.Same(e.value, value) {
+			m.data[key] = e
+			continue
+		}
+		m.put(key, value)
+	}
+}
+
+// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+// The staged writes are applied atomically once f returns nil, every stored entry gets a new version.
+// The writes are discarded if f returns an error or panics.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Transaction(f func(tx txn.Tx[K, V]) error) error {
+	return txn.Run(m, f)
+}
+
+// TxLock acquires the lock of the map, the returned txn.Locked gives access to its content until it is unlocked.
+// Every stored entry gets a new version.
+func (m *TypedMap[K, V]) TxLock() txn.Locked[K, V] {
+	m.mu.Lock()
+	return txn.Locked[K, V]{
+		Load: func(key K) (V, bool) {
+			e, ok := m.data[key]
+			return e.value, ok
+		},
+		Store: func(key K, value V) {
+			m.put(key, value)
+		},
+		Remove: func(key K) {
+			delete(m.data, key)
+		},
+		Unlock: m.mu.Unlock,
+	}
+}
+
+// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+// The map is locked for the duration of the iteration, so the entries are removed atomically.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, e := range m.data {
+		if f(key, e.value) {
+			delete(m.data, key)
+			n++
+		}
+	}
+	return n
+}
+
+// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+// It is equivalent to DeleteFunc with the result of f negated.
+//
+// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+func (m *TypedMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Len returns the number of items in the map.
+func (m *TypedMap[K, V]) Len() (n int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.data)
+}
+
+// Keys returns a slice of all the keys present in the map, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Keys() (keys []K) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, an empty slice is returned if the map is empty.
+func (m *TypedMap[K, V]) Values() (values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values = make([]V, 0, len(m.data))
+	for _, e := range m.data {
+		values = append(values, e.value)
+	}
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map.
+func (m *TypedMap[K, V]) Entries() (keys []K, values []V) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys = make([]K, 0, len(m.data))
+	values = make([]V, 0, len(m.data))
+	for key, e := range m.data {
+		keys = append(keys, key)
+		values = append(values, e.value)
+	}
+	return keys, values
+}
@@ -0,0 +1,82 @@
+package versioned
+
+import (
+	"sync"
+
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// entry is a value stored in the map along with its version.
+type entry[V any] struct {
+	value   V
+	version uint64
+}
+
+// TypedMap implements a thread-safe map where every entry carries a version, changed each time the entry is written.
+// Versions are taken from a counter shared by all the keys of the map, so they are monotonically increasing
+// and never reused, even once a key is deleted and stored again.
+type TypedMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	equality.Comparer[V]
+	txn.ID
+	data map[K]entry[V]
+	// version is the last version assigned to an entry.
+	version uint64
+}
+
+// New returns a new TypedMap, initialized with the given map. if m is nil, an empty map is created.
+// m key, values are copied, so that the caller can safely modify the map after creating a TypedMap.
+// CompareAndSwap and CompareAndDelete compare values using equal, if nil using their Equal method, if V implements it, or reflect.DeepEqual.
+func New[K comparable, V any](m map[K]V, equal equality.Func[V]) *TypedMap[K, V] {
+	v := &TypedMap[K, V]{
+		Comparer: equality.NewComparer(equal, equality.Deep[V]),
+		ID:       txn.NewID(),
+		data:     make(map[K]entry[V], len(m)),
+	}
+	for key, value := range m {
+		v.put(key, value)
+	}
+	return v
+}
+
+// LoadVersioned returns the value stored in the map for a key along with its version.
+// If the key is not present version is 0 and ok is false.
+func (m *TypedMap[K, V]) LoadVersioned(key K) (value V, version uint64, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.data[key]
+	return e.value, e.version, ok
+}
+
+// StoreIfVersion sets the value for a key only if the version of the entry is still version,
+// a version of 0 sets the value only if the key is not present.
+// It returns the new version of the entry, the stored result reports whether the value has been set.
+func (m *TypedMap[K, V]) StoreIfVersion(key K, value V, version uint64) (newVersion uint64, stored bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data[key].version != version {
+		return 0, false
+	}
+	return m.put(key, value), true
+}
+
+// DeleteIfVersion removes the key from the map only if the version of the entry is still version.
+// The deleted result reports whether the key has been removed.
+func (m *TypedMap[K, V]) DeleteIfVersion(key K, version uint64) (deleted bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.data[key]
+	if !ok || e.version != version {
+		return false
+	}
+	delete(m.data, key)
+	return true
+}
+
+// put stores value for key with a new version and returns it, the caller must hold the lock.
+func (m *TypedMap[K, V]) put(key K, value V) uint64 {
+	m.version++
+	m.data[key] = entry[V]{value: value, version: m.version}
+	return m.version
+}
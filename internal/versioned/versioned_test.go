@@ -0,0 +1,244 @@
+package versioned_test
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/thetechpanda/typedmap/internal/compute"
+	"github.com/thetechpanda/typedmap/internal/equality"
+	"github.com/thetechpanda/typedmap/internal/versioned"
+)
+
+// version returns the version of key, failing the test if the key is not present.
+func version[K comparable, V any](t *testing.T, m *versioned.TypedMap[K, V], key K) uint64 {
+	t.Helper()
+	_, v, ok := m.LoadVersioned(key)
+	if !ok {
+		t.Fatalf("LoadVersioned(): Expected key %v to be present", key)
+	}
+	return v
+}
+
+func TestNew(t *testing.T) {
+	src := map[string]int{"a": 1, "b": 2}
+	m := versioned.New(src, nil)
+	delete(src, "a")
+	if m.Len() != 2 {
+		t.Errorf("Len(): Expected the map to be copied, got length %d", m.Len())
+	}
+	if va, vb := version(t, m, "a"), version(t, m, "b"); va == 0 || vb == 0 || va == vb {
+		t.Errorf("New(): Expected distinct non zero versions, got %d and %d", va, vb)
+	}
+	if v, version, ok := m.LoadVersioned("c"); v != 0 || version != 0 || ok {
+		t.Errorf("LoadVersioned(): Expected missing key to have version 0, got %d, %d, %v", v, version, ok)
+	}
+}
+
+func TestStoreIfVersion(t *testing.T) {
+	m := versioned.New[string, []byte](nil, nil)
+
+	// version 0 stores only missing keys.
+	v1, ok := m.StoreIfVersion("k", []byte("a"), 0)
+	if !ok || v1 == 0 {
+		t.Fatalf("StoreIfVersion(): Expected missing key to be stored, got %d, %v", v1, ok)
+	}
+	if _, ok := m.StoreIfVersion("k", []byte("b"), 0); ok {
+		t.Errorf("StoreIfVersion(): Expected present key not to be stored with version 0")
+	}
+	if value, version, _ := m.LoadVersioned("k"); string(value) != "a" || version != v1 {
+		t.Errorf("LoadVersioned(): Expected a at version %d, got %s at %d", v1, value, version)
+	}
+
+	v2, ok := m.StoreIfVersion("k", []byte("b"), v1)
+	if !ok || v2 <= v1 {
+		t.Errorf("StoreIfVersion(): Expected a greater version than %d, got %d, %v", v1, v2, ok)
+	}
+	if _, ok := m.StoreIfVersion("k", []byte("c"), v1); ok {
+		t.Errorf("StoreIfVersion(): Expected stale version not to be stored")
+	}
+	if m.DeleteIfVersion("k", v1) {
+		t.Errorf("DeleteIfVersion(): Expected stale version not to be deleted")
+	}
+	if m.DeleteIfVersion("missing", 0) {
+		t.Errorf("DeleteIfVersion(): Expected missing key not to be deleted")
+	}
+	if !m.DeleteIfVersion("k", v2) || m.Has("k") {
+		t.Errorf("DeleteIfVersion(): Expected key to be deleted")
+	}
+
+	// versions are never reused once a key is deleted and stored again.
+	v3, ok := m.StoreIfVersion("k", []byte("a"), 0)
+	if !ok || v3 <= v2 {
+		t.Errorf("StoreIfVersion(): Expected a greater version than %d, got %d, %v", v2, v3, ok)
+	}
+	if _, ok := m.StoreIfVersion("k", []byte("b"), v1); ok {
+		t.Errorf("StoreIfVersion(): Expected version of the deleted entry not to match")
+	}
+}
+
+func TestWritesChangeVersion(t *testing.T) {
+	m := versioned.New(map[string]int{"k": 1}, nil)
+	last := version(t, m, "k")
+	// changed checks that the version of k has increased since the last call.
+	changed := func(op string, expect bool) {
+		t.Helper()
+		v := version(t, m, "k")
+		if (v > last) != expect {
+			t.Errorf("%s: Expected version change to be %v, got %d after %d", op, expect, v, last)
+		}
+		last = v
+	}
+
+	m.Store("k", 2)
+	changed("Store()", true)
+	m.Swap("k", 2)
+	changed("Swap()", true)
+	m.LoadOrStore("k", 3)
+	changed("LoadOrStore()", false)
+	m.Load("k")
+	changed("Load()", false)
+	m.CompareAndSwap("k", 3, 4)
+	changed("CompareAndSwap()", false)
+	m.CompareAndSwap("k", 2, 3)
+	changed("CompareAndSwap()", true)
+	m.Update("k", func(v int, _ bool) int { return v + 1 })
+	changed("Update()", true)
+	m.UpdateRange(func(_ string, v int) (int, bool) { return v, true })
+	changed("UpdateRange()", true)
+	m.Compute("k", func(v int, _ bool) (int, compute.Op) { return v, compute.Keep })
+	changed("Compute()", false)
+	m.Compute("k", func(v int, _ bool) (int, compute.Op) { return v, compute.Store })
+	changed("Compute()", true)
+	m.Exclusive(func(data map[string]int) {})
+	changed("Exclusive()", false)
+	m.Exclusive(func(data map[string]int) { data["k"]++ })
+	changed("Exclusive()", true)
+}
+
+func TestMapOperations(t *testing.T) {
+	m := versioned.New[string, int](nil, nil)
+	if v, loaded := m.LoadOrStore("a", 1); v != 1 || loaded {
+		t.Errorf("LoadOrStore(): Expected 1 stored, got %d, %v", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("a", 2); v != 1 || !loaded {
+		t.Errorf("LoadOrStore(): Expected 1 loaded, got %d, %v", v, loaded)
+	}
+	if v, loaded := m.Swap("a", 2); v != 1 || !loaded {
+		t.Errorf("Swap(): Expected previous value 1, got %d, %v", v, loaded)
+	}
+	if m.CompareAndDelete("a", 1) || !m.CompareAndDelete("a", 2) {
+		t.Errorf("CompareAndDelete(): Expected only the current value to be deleted")
+	}
+	if m.CompareAndSwap("a", 2, 3) {
+		t.Errorf("CompareAndSwap(): Expected missing key not to be swapped")
+	}
+	m.Store("b", 2)
+	if v, loaded := m.LoadAndDelete("b"); v != 2 || !loaded || m.Has("b") {
+		t.Errorf("LoadAndDelete(): Expected 2 deleted, got %d, %v", v, loaded)
+	}
+	m.Store("c", 3)
+	m.Delete("c")
+	if _, loaded := m.LoadAndDelete("c"); loaded {
+		t.Errorf("Delete(): Expected key to be deleted")
+	}
+	if deleted, err := m.TryCompareAndDelete("c", 3); deleted || err != nil {
+		t.Errorf("TryCompareAndDelete(): Expected false, nil, got %v, %v", deleted, err)
+	}
+
+	for i := 0; i < 10; i++ {
+		m.Store(string(rune('a'+i)), i)
+	}
+	m.Update("z", func(v int, ok bool) int {
+		if ok {
+			t.Errorf("Update(): Expected missing key")
+		}
+		return 25
+	})
+	if v, ok := m.Load("z"); !ok || v != 25 {
+		t.Errorf("Update(): Expected 25, got %d, %v", v, ok)
+	}
+	if n := m.Retain(func(_ string, v int) bool { return v%2 == 0 }); n != 6 || m.Len() != 5 {
+		t.Errorf("Retain(): Expected 6 entries removed, got %d", n)
+	}
+	keys, values := m.Entries()
+	for i, key := range keys {
+		if v, _ := m.Load(key); v != values[i] {
+			t.Errorf("Entries(): Expected value %d for key %s, got %d", v, key, values[i])
+		}
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(m.Keys())), []string{"a", "c", "e", "g", "i"}) {
+		t.Errorf("Keys(): Expected [a c e g i], got %v", m.Keys())
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(m.Values())), []int{0, 2, 4, 6, 8}) {
+		t.Errorf("Values(): Expected [0 2 4 6 8], got %v", m.Values())
+	}
+	if !maps.Equal(maps.Collect(m.All()), map[string]int{"a": 0, "c": 2, "e": 4, "g": 6, "i": 8}) {
+		t.Errorf("All(): Expected the content of the map, got %v", maps.Collect(m.All()))
+	}
+	if len(slices.Collect(m.KeysSeq())) != 5 || len(slices.Collect(m.ValuesSeq())) != 5 {
+		t.Errorf("KeysSeq(), ValuesSeq(): Expected 5 elements")
+	}
+	for range m.All() {
+		break
+	}
+
+	m.UpdateRange(func(key string, v int) (int, bool) { return v * 10, key != "e" })
+	m.Exclusive(func(data map[string]int) {
+		delete(data, "a")
+		data["x"] = 1
+	})
+	if m.Has("a") || !m.Has("x") || m.Len() != 5 {
+		t.Errorf("Exclusive(): Expected a removed and x added, got %v", m.Keys())
+	}
+	m.Clear()
+	if m.Len() != 0 {
+		t.Errorf("Clear(): Expected an empty map, got length %d", m.Len())
+	}
+}
+
+func TestEqual(t *testing.T) {
+	n := versioned.New(map[int][]int{1: {1}}, nil)
+	if n.CompareAndSwap(1, []int{1}, nil) || n.CompareAndDelete(1, []int{1}) {
+		t.Errorf("Expected not comparable type")
+	}
+	if _, err := n.TryCompareAndSwap(1, []int{1}, nil); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndSwap(): Expected ErrNotComparable, got %v", err)
+	}
+	if _, err := n.TryCompareAndDelete(1, []int{1}); !errors.Is(err, equality.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete(): Expected ErrNotComparable, got %v", err)
+	}
+
+	e := versioned.New(map[int][]byte{1: []byte("a")}, bytes.Equal)
+	if swapped, err := e.TryCompareAndSwap(1, []byte("a"), []byte("b")); !swapped || err != nil {
+		t.Errorf("TryCompareAndSwap(): Expected equal value to be swapped, got %v, %v", swapped, err)
+	}
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	// slices are not comparable, the read-modify-write cycles rely on the versions only.
+	m := versioned.New(map[string][]int{"k": {}}, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for {
+					v, version, _ := m.LoadVersioned("k")
+					next := append(slices.Clone(v), i)
+					if _, ok := m.StoreIfVersion("k", next, version); ok {
+						break
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if v, _ := m.Load("k"); len(v) != 800 {
+		t.Errorf("StoreIfVersion(): Expected 800 appended values, got %d", len(v))
+	}
+}
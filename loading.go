@@ -0,0 +1,436 @@
+package typedmap
+
+import (
+	"context"
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/thetechpanda/typedmap/internal/loading"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Loader returns the value for key, it is called by a LoadingMap when the value is not present in the map.
+// ctx carries the values of the context passed to Get, it is cancelled once no caller is waiting for the value.
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// LoadingMap is a TypedMap that loads the missing values using a Loader.
+//
+// With WithExpireAfter, Load, Has, Len, Range, Keys, Values, Entries and the iterators skip the values older than the expire age,
+// as Get does. The functions writing to the map still see them until they are loaded, or written, again or removed from the map.
+type LoadingMap[K comparable, V any] interface {
+	TypedMap[K, V]
+	// Get returns the value for key, if the value is not present in the map it is loaded using the Loader and stored in the map.
+	// Concurrent calls missing the same key wait for a single invocation of the Loader and receive its result.
+	//
+	// Get returns the error of ctx if it is done before the value is loaded, the load continues as long as another caller is waiting for it.
+	// Errors returned by the Loader are not stored in the map, they are cached only if WithNegativeTTL is used.
+	// A panic in the Loader is returned as an error.
+	//
+	// With WithRefreshAfter, a value older than the refresh age is returned as is while it is reloaded in the background,
+	// with WithExpireAfter, a value older than the expire age is loaded again and callers wait for it.
+	Get(ctx context.Context, key K) (V, error)
+}
+
+// loadingMap implements LoadingMap on top of an existing TypedMap.
+type loadingMap[K comparable, V any] struct {
+	TypedMap[K, V]
+	*loading.Group[K, V]
+}
+
+// NewLoading returns a new LoadingMap storing the values returned by loader in m, if m is nil a map returned by New is used.
+// Since m is used as is, any TypedMap can be used, eg. one returned by NewTTL or NewCache to bound the lifetime or the number of the loaded values.
+//
+// Use WithNegativeTTL to cache the errors returned by loader, WithRefreshAfter and WithExpireAfter to refresh or expire the loaded values by age
+// and WithClock to provide the time source of the map.
+//
+// The age of a value is measured from the time it was loaded or written through the LoadingMap,
+// values stored directly in m are considered loaded the first time Get returns them.
+// Expired values are not removed from m, they are only hidden by the LoadingMap, use a TTLMap to remove them.
+func NewLoading[K comparable, V any](m TypedMap[K, V], loader Loader[K, V], opts ...Option) LoadingMap[K, V] {
+	o := withoutOnRemove(opts)
+	if m == nil {
+		m = New[K, V]()
+	}
+	return &loadingMap[K, V]{
+		TypedMap: m,
+		Group:    loading.New(m, loading.Loader[K, V](loader), o.now, o.negativeTTL, o.refreshAfter, o.expireAfter),
+	}
+}
+
+// Load returns the value stored in the map for a key, values older than the age set using WithExpireAfter are reported as missing.
+func (m *loadingMap[K, V]) Load(key K) (value V, ok bool) {
+	value, ok = m.TypedMap.Load(key)
+	if ok && m.Expired(key) {
+		var zero V
+		return zero, false
+	}
+	return value, ok
+}
+
+// Has returns true if the map contains a value for the key that has not expired.
+func (m *loadingMap[K, V]) Has(key K) bool {
+	_, ok := m.Load(key)
+	return ok
+}
+
+// Range calls f sequentially for each key and value present in the map, skipping the expired values.
+func (m *loadingMap[K, V]) Range(f func(K, V) bool) {
+	expired := m.ExpiredKeys()
+	m.TypedMap.Range(func(key K, value V) bool {
+		if _, ok := expired[key]; ok {
+			return true
+		}
+		return f(key, value)
+	})
+}
+
+// All returns an iterator over the entries of the map, skipping the expired values.
+func (m *loadingMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		expired := m.ExpiredKeys()
+		for key, value := range m.TypedMap.All() {
+			if _, ok := expired[key]; !ok && !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// KeysSeq returns an iterator over the keys of the map, skipping the keys whose value has expired.
+func (m *loadingMap[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range m.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over the values of the map, skipping the expired values.
+func (m *loadingMap[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, value := range m.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of entries in the map, not counting the expired values.
+func (m *loadingMap[K, V]) Len() int {
+	n := m.TypedMap.Len()
+	for key := range m.ExpiredKeys() {
+		if m.TypedMap.Has(key) {
+			n--
+		}
+	}
+	return max(n, 0)
+}
+
+// Keys returns a slice of all the keys present in the map, skipping the keys whose value has expired.
+func (m *loadingMap[K, V]) Keys() (keys []K) {
+	keys, _ = m.Entries()
+	return keys
+}
+
+// Values returns a slice of all the values present in the map, skipping the expired values.
+func (m *loadingMap[K, V]) Values() (values []V) {
+	_, values = m.Entries()
+	return values
+}
+
+// Entries returns two slices, one containing all the keys and the other containing all the values present in the map,
+// skipping the expired values.
+func (m *loadingMap[K, V]) Entries() (keys []K, values []V) {
+	expired := m.ExpiredKeys()
+	keys, values = m.TypedMap.Entries()
+	if len(expired) == 0 {
+		return keys, values
+	}
+	n := 0
+	for i, key := range keys {
+		if _, ok := expired[key]; !ok {
+			keys[n], values[n] = key, values[i]
+			n++
+		}
+	}
+	return slices.Clip(keys[:n]), slices.Clip(values[:n])
+}
+
+// Store sets the value for a key, the value is considered loaded now.
+func (m *loadingMap[K, V]) Store(key K, value V) {
+	m.TypedMap.Store(key, value)
+	m.Stored(key)
+}
+
+// LoadOrStore returns the existing value for the key if present, otherwise it stores the given value, considered loaded now.
+// An expired value is returned as well, as the value is present in the map.
+func (m *loadingMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	actual, loaded = m.TypedMap.LoadOrStore(key, value)
+	if !loaded {
+		m.Stored(key)
+	}
+	return actual, loaded
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+func (m *loadingMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	value, loaded = m.TypedMap.LoadAndDelete(key)
+	m.Removed(key)
+	return value, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *loadingMap[K, V]) Delete(key K) {
+	m.TypedMap.Delete(key)
+	m.Removed(key)
+}
+
+// Swap swaps the value for a key and returns the previous value if any, the new value is considered loaded now.
+func (m *loadingMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	previous, loaded = m.TypedMap.Swap(key, value)
+	m.Stored(key)
+	return previous, loaded
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the map is equal to old,
+// the new value is considered loaded now.
+func (m *loadingMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	swapped = m.TypedMap.CompareAndSwap(key, old, new)
+	if swapped {
+		m.Stored(key)
+	}
+	return swapped
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+func (m *loadingMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	deleted = m.TypedMap.CompareAndDelete(key, old)
+	if deleted {
+		m.Removed(key)
+	}
+	return deleted
+}
+
+// TryCompareAndSwap is CompareAndSwap, but it returns ErrNotComparable if the values of the map cannot be compared.
+func (m *loadingMap[K, V]) TryCompareAndSwap(key K, old, new V) (swapped bool, err error) {
+	swapped, err = m.TypedMap.TryCompareAndSwap(key, old, new)
+	if swapped {
+		m.Stored(key)
+	}
+	return swapped, err
+}
+
+// TryCompareAndDelete is CompareAndDelete, but it returns ErrNotComparable if the values of the map cannot be compared.
+func (m *loadingMap[K, V]) TryCompareAndDelete(key K, old V) (deleted bool, err error) {
+	deleted, err = m.TypedMap.TryCompareAndDelete(key, old)
+	if deleted {
+		m.Removed(key)
+	}
+	return deleted, err
+}
+
+// Compute sets the value for key to the result of f, see Computable, a value stored by f is considered loaded now.
+func (m *loadingMap[K, V]) Compute(key K, f func(old V, loaded bool) (V, Op)) (value V, ok bool) {
+	var op Op
+	value, ok = m.TypedMap.Compute(key, func(old V, loaded bool) (V, Op) {
+		var v V
+		v, op = f(old, loaded)
+		return v, op
+	})
+	m.computed(key, op)
+	return value, ok
+}
+
+// ComputeIfAbsent is Compute, calling f only if key is not present.
+func (m *loadingMap[K, V]) ComputeIfAbsent(key K, f func() (V, Op)) (value V, ok bool) {
+	var op Op
+	value, ok = m.TypedMap.ComputeIfAbsent(key, func() (V, Op) {
+		var v V
+		v, op = f()
+		return v, op
+	})
+	m.computed(key, op)
+	return value, ok
+}
+
+// ComputeIfPresent is Compute, calling f only if key is present.
+func (m *loadingMap[K, V]) ComputeIfPresent(key K, f func(old V) (V, Op)) (value V, ok bool) {
+	var op Op
+	value, ok = m.TypedMap.ComputeIfPresent(key, func(old V) (V, Op) {
+		var v V
+		v, op = f(old)
+		return v, op
+	})
+	m.computed(key, op)
+	return value, ok
+}
+
+// computed records the write made by a Compute function returning op, op is OpKeep if f was not called.
+func (m *loadingMap[K, V]) computed(key K, op Op) {
+	switch op {
+	case OpStore:
+		m.Stored(key)
+	case OpDelete:
+		m.Removed(key)
+	}
+}
+
+// Update changes the value for key atomically, the new value is considered loaded now.
+func (m *loadingMap[K, V]) Update(key K, f func(V, bool) V) {
+	m.TypedMap.Update(key, f)
+	m.Stored(key)
+}
+
+// UpdateRange is a version of Range that allows for the modification of the values, see TypedMap.
+// Expired values are visited as well, the updated values are considered loaded now.
+func (m *loadingMap[K, V]) UpdateRange(f func(K, V) (V, bool)) {
+	var updated []K
+	defer func() {
+		m.Stored(updated...)
+	}()
+	m.TypedMap.UpdateRange(func(key K, value V) (V, bool) {
+		value, ok := f(key, value)
+		if ok {
+			updated = append(updated, key)
+		}
+		return value, ok
+	})
+}
+
+// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none, see TypedMap.
+// Expired values are visited as well, the updated values are considered loaded now.
+func (m *loadingMap[K, V]) UpdateRangeAtomic(f func(K, V) (V, bool)) (updated bool) {
+	var keys []K
+	updated = m.TypedMap.UpdateRangeAtomic(func(key K, value V) (V, bool) {
+		keys = append(keys, key)
+		return f(key, value)
+	})
+	if updated {
+		m.Stored(keys...)
+	}
+	return updated
+}
+
+// Exclusive calls f with the underlying map locked, see TypedMap.
+// Since the changes made by f cannot be told apart, every value left in the map is considered loaded now.
+func (m *loadingMap[K, V]) Exclusive(f func(m map[K]V)) {
+	var keys []K
+	m.TypedMap.Exclusive(func(data map[K]V) {
+		f(data)
+		keys = slices.AppendSeq(make([]K, 0, len(data)), maps.Keys(data))
+	})
+	m.Reset(keys...)
+}
+
+// Transaction calls f with a transaction staging the writes to the map, see TypedMap.
+// The values stored by the transaction are considered loaded once it is applied.
+func (m *loadingMap[K, V]) Transaction(f func(tx Tx[K, V]) error) error {
+	var w writes[K, V]
+	err := m.TypedMap.Transaction(func(tx Tx[K, V]) error {
+		w = writes[K, V]{Tx: tx}
+		return f(&w)
+	})
+	if err == nil {
+		m.applied(w.stored)
+	}
+	return err
+}
+
+// applied records the writes of a transaction, stored reports for each key written whether it was stored or deleted.
+func (m *loadingMap[K, V]) applied(stored map[K]bool) {
+	var keys, removed []K
+	for key, ok := range stored {
+		if ok {
+			keys = append(keys, key)
+		} else {
+			removed = append(removed, key)
+		}
+	}
+	m.Removed(removed...)
+	m.Stored(keys...)
+}
+
+// DeleteFunc removes the entries for which f returns true, expired values included, and returns how many entries were removed.
+func (m *loadingMap[K, V]) DeleteFunc(f func(K, V) bool) (n int) {
+	var removed []K
+	defer func() {
+		m.Removed(removed...)
+	}()
+	return m.TypedMap.DeleteFunc(func(key K, value V) bool {
+		if f(key, value) {
+			removed = append(removed, key)
+			return true
+		}
+		return false
+	})
+}
+
+// Retain removes the entries for which f returns false, expired values included, and returns how many entries were removed.
+func (m *loadingMap[K, V]) Retain(f func(K, V) bool) (n int) {
+	return m.DeleteFunc(func(key K, value V) bool {
+		return !f(key, value)
+	})
+}
+
+// Clear removes all the entries from the map.
+func (m *loadingMap[K, V]) Clear() {
+	m.TypedMap.Clear()
+	m.Reset()
+}
+
+// loadingTxMap enlists a LoadingMap in a Txn through the map storing its values, recording the keys written by the transactions.
+type loadingTxMap[K comparable, V any] struct {
+	txn.Map[K, V]
+	m *loadingMap[K, V]
+}
+
+// TxLock acquires the lock of the map storing the values, the keys written are recorded once every map of the transaction is unlocked.
+func (t loadingTxMap[K, V]) TxLock() txn.Locked[K, V] {
+	l := t.Map.TxLock()
+	var w writes[K, V]
+	store, remove, notify := l.Store, l.Remove, l.Notify
+	l.Store = func(key K, value V) {
+		store(key, value)
+		w.record(key, true)
+	}
+	l.Remove = func(key K) {
+		remove(key)
+		w.record(key, false)
+	}
+	l.Notify = func() {
+		t.m.applied(w.stored)
+		if notify != nil {
+			notify()
+		}
+	}
+	return l
+}
+
+// writes is a Tx recording the keys written by the transaction, the last write of a key tells whether it is stored or deleted.
+type writes[K comparable, V any] struct {
+	Tx[K, V]
+	stored map[K]bool
+}
+
+// Store stages the value for key.
+func (w *writes[K, V]) Store(key K, value V) {
+	w.Tx.Store(key, value)
+	w.record(key, true)
+}
+
+// Delete stages the removal of key.
+func (w *writes[K, V]) Delete(key K) {
+	w.Tx.Delete(key)
+	w.record(key, false)
+}
+
+func (w *writes[K, V]) record(key K, stored bool) {
+	if w.stored == nil {
+		w.stored = make(map[K]bool)
+	}
+	w.stored[key] = stored
+}
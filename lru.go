@@ -0,0 +1,21 @@
+package typedmap
+
+import "slices"
+
+// LRUMap is a BoundedMap that, once the capacity is reached, evicts the least recently used entry.
+//
+// Load, LoadOrStore, Store, Swap, CompareAndSwap and Update mark the entry as the most recently used,
+// while Peek, Has, Range, UpdateRange and the functions returning the content of the map do not change the recency of the entries.
+//
+// Since reads change the recency of the entries, all operations on the map use an exclusive lock.
+type LRUMap[K comparable, V any] interface {
+	BoundedMap[K, V]
+}
+
+// NewLRU returns a new LRUMap holding at most capacity entries, if capacity is less than 1 the map holds a single entry.
+// It is NewCache using PolicyLRU, whatever the policy set using WithPolicy. Eviction of the least recently used entry is an O(1) operation.
+//
+// Use WithOnRemove to be notified of the entries leaving the map and WithEqual to set how values are compared.
+func NewLRU[K comparable, V any](capacity int, opts ...Option) LRUMap[K, V] {
+	return NewCache[K, V](capacity, append(slices.Clip(opts), WithPolicy(PolicyLRU))...)
+}
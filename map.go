@@ -1,5 +1,7 @@
 package typedmap
 
+import "iter"
+
 // Map is a generic interface that provides a way to interact with the map.
 // its interface is identical to sync.Map and so are function definition and behaviour.
 type Map[K comparable, V any] interface {
@@ -52,8 +54,34 @@ type Map[K comparable, V any] interface {
 	Range(f func(K, V) bool)
 }
 
+// Iterators is a generic interface that provides range-over-func iterators over the content of a map.
+// Iterators behave as Range does and can be used with the for range statement and the maps and slices packages, eg:
+//
+//	for k, v := range m.All() {
+//		// ...
+//	}
+//	keys := slices.Sorted(m.KeysSeq())
+//	clone := maps.Collect(m.All())
+type Iterators[K, V any] interface {
+	// All returns an iterator over the key-value pairs in the map.
+	All() iter.Seq2[K, V]
+	// KeysSeq returns an iterator over the keys in the map.
+	KeysSeq() iter.Seq[K]
+	// ValuesSeq returns an iterator over the values in the map.
+	ValuesSeq() iter.Seq[V]
+}
+
+// IterableMap is a Map that also provides range-over-func iterators.
+type IterableMap[K comparable, V any] interface {
+	Map[K, V]
+	Iterators[K, V]
+}
+
 // NewSyncMapCompatible returns a new TypedMap that is exactly as sync.Map interface,
 // use it as you would sync.Map with the added benefit of type safety.
+//
+// CompareAndSwap and CompareAndDelete compare values using reflect.DeepEqual,
+// use NewComparable to compare them using == as sync.Map does.
 func NewSyncMapCompatible[K comparable, V any]() Map[K, V] {
 	return New[K, V]()
 }
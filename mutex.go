@@ -9,14 +9,21 @@
 //
 //   - sync.Map uses K, V any, which means that the keys and values can be of any type. However, the typedmap package uses K comparable, V any, which means that the keys must be comparable.
 //   - The CompareAndSwap and CompareAndDelete functions use reflect.DeepEqual to compare the values, which may not be as efficient as using the == operator for simple types. TypeMap detects if the value is comparable type and will always return false if it is not.
+//     Values implementing Equaler are compared using their Equal method, WithEqual sets the function used to compare values, and NewComparable returns a map comparing values using ==.
 package typedmap
 
 import "github.com/thetechpanda/typedmap/internal/mutex"
 
 // TypedMap is a generic interface that provides a way to interact with the map.
-// Its interface extends Map[K, V]
+// Its interface extends IterableMap[K, V]
 type TypedMap[K comparable, V any] interface {
-	Map[K, V]
+	IterableMap[K, V]
+	// Computable provides Compute, ComputeIfAbsent and ComputeIfPresent, they hold the lock of the map while f runs.
+	//
+	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+	Computable[K, V]
+	// Comparer provides TryCompareAndSwap and TryCompareAndDelete, reporting whether the values of the map can be compared.
+	Comparer[K, V]
 	// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
 	//
 	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
@@ -26,10 +33,32 @@ type TypedMap[K comparable, V any] interface {
 	//
 	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
 	UpdateRange(f func(K, V) (V, bool))
+	// UpdateRangeAtomic is a version of UpdateRange that updates either every value in the map or none:
+	// the values returned by f are applied only once f has been called for every entry.
+	// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+	// The result reports whether the values have been updated.
+	//
+	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+	UpdateRangeAtomic(f func(K, V) (V, bool)) bool
 	// Exclusive provides a way to perform  operations on the map ensuring that no other operation is performed on the map during the execution of the function.
 	//
 	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
 	Exclusive(f func(m map[K]V))
+	// Transaction calls f with a transaction staging the writes to the map, the map is locked for the duration of f.
+	// The staged writes are applied atomically once f returns nil, they are discarded if f returns an error or panics,
+	// the error returned by f is returned by Transaction and a panic is propagated.
+	//
+	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+	Transaction(f func(tx Tx[K, V]) error) error
+	// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+	// Unlike UpdateRange, every entry is visited, and the entries are removed atomically under a single lock.
+	//
+	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+	DeleteFunc(f func(K, V) bool) (n int)
+	// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+	//
+	// ! Do not invoke any TypedMap functions within 'f' to prevent a deadlock.
+	Retain(f func(K, V) bool) (n int)
 	// Clear removes all items from the map.
 	Clear()
 	// Has returns true if the map contains the key.
@@ -45,12 +74,25 @@ type TypedMap[K comparable, V any] interface {
 }
 
 // New returns a new TypedMap.
-func New[K comparable, V any]() TypedMap[K, V] {
-	return mutex.New(map[K]V{})
+//
+// CompareAndSwap and CompareAndDelete compare values using their Equal method, if V implements Equaler, or reflect.DeepEqual.
+// Use WithEqual to set how values are compared and WithOnRemove to be notified of the entries removed from the map.
+func New[K comparable, V any](opts ...Option) TypedMap[K, V] {
+	return NewWithMap(map[K]V{}, opts...)
 }
 
 // NewWithMap returns a new TypedMap, initialized with the given map. if m is nil, an empty map is created.
 // m key, values are copied, so that the caller can safely modify the map after creating a TypedMap.
-func NewWithMap[K comparable, V any](m map[K]V) TypedMap[K, V] {
-	return mutex.New(m)
+//
+// Use WithOnRemove to be notified of the entries removed from the map.
+func NewWithMap[K comparable, V any](m map[K]V, opts ...Option) TypedMap[K, V] {
+	o := newOptions(opts)
+	return mutex.NewEqual(m, onRemove[K, V](o), equal[V](o))
+}
+
+// NewComparable returns a new TypedMap whose values are compared using == rather than reflect.DeepEqual.
+// CompareAndSwap and CompareAndDelete behave as they do in sync.Map: pointers are compared by identity, not by the values they point to,
+// and comparing interface values holding non comparable types panics.
+func NewComparable[K, V comparable]() TypedMap[K, V] {
+	return mutex.NewComparable(map[K]V{})
 }
@@ -0,0 +1,71 @@
+package typedmap
+
+import "time"
+
+// options holds the configuration shared by the constructors that accept an Option.
+type options struct {
+	now           func() time.Time
+	sweepInterval time.Duration
+	policy        Policy
+	onRemove      any
+	negativeTTL   time.Duration
+	refreshAfter  time.Duration
+	expireAfter   time.Duration
+	equal         any
+	moveToBack    bool
+}
+
+// Option configures the behaviour of the maps returned by the constructors that accept it.
+type Option func(o *options)
+
+// newOptions returns the configuration resulting from applying opts to the defaults.
+func newOptions(opts []Option) *options {
+	o := &options{now: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithClock sets the function used by the map to get the current time, time.Now is used by default.
+// It is mostly useful to control expiration in tests.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		if now != nil {
+			o.now = now
+		}
+	}
+}
+
+// WithSweepInterval starts a background goroutine that removes expired entries at every interval,
+// the goroutine is stopped by calling Close on the map. By default no background goroutine is started.
+func WithSweepInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.sweepInterval = interval
+	}
+}
+
+// WithNegativeTTL makes a LoadingMap cache the errors returned by its Loader for ttl,
+// during which Get returns the cached error without invoking the Loader. By default errors are not cached.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithRefreshAfter makes a LoadingMap reload the values older than d in the background,
+// Get keeps returning the stale value until the refresh completes. A failed refresh keeps the stale value.
+func WithRefreshAfter(d time.Duration) Option {
+	return func(o *options) {
+		o.refreshAfter = d
+	}
+}
+
+// WithExpireAfter makes a LoadingMap ignore the values older than d, Get loads them again as if they were missing
+// and the functions reading the map skip them, see LoadingMap.
+// Use it along with a longer WithRefreshAfter to bound the staleness of the values returned by Get.
+func WithExpireAfter(d time.Duration) Option {
+	return func(o *options) {
+		o.expireAfter = d
+	}
+}
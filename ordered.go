@@ -0,0 +1,36 @@
+package typedmap
+
+import "github.com/thetechpanda/typedmap/internal/ordered"
+
+// OrderedMap is a TypedMap preserving the insertion order of its keys:
+// Range, Keys, Values, Entries, DeleteFunc and the iterators visit the entries from the first inserted to the last inserted.
+//
+// Storing the value of a key already present keeps its position, unless WithMoveToBack is used.
+// Deleting a key and storing it again inserts it as the last entry. UpdateRange, UpdateRangeAtomic and Exclusive never move the entries,
+// Exclusive inserts the new keys after the last entry, since f receives a Go map only their relative order is unspecified:
+// use Transaction to insert several keys atomically in a defined order, the order of their last write.
+type OrderedMap[K comparable, V any] interface {
+	TypedMap[K, V]
+	// First returns the first entry of the map, the ok result reports whether the map is not empty.
+	First() (key K, value V, ok bool)
+	// Last returns the last entry of the map, the ok result reports whether the map is not empty.
+	Last() (key K, value V, ok bool)
+	// PopFirst removes the first entry of the map and returns it, the ok result reports whether the map was not empty.
+	PopFirst() (key K, value V, ok bool)
+}
+
+// WithMoveToBack makes an OrderedMap move an entry to the back each time the value of its key is stored,
+// by Store, Swap, CompareAndSwap, Update, Compute or a transaction, as if the key was deleted and stored again.
+func WithMoveToBack() Option {
+	return func(o *options) {
+		o.moveToBack = true
+	}
+}
+
+// NewOrdered returns a new OrderedMap.
+//
+// Use WithMoveToBack to move the entries to the back when their value is stored and WithEqual to set how values are compared.
+func NewOrdered[K comparable, V any](opts ...Option) OrderedMap[K, V] {
+	o := withoutOnRemove(opts)
+	return ordered.New[K](o.moveToBack, equal[V](o))
+}
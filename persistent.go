@@ -0,0 +1,93 @@
+package typedmap
+
+import (
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/hamt"
+)
+
+// PersistentMap is an immutable map: With and Without return a new version of the map, sharing its structure with the
+// previous one, which is left untouched. Both are O(log N), copying only the nodes of the trie from the root down to the changed key.
+//
+// Copying a PersistentMap is O(1) and takes a snapshot: a version never changes, so it can be handed to other goroutines
+// and read concurrently without locking, however large the map.
+// The zero value is an empty PersistentMap ready to use.
+//
+//	v1 := typedmap.PersistentMap[string, int]{}.With("a", 1)
+//	v2 := v1.With("b", 2).Without("a")
+//	// v1 still holds "a" only, v2 holds "b" only.
+type PersistentMap[K comparable, V any] struct {
+	m hamt.Map[K, V]
+}
+
+// NewPersistent returns a PersistentMap holding the entries of m, m can be nil.
+// The entries are read using m.Range, without blocking the readers of m: the result is a consistent state of m
+// if Range holds the read lock of m for the whole iteration, as the maps returned by New do.
+func NewPersistent[K comparable, V any](m TypedMap[K, V]) PersistentMap[K, V] {
+	var p PersistentMap[K, V]
+	if m != nil {
+		m.Range(func(key K, value V) bool {
+			p.m = p.m.With(key, value)
+			return true
+		})
+	}
+	return p
+}
+
+// TypedMap returns a new TypedMap, as New does, holding the entries of p.
+func (p PersistentMap[K, V]) TypedMap(opts ...Option) TypedMap[K, V] {
+	m := New[K, V](opts...)
+	m.Exclusive(func(data map[K]V) {
+		for key, value := range p.m.All() {
+			data[key] = value
+		}
+	})
+	return m
+}
+
+// Load returns the value stored in the map for a key.
+// The ok result indicates whether value was found in the map.
+func (p PersistentMap[K, V]) Load(key K) (value V, ok bool) {
+	return p.m.Load(key)
+}
+
+// Has returns true if the map contains the key.
+func (p PersistentMap[K, V]) Has(key K) bool {
+	return p.m.Has(key)
+}
+
+// Len returns the number of items in the map.
+func (p PersistentMap[K, V]) Len() int {
+	return p.m.Len()
+}
+
+// With returns a new version of the map where the value for key is set to value, p is left untouched.
+func (p PersistentMap[K, V]) With(key K, value V) PersistentMap[K, V] {
+	return PersistentMap[K, V]{p.m.With(key, value)}
+}
+
+// Without returns a new version of the map without key, p is left untouched.
+func (p PersistentMap[K, V]) Without(key K) PersistentMap[K, V] {
+	return PersistentMap[K, V]{p.m.Without(key)}
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+func (p PersistentMap[K, V]) Range(f func(K, V) bool) {
+	p.m.Range(f)
+}
+
+// All returns an iterator over the key-value pairs in the map.
+func (p PersistentMap[K, V]) All() iter.Seq2[K, V] {
+	return p.m.All()
+}
+
+// KeysSeq returns an iterator over the keys in the map.
+func (p PersistentMap[K, V]) KeysSeq() iter.Seq[K] {
+	return p.m.KeysSeq()
+}
+
+// ValuesSeq returns an iterator over the values in the map.
+func (p PersistentMap[K, V]) ValuesSeq() iter.Seq[V] {
+	return p.m.ValuesSeq()
+}
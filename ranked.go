@@ -0,0 +1,48 @@
+package typedmap
+
+import (
+	"cmp"
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/ranked"
+)
+
+// RankedMap is a TypedMap ordered by the score of its values: Range, Keys, Values, Entries, DeleteFunc and the iterators
+// visit the entries in ascending order of score. The order is maintained on every write, insertions, removals and Rank are O(log N).
+//
+// The score of a value is computed once when the value is stored, an entry whose score changes is moved as if it was stored for the first time:
+// entries with the same score are ranked in the order their score was stored, the first stored ranks first.
+type RankedMap[K comparable, V any, S cmp.Ordered] interface {
+	TypedMap[K, V]
+	// TopN returns the n entries with the highest scores, in descending order of score.
+	// It returns fewer entries if the map holds less than n entries.
+	TopN(n int) (keys []K, values []V)
+	// BottomN returns the n entries with the lowest scores, in ascending order of score, the reverse of the order of TopN.
+	// It returns fewer entries if the map holds less than n entries.
+	BottomN(n int) (keys []K, values []V)
+	// Rank returns the position of key in the order of TopN, 0 for the entry with the highest score,
+	// the ok result reports whether key is present. It is O(log N).
+	Rank(key K) (rank int, ok bool)
+	// Score returns the score of the value stored for key, the ok result reports whether key is present.
+	Score(key K) (score S, ok bool)
+	// ScoreRange returns an iterator over the entries whose score is greater than or equal to lo and less than hi,
+	// in ascending order of score. Finding the first entry is O(log N).
+	//
+	// Avoid invoking any map functions within the loop body to prevent a deadlock.
+	ScoreRange(lo, hi S) iter.Seq2[K, V]
+}
+
+// NewRanked returns a new RankedMap whose values are their own score.
+//
+// Use WithEqual to set how values are compared.
+func NewRanked[K comparable, V cmp.Ordered](opts ...Option) RankedMap[K, V, V] {
+	return NewRankedFunc[K](func(v V) V { return v }, opts...)
+}
+
+// NewRankedFunc returns a new RankedMap whose values are ordered by the score returned by score,
+// scores are compared as cmp.Compare does. score must always return the same score for the same value.
+//
+// Use WithEqual to set how values are compared.
+func NewRankedFunc[K comparable, V any, S cmp.Ordered](score func(V) S, opts ...Option) RankedMap[K, V, S] {
+	return ranked.New[K](score, equal[V](withoutOnRemove(opts)))
+}
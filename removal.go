@@ -0,0 +1,65 @@
+package typedmap
+
+import (
+	"fmt"
+
+	"github.com/thetechpanda/typedmap/internal/removal"
+)
+
+// RemovalReason describes why an entry has been removed from a map.
+type RemovalReason = removal.Reason
+
+const (
+	// ReasonDeleted means the entry has been removed by the caller, using Delete, LoadAndDelete, CompareAndDelete, Compute, DeleteFunc, Exclusive or a transaction.
+	ReasonDeleted = removal.Deleted
+	// ReasonReplaced means the value of the entry has been replaced by a different value,
+	// eg. using Store, Swap, CompareAndSwap, Update, UpdateRange or Exclusive.
+	ReasonReplaced = removal.Replaced
+	// ReasonExpired means the entry has been removed because its time to live has elapsed.
+	ReasonExpired = removal.Expired
+	// ReasonCapacity means the entry has been evicted, or not admitted, because the map is full.
+	ReasonCapacity = removal.Capacity
+	// ReasonCleared means the entry has been removed by Clear.
+	ReasonCleared = removal.Cleared
+)
+
+// WithOnRemove sets a function called for every entry that leaves the map, along with the reason of its removal.
+// It is supported by New, NewWithMap, NewSyncMap, NewTTL, NewLRU and NewCache.
+// The other constructors accepting options panic if it is set, rather than never calling f.
+//
+// Since Option is not generic, K and V cannot be checked against the types of the map at compile time:
+// the constructor panics if they do not match the types of key and value of the map.
+//
+// f is called after the map lock has been released, in the goroutine that removed the entry, so it may safely call any method on the map.
+// When a value is replaced f receives the previous value, replacing a value with the same value
+// (as compared by ==, or by identity for pointers) is not reported. Values of non comparable types are always reported.
+func WithOnRemove[K comparable, V any](f func(key K, value V, reason RemovalReason)) Option {
+	return func(o *options) {
+		o.onRemove = f
+	}
+}
+
+// onRemove returns the removal hook set using WithOnRemove, or nil if none was set.
+// It panics if the hook does not match the types of the map.
+func onRemove[K comparable, V any](o *options) removal.Hook[K, V] {
+	if o.onRemove == nil {
+		return nil
+	}
+	f, ok := o.onRemove.(func(K, V, RemovalReason))
+	if !ok {
+		var k K
+		var v V
+		panic(fmt.Sprintf("typedmap: WithOnRemove function %T does not match map types %T, %T", o.onRemove, k, v))
+	}
+	return f
+}
+
+// withoutOnRemove returns the configuration resulting from opts, for the constructors that do not support WithOnRemove.
+// It panics if a removal hook was set, so that the hook is not silently ignored.
+func withoutOnRemove(opts []Option) *options {
+	o := newOptions(opts)
+	if o.onRemove != nil {
+		panic("typedmap: WithOnRemove is only supported by New, NewWithMap, NewSyncMap, NewTTL, NewLRU and NewCache")
+	}
+	return o
+}
@@ -0,0 +1,181 @@
+package typedmap
+
+import (
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Set is a thread-safe set of comparable items, backed by a TypedMap whose values are empty structs:
+// it has the same concurrency guarantees as the map backing it, see NewSet and NewShardedSet.
+//
+// Union, Intersect, Difference and IsSubset hold the read locks of both sets while reading them, so that readers are not blocked,
+// the locks are taken in the same global order as the one used by transactions, so that they cannot deadlock.
+// The sets they return are built once the locks are released.
+// The zero value is not usable, use NewSet or NewShardedSet.
+type Set[T comparable] struct {
+	m TypedMap[T, struct{}]
+	// r reads m holding its read lock, its transaction id orders the locks of the sets taking part in the same operation.
+	r txn.Reader[T, struct{}]
+	// newMap returns a new map of the same kind as m, backing the sets returned by the set algebra.
+	newMap func() TypedMap[T, struct{}]
+}
+
+// NewSet returns a new Set holding items, backed by a TypedMap returned by New.
+func NewSet[T comparable](items ...T) *Set[T] {
+	return newSet(func() TypedMap[T, struct{}] {
+		return New[T, struct{}]()
+	}, items)
+}
+
+// NewShardedSet returns a new Set holding items, backed by a TypedMap returned by NewSharded,
+// which spreads the items over the given number of shards.
+func NewShardedSet[T comparable](shards int, items ...T) *Set[T] {
+	return newSet(func() TypedMap[T, struct{}] {
+		return NewSharded[T, struct{}](shards)
+	}, items)
+}
+
+func newSet[T comparable](newMap func() TypedMap[T, struct{}], items []T) *Set[T] {
+	m := newMap()
+	s := &Set[T]{m: m, r: m.(txn.Reader[T, struct{}]), newMap: newMap}
+	for _, item := range items {
+		s.m.Store(item, struct{}{})
+	}
+	return s
+}
+
+// Add adds item to the set.
+func (s *Set[T]) Add(item T) {
+	s.m.Store(item, struct{}{})
+}
+
+// AddIfAbsent adds item to the set if it is not present, the result reports whether item was added.
+func (s *Set[T]) AddIfAbsent(item T) (added bool) {
+	_, loaded := s.m.LoadOrStore(item, struct{}{})
+	return !loaded
+}
+
+// Remove removes item from the set, the result reports whether item was present.
+func (s *Set[T]) Remove(item T) (removed bool) {
+	_, removed = s.m.LoadAndDelete(item)
+	return removed
+}
+
+// Contains returns true if the set holds item.
+func (s *Set[T]) Contains(item T) bool {
+	return s.m.Has(item)
+}
+
+// Len returns the number of items in the set.
+func (s *Set[T]) Len() int {
+	return s.m.Len()
+}
+
+// Clear removes all items from the set.
+func (s *Set[T]) Clear() {
+	s.m.Clear()
+}
+
+// Items returns a slice of all the items in the set, in no particular order.
+// An empty slice is returned if the set is empty.
+func (s *Set[T]) Items() []T {
+	return s.m.Keys()
+}
+
+// All returns an iterator over the items in the set, it behaves as the Range function of the map backing the set.
+//
+// Avoid invoking any set functions within the loop body to prevent a deadlock.
+func (s *Set[T]) All() iter.Seq[T] {
+	return s.m.KeysSeq()
+}
+
+// read calls f with the content of s and other, holding the read locks of both sets.
+// The locks are taken in increasing order of transaction id, as a Txn does.
+func (s *Set[T]) read(other *Set[T], f func(a, b txn.RLocked[T, struct{}])) {
+	if s.r.TxID() == other.r.TxID() {
+		a := s.r.TxRLock()
+		defer a.RUnlock()
+		f(a, a)
+		return
+	}
+	first, second := s.r, other.r
+	swapped := first.TxID() > second.TxID()
+	if swapped {
+		first, second = second, first
+	}
+	a := first.TxRLock()
+	defer a.RUnlock()
+	b := second.TxRLock()
+	defer b.RUnlock()
+	if swapped {
+		a, b = b, a
+	}
+	f(a, b)
+}
+
+// filter returns the items of a for which keep returns true.
+func filter[T comparable](a txn.RLocked[T, struct{}], keep func(T) bool) (items []T) {
+	a.Range(func(item T, _ struct{}) bool {
+		if keep(item) {
+			items = append(items, item)
+		}
+		return true
+	})
+	return items
+}
+
+// Union returns a new set, backed by the same kind of map as s, holding the items present in either s or other.
+func (s *Set[T]) Union(other *Set[T]) *Set[T] {
+	var items []T
+	s.read(other, func(a, b txn.RLocked[T, struct{}]) {
+		items = filter(a, func(T) bool { return true })
+		items = append(items, filter(b, func(item T) bool {
+			_, ok := a.Load(item)
+			return !ok
+		})...)
+	})
+	return newSet(s.newMap, items)
+}
+
+// Intersect returns a new set, backed by the same kind of map as s, holding the items present in both s and other.
+func (s *Set[T]) Intersect(other *Set[T]) *Set[T] {
+	var items []T
+	s.read(other, func(a, b txn.RLocked[T, struct{}]) {
+		if b.Len() < a.Len() {
+			a, b = b, a
+		}
+		items = filter(a, func(item T) bool {
+			_, ok := b.Load(item)
+			return ok
+		})
+	})
+	return newSet(s.newMap, items)
+}
+
+// Difference returns a new set, backed by the same kind of map as s, holding the items of s not present in other.
+func (s *Set[T]) Difference(other *Set[T]) *Set[T] {
+	var items []T
+	s.read(other, func(a, b txn.RLocked[T, struct{}]) {
+		items = filter(a, func(item T) bool {
+			_, ok := b.Load(item)
+			return !ok
+		})
+	})
+	return newSet(s.newMap, items)
+}
+
+// IsSubset returns true if every item of s is present in other.
+func (s *Set[T]) IsSubset(other *Set[T]) (subset bool) {
+	s.read(other, func(a, b txn.RLocked[T, struct{}]) {
+		if a.Len() > b.Len() {
+			return
+		}
+		subset = true
+		a.Range(func(item T, _ struct{}) bool {
+			_, subset = b.Load(item)
+			return subset
+		})
+	})
+	return subset
+}
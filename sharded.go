@@ -0,0 +1,17 @@
+package typedmap
+
+import "github.com/thetechpanda/typedmap/internal/sharded"
+
+// NewSharded returns a new TypedMap that spreads its keys over the given number of shards,
+// each protected by its own RWMutex. Keys are assigned to shards using hash/maphash.
+//
+// Operations on a single key only lock the shard holding the key, reducing writers contention,
+// while Len, Keys, Values, Entries, Clear, UpdateRange and Exclusive lock every shard, in a fixed order,
+// and observe a consistent view of the whole map. Range visits one shard at a time and does not.
+//
+// The number of shards is rounded up to the next power of two, up to 65536, if shards is not positive a default of 32 is used.
+//
+// Use WithEqual to set how values are compared.
+func NewSharded[K comparable, V any](shards int, opts ...Option) TypedMap[K, V] {
+	return sharded.New[K](shards, equal[V](withoutOnRemove(opts)))
+}
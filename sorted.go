@@ -0,0 +1,51 @@
+package typedmap
+
+import (
+	"cmp"
+	"iter"
+
+	"github.com/thetechpanda/typedmap/internal/sorted"
+)
+
+// SortedMap is a TypedMap ordered by key: Range, Keys, Values, Entries, DeleteFunc and the iterators visit the entries
+// in ascending order of the keys. Lookups, insertions and removals are O(log N).
+//
+// Keys are ordered by a comparison function, keys comparing equal are the same key.
+type SortedMap[K comparable, V any] interface {
+	TypedMap[K, V]
+	// Min returns the entry with the smallest key, the ok result reports whether the map is not empty.
+	Min() (key K, value V, ok bool)
+	// Max returns the entry with the largest key, the ok result reports whether the map is not empty.
+	Max() (key K, value V, ok bool)
+	// Floor returns the entry with the largest key less than or equal to key, the ok result reports whether there is one.
+	Floor(key K) (k K, value V, ok bool)
+	// Ceiling returns the entry with the smallest key greater than or equal to key, the ok result reports whether there is one.
+	Ceiling(key K) (k K, value V, ok bool)
+	// RangeBetween returns an iterator over the entries whose keys are greater than or equal to lo and less than hi,
+	// in ascending order of the keys. Finding the first entry is O(log N).
+	//
+	// Avoid invoking any map functions within the loop body to prevent a deadlock.
+	RangeBetween(lo, hi K) iter.Seq2[K, V]
+	// DeleteRange removes the entries whose keys are greater than or equal to lo and less than hi,
+	// and returns how many entries were removed. It is O(log N) plus the number of entries removed.
+	DeleteRange(lo, hi K) (n int)
+	// Descend returns an iterator over the entries in descending order of the keys.
+	//
+	// Avoid invoking any map functions within the loop body to prevent a deadlock.
+	Descend() iter.Seq2[K, V]
+}
+
+// NewSorted returns a new SortedMap whose keys are ordered using cmp.Compare.
+//
+// Use WithEqual to set how values are compared.
+func NewSorted[K cmp.Ordered, V any](opts ...Option) SortedMap[K, V] {
+	return NewSortedFunc[K, V](cmp.Compare[K], opts...)
+}
+
+// NewSortedFunc returns a new SortedMap whose keys are ordered using compare, which returns a negative number if a < b,
+// a positive number if a > b and zero if a and b are the same key, as cmp.Compare does.
+//
+// Use WithEqual to set how values are compared.
+func NewSortedFunc[K comparable, V any](compare func(a, b K) int, opts ...Option) SortedMap[K, V] {
+	return sorted.New(compare, equal[V](withoutOnRemove(opts)))
+}
@@ -4,7 +4,10 @@ import "github.com/thetechpanda/typedmap/internal/syncmap"
 
 // SyncMap is a generic interface that provides a way to interact with the map.
 // its just a generic wrapper around sync.Map
-type SyncMap[K, V any] interface {
+//
+// SyncMap provides the same functions as TypedMap, so it can be used wherever a TypedMap is expected.
+// Since sync.Map cannot be locked, the functions spanning multiple operations are best-effort, as documented on each of them.
+type SyncMap[K comparable, V any] interface {
 	// Load returns the value stored in the map for a key, or nil if no
 	// value is present.
 	// The ok result indicates whether value was found in the map.
@@ -25,12 +28,14 @@ type SyncMap[K, V any] interface {
 	Swap(key K, value V) (previous V, loaded bool)
 	// CompareAndSwap swaps the old and new values for key
 	// if the value stored in the map is equal to old.
-	// The old value must be of a comparable type.
+	//
+	// Values are compared using ==, as sync.Map does, unless V implements Equaler or an equality function has been set using WithEqual.
+	// If the values cannot be compared CompareAndSwap returns false, see TryCompareAndSwap.
 	//
 	// Returns true if the swap was performed.
 	CompareAndSwap(key K, old, new V) bool
 	// CompareAndDelete deletes the entry for key if its value is equal to old.
-	// The old value must be of a comparable type.
+	// Values are compared as CompareAndSwap does, if they cannot be compared CompareAndDelete returns false, see TryCompareAndDelete.
 	//
 	// If there is no current value for key in the map, CompareAndDelete
 	// returns false (even if the old value is the nil interface value).
@@ -47,11 +52,87 @@ type SyncMap[K, V any] interface {
 	// Range may be O(N) with the number of elements in the map even if f returns
 	// false after a constant number of calls.
 	Range(f func(K, V) bool)
+	// DeleteFunc removes the entries for which f returns true and returns how many entries were removed.
+	//
+	// DeleteFunc is best-effort: as Range it does not correspond to any consistent snapshot of the map,
+	// an entry is removed only if its value has not been changed since it was passed to f.
+	DeleteFunc(f func(K, V) bool) (n int)
+	// Retain removes the entries for which f returns false, keeping the others, and returns how many entries were removed.
+	// As DeleteFunc, it is best-effort.
+	Retain(f func(K, V) bool) (n int)
+	// Update allows the caller to change the value associated with the key atomically guaranteeing that the value would not be changed by another goroutine during the operation.
+	//
+	// Update is implemented using a compare and swap loop: if the value for key changes while f is running, f is called again with the new value.
+	Update(key K, f func(V, bool) V)
+	// UpdateRange calls f sequentially for each key and value present in the map and replaces the value with the one returned by f.
+	// If f returns false, UpdateRange stops the iteration, without updating the corresponding value in the map.
+	//
+	// UpdateRange is best-effort: as Range it does not correspond to any consistent snapshot of the map,
+	// a value is replaced only if it has not been changed since it was passed to f.
+	UpdateRange(f func(K, V) (V, bool))
+	// UpdateRangeAtomic calls f sequentially for each key and value present in the map, as UpdateRange does,
+	// but the values returned by f are applied only once f has been called for every entry.
+	// If f returns false, or panics, UpdateRangeAtomic stops the iteration and the map is left untouched.
+	// The result reports whether the values have been applied.
+	//
+	// sync.Map cannot be locked: the values are applied one at a time, concurrent operations may observe part of them,
+	// and a value is replaced only if it has not been changed since it was passed to f.
+	UpdateRangeAtomic(f func(K, V) (V, bool)) bool
+	// Exclusive calls f with a copy of the content of the map, once f returns the changes made by f are applied to the map:
+	// missing entries are deleted, new and changed entries are stored.
+	//
+	// Unlike TypedMap, sync.Map cannot be locked: Exclusive does not prevent other operations from running concurrently with f,
+	// changes applied to the map while f is running may be lost.
+	Exclusive(f func(m map[K]V))
+	// Transaction calls f with a transaction staging the writes to the map, the staged writes are applied once f returns nil,
+	// they are discarded if f returns an error or panics.
+	//
+	// Unlike TypedMap, sync.Map cannot be locked: the writes are applied one at a time, concurrent operations may observe
+	// part of them and values loaded by the transaction may be changed concurrently before the writes are applied.
+	Transaction(f func(tx Tx[K, V]) error) error
+	// Clear removes all items from the map using sync.Map.Clear, Len may be inaccurate if keys are stored or deleted concurrently with Clear.
+	// If a removal hook is set, Clear deletes the keys one at a time to report them: as Range, it does not correspond to any consistent snapshot of the map.
+	Clear()
+	// Has returns true if the map contains the key.
+	Has(key K) bool
+	// Keys returns a slice of all the keys present in the map, an empty slice is returned if the map is empty.
+	// As Range, it does not correspond to any consistent snapshot of the map.
+	Keys() (keys []K)
+	// Values returns a slice of all the values present in the map, an empty slice is returned if the map is empty.
+	// As Range, it does not correspond to any consistent snapshot of the map.
+	Values() (values []V)
+	// Entries returns two slices, one containing all the keys and the other containing all the values present in the map.
+	// As Range, it does not correspond to any consistent snapshot of the map.
+	Entries() (keys []K, values []V)
+	// Len returns the number of unique keys in the map.
+	// It is an O(1) operation, backed by a counter updated by every operation adding or removing a key.
+	// The count is approximate if Clear runs concurrently with operations adding or removing keys.
+	Len() (n int)
+	// Iterators provides range-over-func iterators over the map, they behave as Range does.
+	Iterators[K, V]
+	// Computable provides Compute, ComputeIfAbsent and ComputeIfPresent, implemented using compare and swap loops:
+	// f may be called more than once if the value for the key changes concurrently.
+	Computable[K, V]
+	// Comparer provides TryCompareAndSwap and TryCompareAndDelete, reporting whether the values of the map can be compared.
+	Comparer[K, V]
 }
 
 // NewSyncMap a new SyncMap that wraps sync.Map with generics.
 // It allows the use of sync.Map natively and has the same drawbacks as sync.Map.
-// Using CompareAndSwap or CompareAndDelete with non comparable V types will panic, as it does in sync.Map.
-func NewSyncMap[K any, V any]() SyncMap[K, V] {
-	return syncmap.New[K, V]()
+//
+// Values are stored as is if compared using ==, otherwise they are stored boxed, so that the compare and swap operations work with values of any type:
+// CompareAndSwap and CompareAndDelete compare values using ==, unless V implements Equaler or an equality function is set using WithEqual,
+// and return false if the values cannot be compared. As in sync.Map, comparing interface values holding non comparable types using == panics.
+//
+// Unlike sync.Map, and previous versions of SyncMap, CompareAndSwap and CompareAndDelete do not panic if V is not comparable, eg. a slice:
+// they return false, use TryCompareAndSwap and TryCompareAndDelete to tell a mismatch from values that cannot be compared.
+// Boxing costs an allocation for each Store, Swap and LoadOrStore storing a value, see BENCHMARKS.md.
+//
+// Unlike previous versions of SyncMap, K must be comparable, as sync.Map panics with non comparable keys.
+//
+// Use WithOnRemove to be notified of the entries removed from the map: as sync.Map cannot be locked,
+// the function is called once each entry has been removed, by the goroutine that removed it.
+func NewSyncMap[K comparable, V any](opts ...Option) SyncMap[K, V] {
+	o := newOptions(opts)
+	return syncmap.New(onRemove[K, V](o), equal[V](o))
 }
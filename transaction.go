@@ -0,0 +1,77 @@
+package typedmap
+
+import (
+	"github.com/thetechpanda/typedmap/internal/syncmap"
+	"github.com/thetechpanda/typedmap/internal/txn"
+)
+
+// Tx is a transaction on a TypedMap, see TypedMap.Transaction.
+//
+// Writes are staged, Load returns the values staged by the transaction or, if none, the values stored in the map.
+// A Tx must not be used once the transaction function has returned.
+type Tx[K, V any] = txn.Tx[K, V]
+
+// Transactional is implemented by the maps supporting transactions, such as TypedMap and SyncMap.
+type Transactional[K, V any] interface {
+	// Transaction calls f with a transaction staging the writes to the map, see TypedMap.Transaction.
+	Transaction(f func(tx Tx[K, V]) error) error
+}
+
+// Txn is a transaction spanning several maps, possibly of different types: the writes staged on each map are applied
+// together once the transaction function returns nil, or discarded together.
+// The zero value is an empty Txn ready to use, a Txn must not be used concurrently.
+//
+//	var t typedmap.Txn
+//	from := typedmap.Enlist(&t, accounts)
+//	to := typedmap.Enlist(&t, archive)
+//	err := t.Run(func() error {
+//		v, ok := from.Load(id)
+//		if !ok {
+//			return ErrNotFound
+//		}
+//		from.Delete(id)
+//		to.Store(id, v)
+//		return nil
+//	})
+type Txn struct {
+	g txn.Group
+}
+
+// Enlist adds m to the maps of t and returns the transaction on m, enlisting the same map again returns the same Tx.
+// The Tx can only be used within the function passed to Run.
+//
+// Enlist panics if m does not support multi-map transactions, or if it is called while t is running.
+// A SyncMap does not support them: it cannot be locked, so its writes could be neither isolated nor applied atomically with the others.
+// An IndexedMap does not support them either: its writes could violate a unique index once the other maps have been written.
+// A LoadingMap is enlisted through the map storing its values, the values written by the transaction are considered loaded once applied.
+func Enlist[K comparable, V any](t *Txn, m Transactional[K, V]) Tx[K, V] {
+	l, loading := m.(*loadingMap[K, V])
+	if loading {
+		m = l.TypedMap
+	}
+	if _, ok := m.(*syncmap.SyncMap[K, V]); ok {
+		panic("typedmap: SyncMap does not support multi-map transactions")
+	}
+	tm, ok := m.(txn.Map[K, V])
+	if !ok {
+		panic("typedmap: map does not support multi-map transactions")
+	}
+	if loading {
+		tm = loadingTxMap[K, V]{Map: tm, m: l}
+	}
+	return txn.Enlist(&t.g, tm)
+}
+
+// Run locks the enlisted maps and calls f, the locks are held until Run returns.
+// If f returns nil the writes staged on every map are applied before releasing any lock, so that no other operation
+// observes part of them. Otherwise the writes are discarded and the error returned by f is returned, a panic is propagated.
+//
+// Maps are always locked in the same global order, whatever the order in which they were enlisted,
+// so that transactions sharing some of their maps cannot deadlock.
+// The removal hooks set using WithOnRemove are called once every enlisted map has been unlocked, so that they can use any of them.
+//
+// f must use the enlisted maps only through their Tx, as their locks are held.
+// Run can be called again, e.g. to retry, each call starts with no staged writes. Run panics if called from within f.
+func (t *Txn) Run(f func() error) error {
+	return t.g.Run(f)
+}
@@ -0,0 +1,38 @@
+package typedmap
+
+import (
+	"time"
+
+	"github.com/thetechpanda/typedmap/internal/ttl"
+)
+
+// TTLMap is a TypedMap where each entry expires after a given duration.
+//
+// Expired entries are never visible: Load, Has, Range, Keys, Values, Entries and Len behave as if they were not present.
+// They are removed lazily when accessed, when Sweep is called or by the background sweeper enabled with WithSweepInterval.
+//
+// Operations storing a value for a single key (Store, LoadOrStore, Swap, CompareAndSwap and Update) set the entry to expire after the default ttl,
+// UpdateRange and Exclusive preserve the deadline of existing entries.
+type TTLMap[K comparable, V any] interface {
+	TypedMap[K, V]
+	// StoreWithTTL sets the value for a key, the entry expires after ttl.
+	// If ttl is not positive the entry never expires.
+	StoreWithTTL(key K, value V, ttl time.Duration)
+	// Deadline returns the time at which the entry for key expires.
+	// The ok result is false if the key is not present or has expired,
+	// a zero deadline with ok set to true means the entry never expires.
+	Deadline(key K) (deadline time.Time, ok bool)
+	// Sweep removes all the expired entries from the map and returns how many were removed.
+	Sweep() (n int)
+	// Close stops the background sweeper, if any. The map can still be used after Close.
+	Close()
+}
+
+// NewTTL returns a new TTLMap where entries expire after defaultTTL, if defaultTTL is not positive entries never expire unless stored using StoreWithTTL.
+//
+// Use WithClock to provide the time source of the map, WithSweepInterval to periodically remove expired entries in the background
+// and WithOnRemove to be notified of the entries leaving the map. Use WithEqual to set how values are compared.
+func NewTTL[K comparable, V any](defaultTTL time.Duration, opts ...Option) TTLMap[K, V] {
+	o := newOptions(opts)
+	return ttl.New(defaultTTL, o.now, o.sweepInterval, onRemove[K, V](o), equal[V](o))
+}
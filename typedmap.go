@@ -9,4 +9,5 @@
 //
 //   - sync.Map uses K, V any, which means that the keys and values can be of any type. However, the typedmap package uses K comparable, V any, which means that the keys must be comparable.
 //   - The CompareAndSwap and CompareAndDelete functions use reflect.DeepEqual to compare the values, which may not be as efficient as using the == operator for simple types. TypeMap detects if the value is comparable type and will always return false if it is not.
+//     Values implementing Equaler are compared using their Equal method, WithEqual sets the function used to compare values, and NewComparable returns a map comparing values using ==.
 package typedmap
@@ -1,7 +1,16 @@
 package typedmap_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/thetechpanda/typedmap"
 )
@@ -15,6 +24,64 @@ func TestNew(t *testing.T) {
 		t.Errorf("typedmap.NewWithMap[string, int](nil).Has(`k`) expected false, got true")
 	}
 
+	if typedmap.NewComparable[string, int]().Has(`k`) {
+		t.Errorf("typedmap.NewComparable[string, int]().Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewVersioned[string, int]().Has(`k`) {
+		t.Errorf("typedmap.NewVersioned[string, int]().Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewSharded[string, int](0).Has(`k`) {
+		t.Errorf("typedmap.NewSharded[string, int](0).Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewCOW[string, int](nil).Has(`k`) {
+		t.Errorf("typedmap.NewCOW[string, int](nil).Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewOrdered[string, int]().Has(`k`) {
+		t.Errorf("typedmap.NewOrdered[string, int]().Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewSorted[string, int]().Has(`k`) {
+		t.Errorf("typedmap.NewSorted[string, int]().Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewRanked[string, int]().Has(`k`) {
+		t.Errorf("typedmap.NewRanked[string, int]().Has(`k`) expected false, got true")
+	}
+	if typedmap.NewHashTrie[string, int]().Has(`k`) {
+		t.Errorf("typedmap.NewHashTrie[string, int]().Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewTTL[string, int](time.Minute, typedmap.WithClock(time.Now), typedmap.WithSweepInterval(time.Minute)).Has(`k`) {
+		t.Errorf("typedmap.NewTTL[string, int](time.Minute).Has(`k`) expected false, got true")
+	}
+
+	if typedmap.NewLRU[string, int](1).Has(`k`) {
+		t.Errorf("typedmap.NewLRU[string, int](1).Has(`k`) expected false, got true")
+	}
+
+	for _, p := range []typedmap.Policy{typedmap.PolicyLRU, typedmap.PolicyLFU, typedmap.PolicyARC, typedmap.PolicyS3FIFO, typedmap.PolicyWTinyLFU, -1} {
+		if typedmap.NewCache[string, int](1, typedmap.WithPolicy(p)).Has(`k`) {
+			t.Errorf("typedmap.NewCache[string, int](1, typedmap.WithPolicy(%s)).Has(`k`) expected false, got true", p)
+		}
+	}
+
+	loader := func(ctx context.Context, key string) (int, error) { return len(key), nil }
+	if typedmap.NewLoading[string, int](nil, loader, typedmap.WithNegativeTTL(time.Minute), typedmap.WithRefreshAfter(time.Minute), typedmap.WithExpireAfter(time.Hour)).Has(`k`) {
+		t.Errorf("typedmap.NewLoading[string, int](nil, loader).Has(`k`) expected false, got true")
+	}
+	if v, err := typedmap.NewLoading(typedmap.NewLRU[string, int](1), loader).Get(context.Background(), `key`); err != nil || v != 3 {
+		t.Errorf("typedmap.NewLoading[string, int](typedmap.NewLRU[string, int](1), loader).Get(`key`) expected 3, got %d, %v", v, err)
+	}
+
+	var sm typedmap.TypedMap[string, int] = typedmap.NewSyncMap[string, int]()
+	if sm.Has(`k`) {
+		t.Errorf("typedmap.NewSyncMap[string, int]().Has(`k`) expected false, got true")
+	}
+
 	if _, ok := typedmap.NewSyncMap[string, int]().Load(`k`); ok {
 		t.Errorf("typedmap.NewSyncMap[string, int]().Load(`k`) expected false, got true")
 	}
@@ -23,3 +90,963 @@ func TestNew(t *testing.T) {
 		t.Errorf("typedmap.NewSyncMapCompatible[string, int]().Load(`k`) expected false, got true")
 	}
 }
+
+// typedMaps returns a new map for each TypedMap constructor, the conformance tests below run against all of them,
+// the behaviour specific to each map is tested in its own package.
+func typedMaps() map[string]typedmap.TypedMap[string, int] {
+	maps := map[string]typedmap.TypedMap[string, int]{
+		"New":           typedmap.New[string, int](),
+		"NewComparable": typedmap.NewComparable[string, int](),
+		"NewSharded":    typedmap.NewSharded[string, int](4),
+		"NewSyncMap":    typedmap.NewSyncMap[string, int](),
+		"NewTTL":        typedmap.NewTTL[string, int](time.Minute),
+		"NewLRU":        typedmap.NewLRU[string, int](100),
+		"NewVersioned":  typedmap.NewVersioned[string, int](),
+		"NewCOW":        typedmap.NewCOW[string, int](nil),
+		"NewOrdered":    typedmap.NewOrdered[string, int](),
+		"NewSorted":     typedmap.NewSorted[string, int](),
+		"NewRanked":     typedmap.NewRanked[string, int](),
+		"NewLoading":    typedmap.NewLoading[string, int](nil, nil),
+		"NewIndexed":    typedmap.NewIndexed[string, int](),
+	}
+	for _, p := range []typedmap.Policy{typedmap.PolicyLRU, typedmap.PolicyLFU, typedmap.PolicyARC, typedmap.PolicyS3FIFO, typedmap.PolicyWTinyLFU} {
+		maps["NewCache/"+p.String()] = typedmap.NewCache[string, int](100, typedmap.WithPolicy(p))
+	}
+	return maps
+}
+
+// forEachTypedMap runs f as a subtest for a new map returned by each TypedMap constructor.
+func forEachTypedMap(t *testing.T, f func(t *testing.T, m typedmap.TypedMap[string, int])) {
+	for name, m := range typedMaps() {
+		t.Run(name, func(t *testing.T) {
+			f(t, m)
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	forEachTypedMap(t, func(t *testing.T, m typedmap.TypedMap[string, int]) {
+		// check verifies both the result of op and the content of the map for key.
+		check := func(op string, key string, value int, ok bool, expectValue int, expectOk bool) {
+			t.Helper()
+			if value != expectValue || ok != expectOk {
+				t.Errorf("%s: Expected %d, %v, got %d, %v", op, expectValue, expectOk, value, ok)
+			}
+			if v, ok := m.Load(key); v != expectValue || ok != expectOk {
+				t.Errorf("%s: Expected map to contain %d, %v, got %d, %v", op, expectValue, expectOk, v, ok)
+			}
+		}
+		v, ok := m.Compute(`a`, func(old int, loaded bool) (int, typedmap.Op) {
+			if loaded {
+				t.Errorf("Compute(): Expected missing key, got %d", old)
+			}
+			return 1, typedmap.OpKeep
+		})
+		check("Compute()", `a`, v, ok, 0, false)
+		v, ok = m.Compute(`a`, func(old int, loaded bool) (int, typedmap.Op) { return 1, typedmap.OpStore })
+		check("Compute()", `a`, v, ok, 1, true)
+		v, ok = m.Compute(`a`, func(old int, loaded bool) (int, typedmap.Op) {
+			if !loaded || old != 1 {
+				t.Errorf("Compute(): Expected value 1, got %d, %v", old, loaded)
+			}
+			return old + 1, typedmap.OpStore
+		})
+		check("Compute()", `a`, v, ok, 2, true)
+		v, ok = m.Compute(`a`, func(old int, loaded bool) (int, typedmap.Op) { return 10, typedmap.OpKeep })
+		check("Compute()", `a`, v, ok, 2, true)
+		v, ok = m.Compute(`a`, func(old int, loaded bool) (int, typedmap.Op) { return 10, typedmap.OpDelete })
+		check("Compute()", `a`, v, ok, 0, false)
+		v, ok = m.Compute(`a`, func(old int, loaded bool) (int, typedmap.Op) { return 10, typedmap.OpDelete })
+		check("Compute()", `a`, v, ok, 0, false)
+
+		v, ok = m.ComputeIfAbsent(`b`, func() (int, typedmap.Op) { return 3, typedmap.OpStore })
+		check("ComputeIfAbsent()", `b`, v, ok, 3, true)
+		v, ok = m.ComputeIfAbsent(`b`, func() (int, typedmap.Op) {
+			t.Error("ComputeIfAbsent(): Expected f not to be called for a present key")
+			return 4, typedmap.OpStore
+		})
+		check("ComputeIfAbsent()", `b`, v, ok, 3, true)
+		v, ok = m.ComputeIfAbsent(`c`, func() (int, typedmap.Op) { return 4, typedmap.OpKeep })
+		check("ComputeIfAbsent()", `c`, v, ok, 0, false)
+
+		v, ok = m.ComputeIfPresent(`c`, func(old int) (int, typedmap.Op) {
+			t.Error("ComputeIfPresent(): Expected f not to be called for a missing key")
+			return 5, typedmap.OpStore
+		})
+		check("ComputeIfPresent()", `c`, v, ok, 0, false)
+		v, ok = m.ComputeIfPresent(`b`, func(old int) (int, typedmap.Op) { return old * 2, typedmap.OpStore })
+		check("ComputeIfPresent()", `b`, v, ok, 6, true)
+		v, ok = m.ComputeIfPresent(`b`, func(old int) (int, typedmap.Op) { return 0, typedmap.OpDelete })
+		check("ComputeIfPresent()", `b`, v, ok, 0, false)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					m.Compute(`counter`, func(old int, loaded bool) (int, typedmap.Op) { return old + 1, typedmap.OpStore })
+				}
+			}()
+		}
+		wg.Wait()
+		if v, _ := m.Load(`counter`); v != 10000 {
+			t.Errorf("Compute(): Expected counter to be 10000, got %d", v)
+		}
+	})
+}
+
+func TestDeleteFunc(t *testing.T) {
+	forEachTypedMap(t, func(t *testing.T, m typedmap.TypedMap[string, int]) {
+		for i := 0; i < 10; i++ {
+			m.Store(string(rune('a'+i)), i)
+		}
+		if n := m.DeleteFunc(func(k string, v int) bool { return v%2 == 0 }); n != 5 {
+			t.Errorf("DeleteFunc(): Expected 5 entries to be removed, got %d", n)
+		}
+		if n := m.Retain(func(k string, v int) bool { return v < 5 }); n != 3 {
+			t.Errorf("Retain(): Expected 3 entries to be removed, got %d", n)
+		}
+		if n := m.DeleteFunc(func(k string, v int) bool { return false }); n != 0 {
+			t.Errorf("DeleteFunc(): Expected no entries to be removed, got %d", n)
+		}
+		if keys := slices.Sorted(slices.Values(m.Keys())); !slices.Equal(keys, []string{`b`, `d`}) {
+			t.Errorf("DeleteFunc(): Expected keys [b d], got %v", keys)
+		}
+		if m.Len() != 2 {
+			t.Errorf("Len(): Expected 2 entries, got %d", m.Len())
+		}
+	})
+}
+
+func TestUpdateRangeAtomic(t *testing.T) {
+	forEachTypedMap(t, func(t *testing.T, m typedmap.TypedMap[string, int]) {
+		for i := 0; i < 10; i++ {
+			m.Store(strconv.Itoa(i), 1)
+		}
+		sum := func() (n int) {
+			for _, v := range m.Values() {
+				n += v
+			}
+			return n
+		}
+		calls := 0
+		if m.UpdateRangeAtomic(func(k string, v int) (int, bool) {
+			calls++
+			return 2, calls < 5
+		}) || calls != 5 || sum() != 10 {
+			t.Errorf("UpdateRangeAtomic(): Expected the map to be left untouched after %d calls, got sum %d", calls, sum())
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("UpdateRangeAtomic(): Expected the panic to be propagated")
+				}
+			}()
+			calls = 0
+			m.UpdateRangeAtomic(func(k string, v int) (int, bool) {
+				if calls++; calls == 5 {
+					panic("boom")
+				}
+				return 2, true
+			})
+		}()
+		if sum() != 10 {
+			t.Errorf("UpdateRangeAtomic(): Expected the map to be left untouched by a panic, got sum %d", sum())
+		}
+
+		if !m.UpdateRangeAtomic(func(k string, v int) (int, bool) {
+			i, _ := strconv.Atoi(k)
+			return v + i, true
+		}) || sum() != 55 {
+			t.Errorf("UpdateRangeAtomic(): Expected every value to be updated, got sum %d", sum())
+		}
+	})
+}
+
+func TestLRU(t *testing.T) {
+	// the policy set using WithPolicy is ignored, LFU would evict b.
+	opts := []typedmap.Option{typedmap.WithPolicy(typedmap.PolicyLFU)}
+	m := typedmap.NewLRU[string, int](2, opts...)
+	m.Store(`a`, 1)
+	m.Load(`a`)
+	m.Load(`a`)
+	m.Store(`b`, 2)
+	m.Load(`b`)
+	m.Store(`c`, 3)
+	if keys := slices.Sorted(m.KeysSeq()); !slices.Equal(keys, []string{`b`, `c`}) || m.Capacity() != 2 {
+		t.Errorf("NewLRU() expected the least recently used entry to be evicted, got %v", keys)
+	}
+	if len(opts) != 1 {
+		t.Errorf("NewLRU() expected the options not to be modified, got %v", opts)
+	}
+}
+
+func TestWithOnRemove(t *testing.T) {
+	var removed []string
+	m := typedmap.NewLRU[string, int](1, typedmap.WithOnRemove(func(key string, value int, reason typedmap.RemovalReason) {
+		removed = append(removed, key+":"+reason.String())
+	}))
+	m.Store(`a`, 1)
+	m.Store(`b`, 2)
+	if len(removed) != 1 || removed[0] != `a:Capacity` {
+		t.Errorf("WithOnRemove() expected [a:Capacity], got %v", removed)
+	}
+
+	// New, NewWithMap and NewSyncMap notify the removals as well.
+	record := typedmap.WithOnRemove(func(key string, value int, reason typedmap.RemovalReason) {
+		removed = append(removed, key+":"+reason.String())
+	})
+	for name, m := range map[string]typedmap.TypedMap[string, int]{
+		"New":        typedmap.New[string, int](record),
+		"NewWithMap": typedmap.NewWithMap(map[string]int{`a`: 2}, record),
+		"NewSyncMap": typedmap.NewSyncMap[string, int](record),
+	} {
+		removed = nil
+		m.Store(`a`, 2)
+		m.Store(`b`, 2)
+		m.Delete(`a`)
+		m.Clear()
+		if expect := []string{`a:Deleted`, `b:Cleared`}; !slices.Equal(removed, expect) {
+			t.Errorf("typedmap.%s(typedmap.WithOnRemove(...)) expected %v, got %v", name, expect, removed)
+		}
+	}
+
+	// the constructors not supporting WithOnRemove panic rather than ignoring it.
+	hook := typedmap.WithOnRemove(func(string, int, typedmap.RemovalReason) {})
+	for name, f := range map[string]func(){
+		"NewSharded":   func() { typedmap.NewSharded[string, int](4, hook) },
+		"NewCOW":       func() { typedmap.NewCOW(map[string]int{}, hook) },
+		"NewHashTrie":  func() { typedmap.NewHashTrie[string, int](hook) },
+		"NewVersioned": func() { typedmap.NewVersioned[string, int](hook) },
+		"NewOrdered":   func() { typedmap.NewOrdered[string, int](hook) },
+		"NewSorted":    func() { typedmap.NewSorted[string, int](hook) },
+		"NewRanked":    func() { typedmap.NewRanked[string, int](hook) },
+		"NewLoading":   func() { typedmap.NewLoading[string, int](nil, nil, hook) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("typedmap.%s(typedmap.WithOnRemove(...)) expected panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("typedmap.NewTTL[string, string](time.Minute, typedmap.WithOnRemove(func(string, int, RemovalReason))) expected panic")
+		}
+	}()
+	typedmap.NewTTL[string, string](time.Minute, typedmap.WithOnRemove(func(string, int, typedmap.RemovalReason) {}))
+}
+
+func TestWithEqual(t *testing.T) {
+	maps := map[string]typedmap.TypedMap[string, []byte]{
+		"New":           typedmap.New[string, []byte](typedmap.WithEqual(bytes.Equal)),
+		"NewWithMap":    typedmap.NewWithMap(map[string][]byte{}, typedmap.WithEqual(bytes.Equal)),
+		"NewSharded":    typedmap.NewSharded[string, []byte](0, typedmap.WithEqual(bytes.Equal)),
+		"NewTTL":        typedmap.NewTTL[string, []byte](0, typedmap.WithEqual(bytes.Equal)),
+		"NewLRU":        typedmap.NewLRU[string, []byte](10, typedmap.WithEqual(bytes.Equal)),
+		"NewCache":      typedmap.NewCache[string, []byte](10, typedmap.WithEqual(bytes.Equal)),
+		"NewVersioned":  typedmap.NewVersioned[string, []byte](typedmap.WithEqual(bytes.Equal)),
+		"NewCOW":        typedmap.NewCOW[string, []byte](nil, typedmap.WithEqual(bytes.Equal)),
+		"NewOrdered":    typedmap.NewOrdered[string, []byte](typedmap.WithEqual(bytes.Equal)),
+		"NewSorted":     typedmap.NewSorted[string, []byte](typedmap.WithEqual(bytes.Equal)),
+		"NewRankedFunc": typedmap.NewRankedFunc[string](func(v []byte) int { return len(v) }, typedmap.WithEqual(bytes.Equal)),
+	}
+	for name, m := range maps {
+		m.Store(`k`, []byte(`a`))
+		if swapped, err := m.TryCompareAndSwap(`k`, []byte(`a`), []byte(`b`)); !swapped || err != nil {
+			t.Errorf("%s: TryCompareAndSwap() expected true, nil, got %v, %v", name, swapped, err)
+		}
+	}
+	s := typedmap.NewSyncMap[string, []byte](typedmap.WithEqual(bytes.Equal))
+	s.Store(`k`, []byte(`a`))
+	if !s.CompareAndDelete(`k`, []byte(`a`)) {
+		t.Errorf("NewSyncMap: CompareAndDelete() expected true, got false")
+	}
+	h := typedmap.NewHashTrie[string, []byte](typedmap.WithEqual(bytes.Equal))
+	h.Store(`k`, []byte(`a`))
+	if !h.CompareAndDelete(`k`, []byte(`a`)) {
+		t.Errorf("NewHashTrie: CompareAndDelete() expected true, got false")
+	}
+	if _, err := typedmap.New[string, []byte]().TryCompareAndDelete(`k`, nil); !errors.Is(err, typedmap.ErrNotComparable) {
+		t.Errorf("TryCompareAndDelete() expected ErrNotComparable, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("typedmap.New[string, string](typedmap.WithEqual(bytes.Equal)) expected panic")
+		}
+	}()
+	typedmap.New[string, string](typedmap.WithEqual(bytes.Equal))
+}
+
+func TestTransaction(t *testing.T) {
+	forEachTypedMap(t, func(t *testing.T, m typedmap.TypedMap[string, int]) {
+		m.Store(`a`, 10)
+		m.Store(`b`, 0)
+		// transfer moves amount from a to b, failing if a does not hold enough.
+		errFunds := errors.New("insufficient funds")
+		transfer := func(amount int) error {
+			return m.Transaction(func(tx typedmap.Tx[string, int]) error {
+				a, _ := tx.Load(`a`)
+				b, _ := tx.Load(`b`)
+				tx.Store(`a`, a-amount)
+				tx.Store(`b`, b+amount)
+				if a < amount {
+					return errFunds
+				}
+				return nil
+			})
+		}
+		if err := transfer(4); err != nil {
+			t.Errorf("Transaction(): Expected no error, got %v", err)
+		}
+		if err := transfer(7); !errors.Is(err, errFunds) {
+			t.Errorf("Transaction(): Expected errFunds, got %v", err)
+		}
+		a, _ := m.Load(`a`)
+		b, _ := m.Load(`b`)
+		if a != 6 || b != 4 {
+			t.Errorf("Transaction(): Expected a=6 b=4, got a=%d b=%d", a, b)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Transaction(): Expected the panic to be propagated")
+				}
+			}()
+			m.Transaction(func(tx typedmap.Tx[string, int]) error {
+				tx.Delete(`a`)
+				panic("boom")
+			})
+		}()
+		if err := m.Transaction(func(tx typedmap.Tx[string, int]) error {
+			if _, ok := tx.Load(`a`); !ok {
+				t.Errorf("Load(): Expected the writes of the panicking transaction to be discarded")
+			}
+			tx.Delete(`a`)
+			tx.Delete(`missing`)
+			tx.Store(`c`, 1)
+			return nil
+		}); err != nil || m.Has(`a`) || !m.Has(`c`) {
+			t.Errorf("Transaction(): Expected a deleted and c stored, got %v, %v", err, m.Keys())
+		}
+	})
+}
+
+// noTxMap supports single-map transactions only.
+type noTxMap struct {
+	typedmap.TypedMap[string, int]
+}
+
+func TestLoadingExpireAfter(t *testing.T) {
+	now := time.Now()
+	m := typedmap.NewLoading[string, int](nil, func(ctx context.Context, key string) (int, error) {
+		return len(key), nil
+	}, typedmap.WithExpireAfter(time.Minute), typedmap.WithClock(func() time.Time { return now }))
+	m.Get(context.Background(), `a`)
+	m.Get(context.Background(), `bb`)
+	if v, ok := m.Load(`a`); !ok || v != 1 || len(m.Keys()) != 2 {
+		t.Errorf("Load() expected a fresh value, got %d, %v", v, ok)
+	}
+
+	// expired values are hidden from the read functions until they are loaded again.
+	now = now.Add(time.Minute)
+	m.Store(`c`, 3)
+	if _, ok := m.Load(`a`); ok || m.Has(`bb`) || !m.Has(`c`) {
+		t.Errorf("Load() expected expired values to be missing")
+	}
+	if n := m.Len(); n != 1 {
+		t.Errorf("Len() expected expired values not to be counted, got %d", n)
+	}
+	expect := map[string]int{`c`: 3}
+	if got := maps.Collect(m.All()); !maps.Equal(got, expect) {
+		t.Errorf("All() expected %v, got %v", expect, got)
+	}
+	got := map[string]int{}
+	m.Range(func(key string, value int) bool {
+		got[key] = value
+		return true
+	})
+	if !maps.Equal(got, expect) {
+		t.Errorf("Range() expected %v, got %v", expect, got)
+	}
+	if keys, values := m.Entries(); !slices.Equal(keys, []string{`c`}) || !slices.Equal(values, []int{3}) {
+		t.Errorf("Entries() expected [c] [3], got %v %v", keys, values)
+	}
+	if keys, values := m.Keys(), m.Values(); !slices.Equal(keys, []string{`c`}) || !slices.Equal(values, []int{3}) {
+		t.Errorf("Keys(), Values() expected [c] [3], got %v %v", keys, values)
+	}
+	if keys, values := slices.Collect(m.KeysSeq()), slices.Collect(m.ValuesSeq()); !slices.Equal(keys, []string{`c`}) || !slices.Equal(values, []int{3}) {
+		t.Errorf("KeysSeq(), ValuesSeq() expected [c] [3], got %v %v", keys, values)
+	}
+	for range m.All() {
+		break
+	}
+	for range m.KeysSeq() {
+		break
+	}
+	for range m.ValuesSeq() {
+		break
+	}
+	m.Range(func(string, int) bool { return false })
+
+	if v, err := m.Get(context.Background(), `a`); err != nil || v != 1 || !m.Has(`a`) || m.Len() != 2 {
+		t.Errorf("Get() expected the expired value to be loaded again, got %d, %v", v, err)
+	}
+}
+
+func TestLoadingWrites(t *testing.T) {
+	now := time.Now()
+	var calls atomic.Int64
+	m := typedmap.NewLoading[string, int](nil, func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		return len(key), nil
+	}, typedmap.WithExpireAfter(time.Minute), typedmap.WithClock(func() time.Time { return now }))
+	keys := []string{`a`, `b`, `c`, `d`, `e`, `f`, `g`, `h`, `i`, `j`, `k`}
+	load := func() {
+		for _, key := range keys {
+			m.Get(context.Background(), key)
+		}
+		now = now.Add(2 * time.Minute)
+	}
+
+	// a value written after expiring is fresh again.
+	load()
+	m.Store(`a`, 42)
+	m.Swap(`b`, 42)
+	m.CompareAndSwap(`c`, 1, 42)
+	m.TryCompareAndSwap(`d`, 1, 42)
+	m.Update(`e`, func(int, bool) int { return 42 })
+	m.Compute(`f`, func(int, bool) (int, typedmap.Op) { return 42, typedmap.OpStore })
+	m.ComputeIfPresent(`g`, func(int) (int, typedmap.Op) { return 42, typedmap.OpStore })
+	m.Transaction(func(tx typedmap.Tx[string, int]) error {
+		tx.Store(`h`, 42)
+		return nil
+	})
+	for _, key := range keys[:8] {
+		if v, ok := m.Load(key); !ok || v != 42 {
+			t.Errorf("Load(%s) expected the written value 42, got %d, %v", key, v, ok)
+		}
+	}
+	if got := m.Keys(); len(got) != 8 || m.Len() != 8 {
+		t.Errorf("Keys() expected the 8 written keys, got %v", got)
+	}
+	if v, err := m.Get(context.Background(), `a`); err != nil || v != 42 || calls.Load() != int64(len(keys)) {
+		t.Errorf("Get() expected the written value without a load, got %d, %v after %d loads", v, err, calls.Load())
+	}
+
+	// a key deleted, then stored again after the expire age, is not expired.
+	m.Clear()
+	load()
+	m.Delete(`a`)
+	m.LoadAndDelete(`b`)
+	m.CompareAndDelete(`c`, 1)
+	m.TryCompareAndDelete(`d`, 1)
+	m.Compute(`e`, func(int, bool) (int, typedmap.Op) { return 0, typedmap.OpDelete })
+	m.DeleteFunc(func(key string, _ int) bool { return key == `f` })
+	m.Retain(func(key string, _ int) bool { return key != `g` })
+	m.Transaction(func(tx typedmap.Tx[string, int]) error {
+		tx.Delete(`h`)
+		return nil
+	})
+	now = now.Add(2 * time.Minute)
+	for i, key := range keys[:8] {
+		if i%2 == 0 {
+			m.LoadOrStore(key, 7)
+		} else {
+			m.ComputeIfAbsent(key, func() (int, typedmap.Op) { return 7, typedmap.OpStore })
+		}
+		if v, ok := m.Load(key); !ok || v != 7 {
+			t.Errorf("Load(%s) expected the value stored after the delete, got %d, %v", key, v, ok)
+		}
+	}
+
+	load()
+	m.UpdateRange(func(key string, value int) (int, bool) { return 1, false })
+	if got := m.Keys(); len(got) != 0 {
+		t.Errorf("UpdateRange() expected no value to be updated, got %v", got)
+	}
+	m.UpdateRange(func(key string, value int) (int, bool) { return 1, true })
+	if got := m.Keys(); len(got) != len(keys) {
+		t.Errorf("UpdateRange() expected the updated values to be fresh, got %v", got)
+	}
+	load()
+	if m.UpdateRangeAtomic(func(key string, value int) (int, bool) { return 1, key != `k` }) || len(m.Keys()) != 0 {
+		t.Errorf("UpdateRangeAtomic() expected no value to be updated")
+	}
+	m.UpdateRangeAtomic(func(key string, value int) (int, bool) { return 1, true })
+	if got := m.Keys(); len(got) != len(keys) {
+		t.Errorf("UpdateRangeAtomic() expected every value to be fresh, got %v", got)
+	}
+	load()
+	m.Exclusive(func(data map[string]int) { delete(data, `a`) })
+	if got := m.Keys(); len(got) != len(keys)-1 {
+		t.Errorf("Exclusive() expected every value left to be fresh, got %v", got)
+	}
+	load()
+	m.Transaction(func(tx typedmap.Tx[string, int]) error {
+		tx.Store(`a`, 1)
+		return errors.New("rollback")
+	})
+	m.Clear()
+	m.Store(`a`, 1)
+	if got := m.Keys(); len(got) != 1 {
+		t.Errorf("Clear() expected the values stored again to be fresh, got %v", got)
+	}
+}
+
+func TestTxn(t *testing.T) {
+	from := typedmap.New[string, int]()
+	to := typedmap.NewLRU[string, int](10)
+	loaded := typedmap.NewLoading[string, int](nil, func(ctx context.Context, key string) (int, error) {
+		return 0, nil
+	})
+	from.Store(`a`, 10)
+
+	var txn typedmap.Txn
+	// enlisted in the opposite order in the second transaction, the maps are still locked in the same order.
+	txFrom, txTo := typedmap.Enlist(&txn, from), typedmap.Enlist(&txn, to)
+	txLoaded := typedmap.Enlist(&txn, loaded)
+	var reverse typedmap.Txn
+	revTo, revFrom := typedmap.Enlist(&reverse, to), typedmap.Enlist(&reverse, from)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			txn.Run(func() error {
+				v, _ := txFrom.Load(`a`)
+				txFrom.Store(`a`, v-1)
+				w, _ := txTo.Load(`a`)
+				txTo.Store(`a`, w+1)
+				txLoaded.Store(`a`, w+1)
+				return nil
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			reverse.Run(func() error {
+				w, _ := revTo.Load(`a`)
+				revTo.Store(`a`, w-1)
+				v, _ := revFrom.Load(`a`)
+				revFrom.Store(`a`, v+1)
+				return errors.New(`abort`)
+			})
+		}()
+		wg.Wait()
+	}
+	a, _ := from.Load(`a`)
+	b, _ := to.Load(`a`)
+	c, _ := loaded.Load(`a`)
+	if a != -90 || b != 100 || c != 100 {
+		t.Errorf("Txn.Run() expected a=-90 in from and a=100 in to, got %d, %d, %d", a, b, c)
+	}
+
+	for _, m := range []typedmap.Transactional[string, int]{noTxMap{from}, typedmap.NewSyncMap[string, int](), typedmap.NewIndexed[string, int]()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Enlist() expected a panic for %T, not supporting multi-map transactions", m)
+				}
+			}()
+			typedmap.Enlist(&txn, m)
+		}()
+	}
+}
+
+func TestTxnLoading(t *testing.T) {
+	now := time.Now()
+	var calls atomic.Int64
+	var removed []string
+	m := typedmap.NewCache[string, int](10, typedmap.WithOnRemove(func(key string, value int, reason typedmap.RemovalReason) {
+		removed = append(removed, key)
+	}))
+	loaded := typedmap.NewLoading(m, func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		return len(key), nil
+	}, typedmap.WithExpireAfter(time.Minute), typedmap.WithClock(func() time.Time { return now }))
+	loaded.Get(context.Background(), `a`)
+	loaded.Get(context.Background(), `b`)
+	now = now.Add(2 * time.Minute)
+
+	// the values written by a multi-map transaction are fresh, as the ones written by Store.
+	var txn typedmap.Txn
+	tx := typedmap.Enlist(&txn, loaded)
+	err := txn.Run(func() error {
+		tx.Store(`a`, 42)
+		tx.Delete(`b`)
+		return nil
+	})
+	if v, ok := loaded.Load(`a`); err != nil || !ok || v != 42 || !slices.Equal(removed, []string{`b`, `a`}) {
+		t.Errorf("Txn.Run() expected the stored value to be fresh and the hook called for b then a, got %d, %v, %v, %v", v, ok, removed, err)
+	}
+	now = now.Add(2 * time.Minute)
+	m.Store(`b`, 7)
+	if v, err := loaded.Get(context.Background(), `b`); err != nil || v != 7 || calls.Load() != 2 {
+		t.Errorf("Get() expected the value stored after the delete not to be reloaded, got %d, %v after %d loads", v, err, calls.Load())
+	}
+
+	// a loading map over a map not supporting multi-map transactions cannot be enlisted.
+	for _, m := range []typedmap.TypedMap[string, int]{noTxMap{typedmap.New[string, int]()}, typedmap.NewSyncMap[string, int]()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Enlist() expected a panic for a loading map over %T, not supporting multi-map transactions", m)
+				}
+			}()
+			typedmap.Enlist(&txn, typedmap.NewLoading(m, nil))
+		}()
+	}
+}
+
+func TestTxnOnRemove(t *testing.T) {
+	// archive is created first, so that it is locked before cache and unlocked after it.
+	archive := typedmap.New[string, int]()
+	cache := typedmap.NewLRU[string, int](1, typedmap.WithOnRemove(func(key string, value int, reason typedmap.RemovalReason) {
+		// the hook uses the other map of the transaction, which must no longer be locked.
+		archive.Store(key, value)
+	}))
+	cache.Store(`a`, 1)
+
+	var txn typedmap.Txn
+	txCache, txArchive := typedmap.Enlist(&txn, cache), typedmap.Enlist(&txn, archive)
+	err := txn.Run(func() error {
+		txCache.Store(`b`, 2)
+		txArchive.Store(`c`, 3)
+		return nil
+	})
+	if v, ok := archive.Load(`a`); err != nil || !ok || v != 1 || !archive.Has(`c`) {
+		t.Errorf("Txn.Run() expected the evicted entry to be archived, got %d, %v, %v", v, ok, err)
+	}
+}
+
+func TestTxnDeleteStore(t *testing.T) {
+	ordered := typedmap.NewOrdered[string, int]()
+	ordered.Store(`a`, 1)
+	ordered.Store(`b`, 2)
+	var removed []typedmap.RemovalReason
+	lru := typedmap.NewLRU[string, int](10, typedmap.WithOnRemove(func(key string, value int, reason typedmap.RemovalReason) {
+		removed = append(removed, reason)
+	}))
+	lru.Store(`a`, 1)
+
+	var txn typedmap.Txn
+	txOrdered, txLRU := typedmap.Enlist(&txn, ordered), typedmap.Enlist(&txn, lru)
+	err := txn.Run(func() error {
+		txOrdered.Delete(`a`)
+		txOrdered.Store(`a`, 3)
+		txLRU.Delete(`a`)
+		txLRU.Store(`a`, 2)
+		return nil
+	})
+	if keys := ordered.Keys(); err != nil || !slices.Equal(keys, []string{`b`, `a`}) {
+		t.Errorf("Txn.Run() expected a to be inserted as the last entry, got %v, %v", keys, err)
+	}
+	if v, ok := lru.Load(`a`); !ok || v != 2 || !slices.Equal(removed, []typedmap.RemovalReason{typedmap.ReasonDeleted}) {
+		t.Errorf("Txn.Run() expected a to be deleted then stored, got %d, %v, %v", v, ok, removed)
+	}
+
+	// a key stored then deleted is only deleted.
+	removed = nil
+	err = lru.Transaction(func(tx typedmap.Tx[string, int]) error {
+		tx.Store(`a`, 4)
+		tx.Delete(`a`)
+		return nil
+	})
+	if err != nil || lru.Has(`a`) || !slices.Equal(removed, []typedmap.RemovalReason{typedmap.ReasonDeleted}) {
+		t.Errorf("Transaction() expected a to be deleted, got %v, %v", removed, err)
+	}
+}
+
+func TestPersistent(t *testing.T) {
+	m := typedmap.New[string, int]()
+	m.Store(`a`, 1)
+	m.Store(`b`, 2)
+
+	v1 := typedmap.NewPersistent(m)
+	v2 := v1.With(`c`, 3).Without(`a`)
+	if v1.Len() != 2 || !v1.Has(`a`) || v1.Has(`c`) {
+		t.Errorf("With() expected the previous version to be left untouched, got %v", maps.Collect(v1.All()))
+	}
+	if c, ok := v2.Load(`c`); !ok || c != 3 || v2.Has(`a`) || v2.Len() != 2 {
+		t.Errorf("With() expected b and c, got %v", maps.Collect(v2.All()))
+	}
+	if keys := slices.Sorted(v2.KeysSeq()); !slices.Equal(keys, []string{`b`, `c`}) {
+		t.Errorf("KeysSeq() expected b and c, got %v", keys)
+	}
+	if values := slices.Sorted(v2.ValuesSeq()); !slices.Equal(values, []int{2, 3}) {
+		t.Errorf("ValuesSeq() expected 2 and 3, got %v", values)
+	}
+	var n int
+	v2.Range(func(string, int) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Range() expected to stop after 1 entry, got %d", n)
+	}
+
+	c := v2.TypedMap(typedmap.WithEqual(func(a, b int) bool { return a == b }))
+	if !maps.Equal(maps.Collect(c.All()), map[string]int{`b`: 2, `c`: 3}) || !c.CompareAndSwap(`b`, 2, 4) {
+		t.Errorf("TypedMap() expected b and c, got %v", maps.Collect(c.All()))
+	}
+	if typedmap.NewPersistent[string, int](nil).Len() != 0 {
+		t.Errorf("NewPersistent(nil) expected an empty map")
+	}
+	// NewPersistent only reads m, so it can run while m is being read.
+	for range m.All() {
+		if typedmap.NewPersistent(m).Len() != 2 {
+			t.Errorf("NewPersistent() expected a and b while m is read")
+		}
+		break
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	m := typedmap.NewOrdered[string, int]()
+	moved := typedmap.NewOrdered[string, int](typedmap.WithMoveToBack())
+	for _, o := range []typedmap.OrderedMap[string, int]{m, moved} {
+		o.Store(`a`, 1)
+		o.Store(`b`, 2)
+		o.Store(`a`, 3)
+	}
+	if keys := m.Keys(); !slices.Equal(keys, []string{`a`, `b`}) {
+		t.Errorf("NewOrdered() expected keys [a b], got %v", keys)
+	}
+	if keys := moved.Keys(); !slices.Equal(keys, []string{`b`, `a`}) {
+		t.Errorf("NewOrdered(WithMoveToBack()) expected keys [b a], got %v", keys)
+	}
+	if key, value, ok := moved.PopFirst(); !ok || key != `b` || value != 2 {
+		t.Errorf("PopFirst() expected b=2, got %s=%d, %v", key, value, ok)
+	}
+}
+
+func TestSorted(t *testing.T) {
+	m := typedmap.NewSorted[int, string]()
+	reverse := typedmap.NewSortedFunc[int, string](func(a, b int) int { return b - a })
+	for _, s := range []typedmap.SortedMap[int, string]{m, reverse} {
+		for _, key := range []int{3, 1, 2} {
+			s.Store(key, strconv.Itoa(key))
+		}
+	}
+	if keys := m.Keys(); !slices.Equal(keys, []int{1, 2, 3}) {
+		t.Errorf("NewSorted() expected keys [1 2 3], got %v", keys)
+	}
+	if keys := reverse.Keys(); !slices.Equal(keys, []int{3, 2, 1}) {
+		t.Errorf("NewSortedFunc() expected keys [3 2 1], got %v", keys)
+	}
+	if key, _, ok := m.Floor(0); ok {
+		t.Errorf("Floor() expected no key, got %d", key)
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	type user struct {
+		email  string
+		tenant int
+		tags   []string
+	}
+	m := typedmap.NewIndexed[int, user]()
+	m.Store(1, user{`a`, 1, []string{`x`, `y`}})
+	byEmail, err := typedmap.AddUniqueIndex(m, `email`, func(u user) string { return u.email })
+	if err != nil {
+		t.Errorf("AddUniqueIndex() unexpected error %v", err)
+	}
+	byTenant := typedmap.AddIndex(m, `tenant`, func(u user) int { return u.tenant })
+	byTag := typedmap.AddMultiIndex(m, `tag`, func(u user) []string { return u.tags })
+	m.Store(2, user{`b`, 1, []string{`y`}})
+	if err := m.TryStore(3, user{`a`, 2, nil}); !errors.Is(err, typedmap.ErrDuplicate) {
+		t.Errorf("TryStore() expected ErrDuplicate, got %v", err)
+	}
+	if key, _, ok := byEmail.Lookup(`b`); !ok || key != 2 {
+		t.Errorf("Lookup() expected 2, got %d, %v", key, ok)
+	}
+	if key, _, ok := m.LookupBy(`email`, `b`); !ok || key != 2 {
+		t.Errorf("LookupBy() expected 2, got %d, %v", key, ok)
+	}
+	if keys := slices.Sorted(maps.Keys(maps.Collect(byTenant.Range(1)))); !slices.Equal(keys, []int{1, 2}) {
+		t.Errorf("Range() expected keys [1 2], got %v", keys)
+	}
+	if keys := slices.Sorted(maps.Keys(maps.Collect(m.RangeBy(`tenant`, 1)))); !slices.Equal(keys, []int{1, 2}) {
+		t.Errorf("RangeBy() expected keys [1 2], got %v", keys)
+	}
+	if n := byTag.Count(`y`); n != 2 {
+		t.Errorf("Count() expected 2, got %d", n)
+	}
+	if _, err := typedmap.AddUniqueIndex(m, `unique tenant`, func(u user) int { return u.tenant }); !errors.Is(err, typedmap.ErrDuplicate) {
+		t.Errorf("AddUniqueIndex() expected ErrDuplicate, got %v", err)
+	}
+
+	// the writes of TypedMap panic with ErrDuplicate, leaving the map untouched.
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, typedmap.ErrDuplicate) {
+				t.Errorf("Store() expected a panic with ErrDuplicate, got %v", err)
+			}
+		}()
+		var tm typedmap.TypedMap[int, user] = m
+		tm.Store(3, user{`a`, 2, nil})
+	}()
+	// the writes of a transaction are checked together: two users can exchange their emails.
+	err = m.Transaction(func(tx typedmap.Tx[int, user]) error {
+		a, _ := tx.Load(1)
+		b, _ := tx.Load(2)
+		a.email, b.email = b.email, a.email
+		tx.Store(1, a)
+		tx.Store(2, b)
+		return nil
+	})
+	if key, _, _ := byEmail.Lookup(`a`); err != nil || key != 2 || m.Has(3) {
+		t.Errorf("Transaction() expected a to belong to 2, got %d, %v", key, err)
+	}
+	err = m.Transaction(func(tx typedmap.Tx[int, user]) error {
+		tx.Store(3, user{email: `a`})
+		return nil
+	})
+	if !errors.Is(err, typedmap.ErrDuplicate) || m.Has(3) {
+		t.Errorf("Transaction() expected ErrDuplicate, got %v", err)
+	}
+
+	for i, f := range []func(){
+		func() {
+			typedmap.AddIndex[int, user](struct{ typedmap.IndexedMap[int, user] }{m}, `other`, func(u user) int { return u.tenant })
+		},
+		func() { typedmap.AddIndex(m, `tenant`, func(u user) int { return u.tenant }) },
+		func() { m.LookupBy(`name`, `a`) },
+		func() { m.LookupBy(`email`, 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: expected a panic", i)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestRanked(t *testing.T) {
+	type player struct {
+		name  string
+		score int
+	}
+	m := typedmap.NewRanked[string, int]()
+	players := typedmap.NewRankedFunc[string](func(p player) int { return p.score })
+	for i, name := range []string{`a`, `b`, `c`} {
+		m.Store(name, i)
+		players.Store(name, player{name, i})
+	}
+	m.Store(`a`, 10)
+	if keys, _ := m.TopN(2); !slices.Equal(keys, []string{`a`, `c`}) {
+		t.Errorf("NewRanked() expected top keys [a c], got %v", keys)
+	}
+	if rank, ok := m.Rank(`b`); !ok || rank != 2 {
+		t.Errorf("Rank() expected 2, got %d, %v", rank, ok)
+	}
+	if keys := slices.Collect(maps.Keys(maps.Collect(players.ScoreRange(1, 3)))); len(keys) != 2 {
+		t.Errorf("NewRankedFunc() expected 2 players with a score in [1, 3), got %v", keys)
+	}
+}
+
+func TestSet(t *testing.T) {
+	for name, newSet := range map[string]func(items ...int) *typedmap.Set[int]{
+		"NewSet": typedmap.NewSet[int],
+		"NewShardedSet": func(items ...int) *typedmap.Set[int] {
+			return typedmap.NewShardedSet(4, items...)
+		},
+	} {
+		s := newSet(1, 2, 3)
+		if !s.AddIfAbsent(4) || s.AddIfAbsent(4) {
+			t.Errorf("%s: AddIfAbsent() expected 4 to be added once", name)
+		}
+		s.Add(5)
+		if !s.Remove(5) || s.Remove(5) || s.Contains(5) {
+			t.Errorf("%s: Remove() expected 5 to be removed once", name)
+		}
+		if s.Len() != 4 || !s.Contains(1) {
+			t.Errorf("%s: expected [1 2 3 4], got %v", name, s.Items())
+		}
+		other := newSet(3, 4, 5)
+		// check expects set to hold the sorted items of expect.
+		check := func(op string, set *typedmap.Set[int], expect []int) {
+			t.Helper()
+			if items := slices.Sorted(set.All()); !slices.Equal(items, expect) {
+				t.Errorf("%s: %s expected %v, got %v", name, op, expect, items)
+			}
+		}
+		check("Union()", s.Union(other), []int{1, 2, 3, 4, 5})
+		check("Intersect()", s.Intersect(other), []int{3, 4})
+		check("Intersect()", other.Intersect(s), []int{3, 4})
+		check("Difference()", s.Difference(other), []int{1, 2})
+		check("Difference()", s.Difference(s), nil)
+		check("Union()", s.Union(s), []int{1, 2, 3, 4})
+		if s.IsSubset(other) || !s.Intersect(other).IsSubset(other) || !s.IsSubset(s) || s.IsSubset(newSet(1)) {
+			t.Errorf("%s: IsSubset() unexpected result", name)
+		}
+
+		// set algebra on the same sets in opposite orders must not deadlock.
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if i%2 == 0 {
+						s.Union(other)
+					} else {
+						other.Difference(s)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		// the set algebra only takes the read locks, so it can run while the sets are being read.
+		for range s.All() {
+			if s.Union(other).Len() != 5 || !s.IsSubset(s) {
+				t.Errorf("%s: Union() expected to run while the set is read", name)
+			}
+			break
+		}
+		ctx, start := context.WithCancel(context.Background())
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-ctx.Done()
+				for j := 0; j < 100; j++ {
+					switch i % 4 {
+					case 0:
+						s.Intersect(other)
+					case 1:
+						other.Union(s)
+					case 2:
+						s.Contains(j)
+						other.Items()
+					default:
+						s.Add(j + 10)
+						s.Remove(j + 10)
+					}
+				}
+			}()
+		}
+		start()
+		wg.Wait()
+		if items := slices.Sorted(s.All()); !slices.Equal(items, []int{1, 2, 3, 4}) {
+			t.Errorf("%s: expected [1 2 3 4], got %v", name, items)
+		}
+		s.Clear()
+		if s.Len() != 0 {
+			t.Errorf("%s: Clear() expected an empty set, got %v", name, s.Items())
+		}
+	}
+}
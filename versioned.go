@@ -0,0 +1,38 @@
+package typedmap
+
+import "github.com/thetechpanda/typedmap/internal/versioned"
+
+// Versioned is a generic interface that provides optimistic concurrency control over the entries of a map.
+//
+// Every entry carries a version, changed each time the entry is written. Versions are monotonically increasing
+// and never reused, even once a key is deleted and stored again, so that a read-modify-write cycle can load a value,
+// perform slow work without holding any lock and store the result only if the entry has not been changed meanwhile.
+// Unlike CompareAndSwap, versions work with values of any type, comparable or not.
+type Versioned[K, V any] interface {
+	// LoadVersioned returns the value stored in the map for a key along with its version.
+	// If the key is not present version is 0 and ok is false.
+	LoadVersioned(key K) (value V, version uint64, ok bool)
+	// StoreIfVersion sets the value for a key only if the version of the entry is still version,
+	// a version of 0 sets the value only if the key is not present.
+	// It returns the new version of the entry, the stored result reports whether the value has been set.
+	StoreIfVersion(key K, value V, version uint64) (newVersion uint64, stored bool)
+	// DeleteIfVersion removes the key from the map only if the version of the entry is still version.
+	// The deleted result reports whether the key has been removed.
+	DeleteIfVersion(key K, version uint64) (deleted bool)
+}
+
+// VersionedMap is a TypedMap whose entries carry a version, see Versioned.
+//
+// Every operation writing an entry (Store, LoadOrStore, Swap, CompareAndSwap, Update, UpdateRange, Compute and StoreIfVersion) gives it a new version,
+// Exclusive gives a new version to the new entries and to the entries whose value has changed.
+type VersionedMap[K comparable, V any] interface {
+	TypedMap[K, V]
+	Versioned[K, V]
+}
+
+// NewVersioned returns a new VersionedMap.
+//
+// Use WithEqual to set how CompareAndSwap and CompareAndDelete compare values.
+func NewVersioned[K comparable, V any](opts ...Option) VersionedMap[K, V] {
+	return versioned.New[K](nil, equal[V](withoutOnRemove(opts)))
+}